@@ -0,0 +1,85 @@
+// Command jwtkeys lists and rotates the key pairs tokens are signed with.
+//
+//	go run ./cmd/jwtkeys list
+//	go run ./cmd/jwtkeys rotate [-alg EdDSA|RS256] [-activate-in 10m]
+//
+// A rotated key is published in /.well-known/jwks.json right away but only
+// starts signing after -activate-in, so services caching the key set have
+// fetched it by then. Running servers pick it up on their next reload.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/sol-tad/Blog-post-Api/config"
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"github.com/sol-tad/Blog-post-Api/infrastructure"
+	"github.com/sol-tad/Blog-post-Api/repository"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	// Settings may also come from the environment alone
+	_ = godotenv.Load()
+
+	switch os.Args[1] {
+	case "list":
+		config.ConnectDB()
+		list(repository.NewSigningKeyRepository(config.SigningKeyCollection))
+	case "rotate":
+		flags := flag.NewFlagSet("rotate", flag.ExitOnError)
+		algorithm := flags.String("alg", domain.SigningAlgorithmEdDSA, "algorithm of the new key: EdDSA or RS256")
+		activateIn := flags.Duration("activate-in", 10*time.Minute, "how long to publish the new key before signing with it")
+		flags.Parse(os.Args[2:])
+
+		kek, err := infrastructure.KeyEncryptionKeyFromEnv()
+		if err != nil {
+			log.Fatal(err)
+		}
+		config.ConnectDB()
+		repo := repository.NewSigningKeyRepository(config.SigningKeyCollection)
+		key, err := infrastructure.RotateSigningKey(context.Background(), repo, kek, *algorithm, *activateIn)
+		if err != nil {
+			log.Fatal("rotating signing key failed: ", err)
+		}
+		fmt.Printf("created %s key %s, signing from %s\n", key.Algorithm, key.ID, key.NotBefore.Format(time.RFC3339))
+		list(repo)
+	default:
+		usage()
+	}
+}
+
+func list(repo domain.SigningKeyRepository) {
+	now := time.Now()
+	keys, err := repo.List(context.Background(), now)
+	if err != nil {
+		log.Fatal("listing signing keys failed: ", err)
+	}
+	for _, key := range keys {
+		state := "verifying"
+		switch {
+		case key.CanSign(now):
+			state = "signing"
+		case now.Before(key.NotBefore):
+			state = "pending"
+		}
+		expires := "never"
+		if key.ExpiresAt != nil {
+			expires = key.ExpiresAt.Format(time.RFC3339)
+		}
+		fmt.Printf("%-20s %-6s %-10s from %s, expires %s\n", key.ID, key.Algorithm, state, key.NotBefore.Format(time.RFC3339), expires)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: jwtkeys list | rotate [-alg EdDSA|RS256] [-activate-in 10m]")
+	os.Exit(2)
+}
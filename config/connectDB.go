@@ -14,6 +14,21 @@ var UserCollection *mongo.Collection
 var BlogCollection *mongo.Collection
 var InteractionCollection *mongo.Collection
 var CommentCollection *mongo.Collection
+var RevisionCollection *mongo.Collection
+var BookmarkCollection *mongo.Collection
+var ReadingListCollection *mongo.Collection
+var BookmarkPositionCollection *mongo.Collection
+var FollowCollection *mongo.Collection
+var AIUsageCollection *mongo.Collection
+var PromptTemplateCollection *mongo.Collection
+var TranslationCollection *mongo.Collection
+var EmbeddingCollection *mongo.Collection
+var SessionCollection *mongo.Collection
+var RevokedTokenCollection *mongo.Collection
+var SigningKeyCollection *mongo.Collection
+var TwoFactorCollection *mongo.Collection
+var SettingsCollection *mongo.Collection
+var RoleChangeCollection *mongo.Collection
 
 func ConnectDB(){
     MONGODB_URI := os.Getenv("MONGODB_URI")
@@ -31,6 +46,21 @@ func ConnectDB(){
 	BlogCollection=client.Database("blogDB").Collection("blogs")
 	InteractionCollection = client.Database("blogDB").Collection("interactions")
 	CommentCollection = client.Database("blogDB").Collection("comments")
+	RevisionCollection = client.Database("blogDB").Collection("blog_revisions")
+	BookmarkCollection = client.Database("blogDB").Collection("bookmarks")
+	ReadingListCollection = client.Database("blogDB").Collection("reading_lists")
+	BookmarkPositionCollection = client.Database("blogDB").Collection("bookmark_positions")
+	FollowCollection = client.Database("blogDB").Collection("follows")
+	AIUsageCollection = client.Database("blogDB").Collection("ai_usage")
+	PromptTemplateCollection = client.Database("blogDB").Collection("prompt_templates")
+	TranslationCollection = client.Database("blogDB").Collection("blog_translations")
+	EmbeddingCollection = client.Database("blogDB").Collection("blog_embeddings")
+	SessionCollection = client.Database("blogDB").Collection("sessions")
+	RevokedTokenCollection = client.Database("blogDB").Collection("revoked_tokens")
+	SigningKeyCollection = client.Database("blogDB").Collection("signing_keys")
+	TwoFactorCollection = client.Database("blogDB").Collection("two_factor")
+	SettingsCollection = client.Database("blogDB").Collection("settings")
+	RoleChangeCollection = client.Database("blogDB").Collection("role_changes")
 	log.Println("Connected to MongoDB")
 
 }
\ No newline at end of file
@@ -1,11 +1,17 @@
 package controllers
 
 import (
+	"context"
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sol-tad/Blog-post-Api/domain"
 	"github.com/sol-tad/Blog-post-Api/usecase"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type AIController struct {
@@ -25,9 +31,9 @@ func (c *AIController) GenerateBlog(ctx *gin.Context) {
         return
     }
 
-    content, err := c.aiUC.GenerateBlog(params)
+    content, err := c.aiUC.GenerateBlog(ctx.Request.Context(), params, ctx.GetString("id"), ctx.GetString("role"))
     if err != nil {
-        ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Generation failed: " + err.Error()})
+        respondAIError(ctx, err, "Generation failed: " + err.Error())
         return
     }
 
@@ -35,10 +41,85 @@ func (c *AIController) GenerateBlog(ctx *gin.Context) {
 }
 
 
+// Generate blog with parameters, streaming it as Server-Sent Events. Every
+// "token" event carries a piece of text; a final "done" event carries the
+// whole post, or an "error" event reports a failure midway. Closing the
+// connection cancels generation.
+func (c *AIController) GenerateBlogStream(ctx *gin.Context) {
+    var params domain.GenerationParams
+
+    if err := ctx.ShouldBindJSON(&params); err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    started := false
+    content, err := c.aiUC.GenerateBlogStream(ctx.Request.Context(), params, ctx.GetString("id"), ctx.GetString("role"), func(chunk string) error {
+        if !started {
+            started = true
+            startEventStream(ctx)
+        }
+        ctx.SSEvent("token", gin.H{"text": chunk})
+        ctx.Writer.Flush()
+        return ctx.Request.Context().Err()
+    })
+
+    if ctx.Request.Context().Err() != nil {
+        // the client went away, there is nobody left to answer
+        return
+    }
+    if err != nil {
+        if !started {
+            respondAIError(ctx, err, "Generation failed: " + err.Error())
+            return
+        }
+        ctx.SSEvent("error", gin.H{"error": "Generation failed: " + err.Error()})
+        ctx.Writer.Flush()
+        return
+    }
+
+    if !started {
+        startEventStream(ctx)
+    }
+    ctx.SSEvent("done", domain.AIResponse{Content: content})
+    ctx.Writer.Flush()
+}
+
+// startEventStream sends the headers of a Server-Sent Events response. The
+// status can no longer change once the first event is written.
+func startEventStream(ctx *gin.Context) {
+    ctx.Header("Content-Type", "text/event-stream")
+    ctx.Header("Cache-Control", "no-cache")
+    ctx.Header("Connection", "keep-alive")
+    ctx.Header("X-Accel-Buffering", "no")
+    ctx.Status(http.StatusOK)
+}
+
+
+// Generate a blog and save it as an unreviewed draft owned by the caller
+func (c *AIController) GenerateDraft(ctx *gin.Context) {
+    var params domain.GenerationParams
+
+    if err := ctx.ShouldBindJSON(&params); err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    blog, err := c.aiUC.GenerateDraft(ctx.Request.Context(), params, ctx.GetString("id"), ctx.GetString("role"))
+    if err != nil {
+        respondAIError(ctx, err, "Generation failed: " + err.Error())
+        return
+    }
+
+    ctx.JSON(http.StatusCreated, blog)
+}
+
+
 // Summarize existing content
 func (c *AIController) SummarizeBlog(ctx *gin.Context) {
     var request struct {
-        Content string `json:"content" binding:"required"`
+        Content  string `json:"content" binding:"required"`
+        Template string `json:"template"`
     }
     
     if err := ctx.ShouldBindJSON(&request); err != nil {
@@ -46,11 +127,176 @@ func (c *AIController) SummarizeBlog(ctx *gin.Context) {
         return
     }
 
-    summary, err := c.aiUC.SummarizeBLog(request.Content)
+    summary, err := c.aiUC.SummarizeBLog(ctx.Request.Context(), request.Content, request.Template, ctx.GetString("id"), ctx.GetString("role"))
     if err != nil {
-        ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Summarization failed"})
+        respondAIError(ctx, err, "Summarization failed: " + err.Error())
         return
     }
 
     ctx.JSON(http.StatusOK, domain.AIResponse{Content: summary})
-}
\ No newline at end of file
+}
+
+// Summarize a stored blog and save the summary on it; ?refresh=true
+// replaces a summary that is still current and ?template= picks a prompt
+// template
+func (c *AIController) SummarizeStoredBlog(ctx *gin.Context) {
+    refresh := ctx.Query("refresh") == "true"
+    summary, err := c.aiUC.SummarizeStoredBlog(ctx.Request.Context(), ctx.Param("id"), ctx.GetString("id"), ctx.GetString("role"), ctx.Query("template"), refresh)
+    if err != nil {
+        respondAIError(ctx, err, "Summarization failed: " + err.Error())
+        return
+    }
+
+    ctx.JSON(http.StatusOK, summary)
+}
+
+
+// Suggest tags for a stored blog; ?apply=true adds them to the blog
+func (c *AIController) SuggestTags(ctx *gin.Context) {
+    apply := ctx.Query("apply") == "true"
+    tags, blog, err := c.aiUC.SuggestTags(ctx.Request.Context(), ctx.Param("id"), ctx.GetString("id"), ctx.GetString("role"), apply)
+    if err != nil {
+        respondAIError(ctx, err, err.Error())
+        return
+    }
+
+    response := gin.H{"suggestions": tags, "applied": apply}
+    if apply {
+        response["blog"] = blog
+    }
+    ctx.JSON(http.StatusOK, response)
+}
+
+// Suggest titles for a stored blog; ?apply=true renames the blog to the
+// first suggestion
+func (c *AIController) SuggestTitles(ctx *gin.Context) {
+    apply := ctx.Query("apply") == "true"
+    titles, blog, err := c.aiUC.SuggestTitles(ctx.Request.Context(), ctx.Param("id"), ctx.GetString("id"), ctx.GetString("role"), apply)
+    if err != nil {
+        respondAIError(ctx, err, err.Error())
+        return
+    }
+
+    response := gin.H{"suggestions": titles, "applied": apply}
+    if apply {
+        response["blog"] = blog
+    }
+    ctx.JSON(http.StatusOK, response)
+}
+
+
+// Usage of the caller this month against their quota
+func (c *AIController) GetMyUsage(ctx *gin.Context) {
+    usage, err := c.aiUC.GetMyUsage(ctx.GetString("id"), ctx.GetString("role"))
+    if err != nil {
+        respondAIError(ctx, err, err.Error())
+        return
+    }
+
+    ctx.JSON(http.StatusOK, usage)
+}
+
+// Usage per user and operation, for admins. from and to accept RFC3339 or
+// YYYY-MM-DD dates and default to the current month.
+func (c *AIController) GetUsageReport(ctx *gin.Context) {
+    now := time.Now().UTC()
+    filter := domain.AIUsageFilter{
+        From: time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC),
+    }
+
+    if userID := ctx.Query("user_id"); userID != "" {
+        id, err := primitive.ObjectIDFromHex(userID)
+        if err != nil {
+            ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+            return
+        }
+        filter.UserID = id
+    }
+    for param, target := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+        value := ctx.Query(param)
+        if value == "" {
+            continue
+        }
+        parsed, err := parseReportDate(value)
+        if err != nil {
+            ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + param + " date"})
+            return
+        }
+        *target = parsed
+    }
+
+    report, err := c.aiUC.GetUsageReport(filter)
+    if err != nil {
+        ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx.JSON(http.StatusOK, gin.H{
+        "from": filter.From,
+        "to":   filter.To,
+        "data": report,
+    })
+}
+
+// Machine translate a stored blog; ?refresh=true translates it again even
+// if a current or hand edited translation exists
+func (c *AIController) TranslateBlog(ctx *gin.Context) {
+    var req struct {
+        Language string `json:"language" binding:"required"`
+    }
+    if err := ctx.ShouldBindJSON(&req); err != nil {
+        ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    refresh := ctx.Query("refresh") == "true"
+    translation, err := c.aiUC.TranslateBlog(ctx.Request.Context(), ctx.Param("id"), req.Language, ctx.GetString("id"), ctx.GetString("role"), refresh)
+    if err != nil {
+        respondAIError(ctx, err, err.Error())
+        return
+    }
+
+    ctx.JSON(http.StatusOK, translation)
+}
+
+func parseReportDate(value string) (time.Time, error) {
+    if t, err := time.Parse(time.RFC3339, value); err == nil {
+        return t, nil
+    }
+    return time.Parse("2006-01-02", value)
+}
+
+
+// respondAIError answers with the status for err and, when the provider
+// said how long to wait, a Retry-After header
+func respondAIError(ctx *gin.Context, err error, message string) {
+    var providerErr *domain.AIProviderError
+    if errors.As(err, &providerErr) && providerErr.RetryAfter > 0 {
+        ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(providerErr.RetryAfter.Seconds()))))
+    }
+    ctx.JSON(aiErrorStatus(err), gin.H{"error": message})
+}
+
+// aiErrorStatus maps AI usecase errors to HTTP status codes
+func aiErrorStatus(err error) int {
+    switch {
+    case errors.Is(err, usecase.ErrAIUnavailable), errors.Is(err, domain.ErrAIProviderUnavailable),
+        errors.Is(err, domain.ErrAICircuitOpen):
+        return http.StatusServiceUnavailable
+    case errors.Is(err, usecase.ErrAIQuotaExceeded), errors.Is(err, domain.ErrAIRateLimited):
+        return http.StatusTooManyRequests
+    case errors.Is(err, domain.ErrAITimeout), errors.Is(err, context.DeadlineExceeded):
+        return http.StatusGatewayTimeout
+    case errors.Is(err, usecase.ErrInvalidAIUser):
+        return http.StatusUnauthorized
+    case errors.Is(err, usecase.ErrTemplateNotFound), errors.Is(err, usecase.ErrInvalidTemplate),
+        errors.Is(err, usecase.ErrTemplateKind), errors.Is(err, usecase.ErrTemplateExists):
+        return templateErrorStatus(err)
+    case errors.Is(err, usecase.ErrEmptyAIOutput):
+        return http.StatusBadGateway
+    case errors.Is(err, usecase.ErrInvalidLength):
+        return http.StatusBadRequest
+    default:
+        return blogErrorStatus(err)
+    }
+}
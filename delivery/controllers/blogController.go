@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -45,12 +46,15 @@ func (bc *BlogController) CreateBlog(c *gin.Context) {
 	}
 	
 	blog.AuthorID = objID
+	// AI provenance and summaries are only recorded by the AI endpoints
+	blog.AI = nil
+	blog.Summary = nil
 
 	blog.CreatedAt = time.Now()
 	blog.UpdatedAt = time.Now()
 
 	if err := bc.BlogUsecase.StoreBlog(&blog); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(blogErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -64,13 +68,45 @@ func (bc *BlogController) GetBlog(c *gin.Context) {
 		return
 	}
 
-	blog := bc.BlogUsecase.ViewBlogByID(id) // If your function requires userID, pass it properly or "" if not needed.
+	format, ok := contentFormat(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be raw, html or both"})
+		return
+	}
+
+	// ?lang picks a translation; otherwise Accept-Language is negotiated
+	lang := c.Query("lang")
+	if lang != "" {
+		var valid bool
+		if lang, valid = domain.NormalizeLanguage(lang); !valid {
+			c.JSON(http.StatusBadRequest, gin.H{"error": usecase.ErrInvalidLanguage.Error()})
+			return
+		}
+	}
+
+	blog := bc.BlogUsecase.ViewBlogInLanguage(id, c.GetString("id"), c.GetString("role"), lang, c.GetHeader("Accept-Language"))
 	if blog == nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Blog not found"})
 		return
 	}
 
-	c.JSON(http.StatusOK, blog)
+	// Old slugs redirect to the blog's current slug
+	if id != blog.ID.Hex() && id != blog.Slug && blog.Slug != "" {
+		location := "/blogs/" + blog.Slug
+		if c.Request.URL.RawQuery != "" {
+			location += "?" + c.Request.URL.RawQuery
+		}
+		c.Redirect(http.StatusMovedPermanently, location)
+		return
+	}
+
+	c.Header("Vary", "Accept-Language")
+	if blog.Translation != nil {
+		c.Header("Content-Language", blog.Translation.Language)
+	} else {
+		c.Header("Content-Language", blog.CurrentLanguage())
+	}
+	c.JSON(http.StatusOK, blog.WithFormat(format))
 }
 
 
@@ -83,55 +119,20 @@ func (bc *BlogController) UpdateBlog(c *gin.Context) {
 		return
 	}
 	
-	// Get existing blog
-	existingBlog:= bc.BlogUsecase.ViewBlogByID(id)
-	if existingBlog== nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "blog not found"})
-		return
-	}
-	
-	// Verify ownership
-	userID := c.GetString("id")
-	if existingBlog.AuthorID.Hex() != userID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "you can only update your own blogs"})
-		return
-	}
-	
-	// Update allowed fields
-	existingBlog.Title = updatedBlog.Title
-	existingBlog.Content = updatedBlog.Content
-	existingBlog.Tags = updatedBlog.Tags
-	existingBlog.UpdatedAt = time.Now()
-	
-	if err := bc.BlogUsecase.UpdateBlog(id,existingBlog); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	blog, err := bc.BlogUsecase.EditBlog(id, c.GetString("id"), c.GetString("role"), &updatedBlog)
+	if err != nil {
+		c.JSON(blogErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	
-	c.JSON(http.StatusOK, existingBlog)
+	c.JSON(http.StatusOK, blog)
 }
 
 func (bc *BlogController) DeleteBlog(c *gin.Context) {
 	id := c.Param("id")
 	
-	// Get existing blog
-	blog := bc.BlogUsecase.ViewBlogByID(id)
-	if blog == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "blog not found"})
-		return
-	}
-	
-	// Verify ownership or admin role
-	userID := c.GetString("id")
-	userRole := c.GetString("role")
-	
-	if blog.AuthorID.Hex() != userID && userRole != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "you are not authorized to delete this blog"})
-		return
-	}
-	
-	if err := bc.BlogUsecase.DeleteBlog(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := bc.BlogUsecase.DeleteBlog(id, c.GetString("id"), c.GetString("role")); err != nil {
+		c.JSON(blogErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	
@@ -150,6 +151,20 @@ func (bc *BlogController) ListBlogs(c *gin.Context) {
 		SortOrder: c.DefaultQuery("sort_order", "desc"),
 	}
 	
+	// Unpublished blogs are only listed for their author or roles that may
+	// see every blog
+	if viewerID, err := primitive.ObjectIDFromHex(c.GetString("id")); err == nil {
+		filter.ViewerID = viewerID
+	}
+	filter.IncludeUnpublished = domain.RoleHas(c.GetString("role"), domain.PermBlogsViewUnpublished)
+	filter.Status = c.Query("status")
+
+	format, ok := contentFormat(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be raw, html or both"})
+		return
+	}
+
 	// Parse date filters
 	if startDate := c.Query("start_date"); startDate != "" {
 		filter.StartDate, _ = time.Parse(time.RFC3339, startDate)
@@ -164,6 +179,31 @@ func (bc *BlogController) ListBlogs(c *gin.Context) {
 		return
 	}
 	
+	c.JSON(http.StatusOK, gin.H{
+		"data": formatBlogs(blogs, format),
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// ListMyBlogs lists the authenticated user's own blogs in any status
+func (bc *BlogController) ListMyBlogs(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	blogs, total, err := bc.BlogUsecase.ListMyBlogs(c.GetString("id"), page, limit, c.Query("status"))
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidBlogStatus) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch blogs"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"data": blogs,
 		"pagination": gin.H{
@@ -172,4 +212,280 @@ func (bc *BlogController) ListBlogs(c *gin.Context) {
 			"total": total,
 		},
 	})
-}
\ No newline at end of file
+}
+
+func (bc *BlogController) SubmitBlog(c *gin.Context) {
+	bc.changeStatus(c, domain.BlogStatusInReview)
+}
+
+func (bc *BlogController) PublishBlog(c *gin.Context) {
+	bc.changeStatus(c, domain.BlogStatusPublished)
+}
+
+func (bc *BlogController) ArchiveBlog(c *gin.Context) {
+	bc.changeStatus(c, domain.BlogStatusArchived)
+}
+
+func (bc *BlogController) UnpublishBlog(c *gin.Context) {
+	bc.changeStatus(c, domain.BlogStatusDraft)
+}
+
+func (bc *BlogController) changeStatus(c *gin.Context, status string) {
+	blog, err := bc.BlogUsecase.ChangeStatus(c.Param("id"), c.GetString("id"), c.GetString("role"), status)
+	if err != nil {
+		c.JSON(blogErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, blog)
+}
+
+type scheduleRequest struct {
+	PublishAt time.Time `json:"publish_at" binding:"required"`
+}
+
+// ScheduleBlog schedules a draft or in-review blog to be published later
+func (bc *BlogController) ScheduleBlog(c *gin.Context) {
+	var req scheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	blog, err := bc.BlogUsecase.SchedulePublish(c.Param("id"), c.GetString("id"), c.GetString("role"), req.PublishAt)
+	if err != nil {
+		c.JSON(blogErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, blog)
+}
+
+// RescheduleBlog moves the publish time of a scheduled blog
+func (bc *BlogController) RescheduleBlog(c *gin.Context) {
+	var req scheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	blog, err := bc.BlogUsecase.ReschedulePublish(c.Param("id"), c.GetString("id"), c.GetString("role"), req.PublishAt)
+	if err != nil {
+		c.JSON(blogErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, blog)
+}
+
+// CancelSchedule returns a scheduled blog to draft
+func (bc *BlogController) CancelSchedule(c *gin.Context) {
+	blog, err := bc.BlogUsecase.CancelSchedule(c.Param("id"), c.GetString("id"), c.GetString("role"))
+	if err != nil {
+		c.JSON(blogErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, blog)
+}
+
+func (bc *BlogController) ListRevisions(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	revisions, err := bc.BlogUsecase.ListRevisions(c.Param("id"), c.GetString("id"), c.GetString("role"), page, limit)
+	if err != nil {
+		c.JSON(blogErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": revisions,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+		},
+	})
+}
+
+func (bc *BlogController) GetRevision(c *gin.Context) {
+	rev, err := strconv.Atoi(c.Param("rev"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid revision number"})
+		return
+	}
+
+	revision, err := bc.BlogUsecase.GetRevision(c.Param("id"), c.GetString("id"), c.GetString("role"), rev)
+	if err != nil {
+		c.JSON(blogErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, revision)
+}
+
+// DiffRevisions compares revision "from" with revision "to", or with the
+// current blog when "to" is omitted
+func (bc *BlogController) DiffRevisions(c *gin.Context) {
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from revision"})
+		return
+	}
+	to, err := strconv.Atoi(c.DefaultQuery("to", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to revision"})
+		return
+	}
+
+	diff, err := bc.BlogUsecase.DiffRevisions(c.Param("id"), c.GetString("id"), c.GetString("role"), from, to)
+	if err != nil {
+		c.JSON(blogErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
+func (bc *BlogController) RestoreRevision(c *gin.Context) {
+	rev, err := strconv.Atoi(c.Param("rev"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid revision number"})
+		return
+	}
+
+	blog, err := bc.BlogUsecase.RestoreRevision(c.Param("id"), c.GetString("id"), c.GetString("role"), rev)
+	if err != nil {
+		c.JSON(blogErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, blog)
+}
+
+// SetCommentPolicy opens, moderates or closes comments on a blog
+func (bc *BlogController) SetCommentPolicy(c *gin.Context) {
+	var req struct {
+		Policy string `json:"policy" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	blog, err := bc.BlogUsecase.SetCommentPolicy(c.Param("id"), c.GetString("id"), c.GetString("role"), req.Policy)
+	if err != nil {
+		c.JSON(blogErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, blog)
+}
+
+// ListTranslations lists the languages a blog has been translated into
+func (bc *BlogController) ListTranslations(c *gin.Context) {
+	translations, err := bc.BlogUsecase.ListTranslations(c.Param("id"), c.GetString("id"), c.GetString("role"))
+	if err != nil {
+		c.JSON(blogErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": translations})
+}
+
+// SaveTranslation writes or corrects the translation into :lang by hand
+func (bc *BlogController) SaveTranslation(c *gin.Context) {
+	var req struct {
+		Title   string `json:"title" binding:"required"`
+		Content string `json:"content" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	translation, err := bc.BlogUsecase.SaveTranslation(c.Param("id"), c.Param("lang"), c.GetString("id"), c.GetString("role"), req.Title, req.Content)
+	if err != nil {
+		c.JSON(blogErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, translation)
+}
+
+// ConfirmAIDraft marks an AI generated blog as reviewed by its author
+func (bc *BlogController) ConfirmAIDraft(c *gin.Context) {
+	blog, err := bc.BlogUsecase.ConfirmAIDraft(c.Param("id"), c.GetString("id"), c.GetString("role"))
+	if err != nil {
+		c.JSON(blogErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, blog)
+}
+
+// SemanticSearch ranks published blogs by how close they are in meaning
+// to ?q
+func (bc *BlogController) SemanticSearch(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	results, err := bc.BlogUsecase.Search.Search(c.Request.Context(), c.Query("q"), limit)
+	if err != nil {
+		respondAIError(c, err, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": results})
+}
+
+// RelatedBlogs lists the published blogs closest in meaning to :id
+func (bc *BlogController) RelatedBlogs(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "5"))
+	results, err := bc.BlogUsecase.Search.Related(c.Request.Context(), c.Param("id"), c.GetString("id"), c.GetString("role"), limit)
+	if err != nil {
+		respondAIError(c, err, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": results})
+}
+
+// contentFormat reads the format query parameter, defaulting to raw
+func contentFormat(c *gin.Context) (string, bool) {
+	format := c.DefaultQuery("format", domain.ContentFormatRaw)
+	switch format {
+	case domain.ContentFormatRaw, domain.ContentFormatHTML, domain.ContentFormatBoth:
+		return format, true
+	}
+	return "", false
+}
+
+func formatBlogs(blogs []*domain.Blog, format string) []domain.Blog {
+	result := make([]domain.Blog, 0, len(blogs))
+	for _, blog := range blogs {
+		result = append(result, blog.WithFormat(format))
+	}
+	return result
+}
+
+// blogErrorStatus maps blog usecase errors to HTTP status codes
+func blogErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrBlogNotFound), errors.Is(err, usecase.ErrRevisionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, usecase.ErrBlogForbidden), errors.Is(err, usecase.ErrModerationHold):
+		return http.StatusForbidden
+	case errors.Is(err, usecase.ErrInvalidBlogStatus), errors.Is(err, usecase.ErrInvalidPublishTime),
+		errors.Is(err, usecase.ErrInvalidCommentPolicy), errors.Is(err, usecase.ErrInvalidLanguage),
+		errors.Is(err, usecase.ErrInvalidTranslation), errors.Is(err, usecase.ErrEmptyQuery):
+		return http.StatusBadRequest
+	case errors.Is(err, usecase.ErrInvalidTransition), errors.Is(err, usecase.ErrNotScheduled),
+		errors.Is(err, usecase.ErrAIReviewPending), errors.Is(err, usecase.ErrNotAIDraft),
+		errors.Is(err, usecase.ErrSameLanguage), errors.Is(err, usecase.ErrHumanTranslation),
+		errors.Is(err, usecase.ErrSlugUnavailable):
+		return http.StatusConflict
+	case errors.Is(err, usecase.ErrSearchUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
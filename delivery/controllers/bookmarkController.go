@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sol-tad/Blog-post-Api/usecase"
+)
+
+type BookmarkController struct {
+	BookmarkUsecase *usecase.BookmarkUsecase
+}
+
+func NewBookmarkController(bookmarkUsecase *usecase.BookmarkUsecase) *BookmarkController {
+	return &BookmarkController{
+		BookmarkUsecase: bookmarkUsecase,
+	}
+}
+
+func (bc *BookmarkController) AddBookmark(c *gin.Context) {
+	var req struct {
+		BlogID string `json:"blog_id" binding:"required"`
+		ListID string `json:"list_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	bookmark, err := bc.BookmarkUsecase.AddBookmark(c.GetString("id"), req.BlogID, req.ListID, c.GetString("role"))
+	if err != nil {
+		c.JSON(bookmarkErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, bookmark)
+}
+
+func (bc *BookmarkController) RemoveBookmark(c *gin.Context) {
+	err := bc.BookmarkUsecase.RemoveBookmark(c.GetString("id"), c.Param("blog_id"), c.Query("list_id"))
+	if err != nil {
+		c.JSON(bookmarkErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Bookmark removed successfully"})
+}
+
+func (bc *BookmarkController) ListBookmarks(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	bookmarks, total, err := bc.BookmarkUsecase.ListBookmarks(c.GetString("id"), c.Query("list_id"), page, limit)
+	if err != nil {
+		c.JSON(bookmarkErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": bookmarks,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+func (bc *BookmarkController) ReorderBookmarks(c *gin.Context) {
+	var req struct {
+		ListID  string   `json:"list_id"`
+		BlogIDs []string `json:"blog_ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := bc.BookmarkUsecase.ReorderBookmarks(c.GetString("id"), req.ListID, req.BlogIDs); err != nil {
+		c.JSON(bookmarkErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Bookmarks reordered successfully"})
+}
+
+func (bc *BookmarkController) GetReadingLists(c *gin.Context) {
+	lists, err := bc.BookmarkUsecase.GetReadingLists(c.GetString("id"))
+	if err != nil {
+		c.JSON(bookmarkErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": lists})
+}
+
+func (bc *BookmarkController) CreateReadingList(c *gin.Context) {
+	var req struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	list, err := bc.BookmarkUsecase.CreateReadingList(c.GetString("id"), req.Name)
+	if err != nil {
+		c.JSON(bookmarkErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, list)
+}
+
+func (bc *BookmarkController) RenameReadingList(c *gin.Context) {
+	var req struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := bc.BookmarkUsecase.RenameReadingList(c.GetString("id"), c.Param("id"), req.Name); err != nil {
+		c.JSON(bookmarkErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reading list renamed successfully"})
+}
+
+func (bc *BookmarkController) DeleteReadingList(c *gin.Context) {
+	if err := bc.BookmarkUsecase.DeleteReadingList(c.GetString("id"), c.Param("id")); err != nil {
+		c.JSON(bookmarkErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reading list deleted successfully"})
+}
+
+// bookmarkErrorStatus maps bookmark usecase errors to HTTP status codes
+func bookmarkErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrBookmarkNotFound), errors.Is(err, usecase.ErrReadingListNotFound),
+		errors.Is(err, usecase.ErrBlogNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, usecase.ErrInvalidListName):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -1,9 +1,9 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sol-tad/Blog-post-Api/domain"
@@ -21,50 +21,89 @@ func NewCommentController(commentUsecase *usecase.CommentUsecase) *CommentContro
 	}
 }
 
+// commentRequest is what a client may set on a comment; everything else,
+// like the parent and depth of a reply, is filled in by the server
+type commentRequest struct {
+	Content string `json:"content" binding:"required"`
+}
+
 func (cc *CommentController) CreateComment(c *gin.Context) {
-	var comment domain.Comment
-	if err := c.ShouldBindJSON(&comment); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	comment, ok := newComment(c)
+	if !ok {
 		return
 	}
-	
-	// Set user information
-	userID, exists := c.Get("id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+
+	if err := cc.CommentUsecase.CreateComment(comment); err != nil {
+		c.JSON(commentErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
-	
-	objID, err := primitive.ObjectIDFromHex(userID.(string))
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+// CreateReply posts a reply to an existing comment
+func (cc *CommentController) CreateReply(c *gin.Context) {
+	reply, ok := newComment(c)
+	if !ok {
+		return
+	}
+
+	if err := cc.CommentUsecase.CreateReply(c.Param("comment_id"), reply); err != nil {
+		c.JSON(commentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, reply)
+}
+
+// newComment builds a comment by the caller on the blog in the path from
+// the request body. It responds itself when the request is invalid.
+func newComment(c *gin.Context) (*domain.Comment, bool) {
+	var req commentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	userID, err := primitive.ObjectIDFromHex(c.GetString("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
-		return
+		return nil, false
 	}
-	
-	comment.UserID = objID
-	comment.Username = c.GetString("username")
-	comment.CreatedAt = time.Now()
-	comment.UpdatedAt = time.Now()
-
-	// Set blog ID
-	blogID := c.Param("blog_id")
-	blogObjID, err := primitive.ObjectIDFromHex(blogID)
+	blogID, err := primitive.ObjectIDFromHex(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid blog ID"})
+		return nil, false
+	}
+
+	return &domain.Comment{
+		BlogID:  blogID,
+		UserID:  userID,
+		Content: req.Content,
+	}, true
+}
+
+// GetCommentThread returns the blog's comments as a tree, or as a flat list
+// with parent pointers when layout=flat
+func (cc *CommentController) GetCommentThread(c *gin.Context) {
+	maxDepth, err := strconv.Atoi(c.DefaultQuery("max_depth", strconv.Itoa(domain.MaxCommentDepth)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid max_depth"})
 		return
 	}
-	comment.BlogID = blogObjID
+	flat := c.Query("layout") == "flat"
 
-	if err := cc.CommentUsecase.CreateComment(&comment); err != nil {
+	comments, err := cc.CommentUsecase.GetCommentThread(c.Param("id"), maxDepth, flat)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, comment)
+	c.JSON(http.StatusOK, gin.H{"data": comments})
 }
 
 func (cc *CommentController) GetComments(c *gin.Context) {
-	blogID := c.Param("blog_id")
+	blogID := c.Param("id")
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
 
@@ -85,63 +124,108 @@ func (cc *CommentController) GetComments(c *gin.Context) {
 }
 
 func (cc *CommentController) UpdateComment(c *gin.Context) {
-	id := c.Param("id")
+	id := c.Param("comment_id")
 	
-	var updatedComment domain.Comment
-	if err := c.ShouldBindJSON(&updatedComment); err != nil {
+	var req commentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 	
-	// Get existing comment
-	comment, err := cc.CommentUsecase.GetCommentByID(id)
+	comment, err := cc.CommentUsecase.UpdateComment(id, c.GetString("id"), req.Content)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "comment not found"})
+		c.JSON(commentErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	
-	// Verify ownership
-	userID := c.GetString("id")
-	if comment.UserID.Hex() != userID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "you can only update your own comments"})
+	c.JSON(http.StatusOK, comment)
+}
+
+func (cc *CommentController) DeleteComment(c *gin.Context) {
+	id := c.Param("comment_id")
+	
+	if err := cc.CommentUsecase.DeleteComment(id, c.GetString("id"), c.GetString("role")); err != nil {
+		c.JSON(commentErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	
-	// Update content
-	comment.Content = updatedComment.Content
-	comment.UpdatedAt = time.Now()
-	
-	if err := cc.CommentUsecase.UpdateComment(comment); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
+}
+
+// ListPendingComments lists comments waiting for moderation on a blog
+func (cc *CommentController) ListPendingComments(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	comments, err := cc.CommentUsecase.ListPendingComments(c.Param("id"), c.GetString("id"), c.GetString("role"), page, limit)
+	if err != nil {
+		c.JSON(commentErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
-	
-	c.JSON(http.StatusOK, comment)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": comments,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+		},
+	})
 }
 
-func (cc *CommentController) DeleteComment(c *gin.Context) {
-	id := c.Param("id")
-	
-	// Get existing comment
-	comment, err := cc.CommentUsecase.GetCommentByID(id)
+func (cc *CommentController) ApproveComment(c *gin.Context) {
+	cc.moderate(c, usecase.ModerationApprove)
+}
+
+func (cc *CommentController) RejectComment(c *gin.Context) {
+	cc.moderate(c, usecase.ModerationReject)
+}
+
+func (cc *CommentController) moderate(c *gin.Context, action string) {
+	err := cc.CommentUsecase.ModerateComment(c.Param("id"), c.Param("comment_id"), c.GetString("id"), c.GetString("role"), action)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "comment not found"})
+		c.JSON(commentErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
-	
-	// Verify ownership or admin role
-	userID := c.GetString("id")
-	userRole := c.GetString("role")
-	
-	if comment.UserID.Hex() != userID && userRole != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "you are not authorized to delete this comment"})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Comment moderated successfully"})
+}
+
+// ModerateComments approves or rejects several pending comments at once
+func (cc *CommentController) ModerateComments(c *gin.Context) {
+	var req struct {
+		Action     string   `json:"action" binding:"required"`
+		CommentIDs []string `json:"comment_ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	
-	if err := cc.CommentUsecase.DeleteComment(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+
+	results, err := cc.CommentUsecase.ModerateComments(c.Param("id"), req.CommentIDs, c.GetString("id"), c.GetString("role"), req.Action)
+	if err != nil {
+		c.JSON(commentErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
-	
-	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
-}
\ No newline at end of file
+
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+// commentErrorStatus maps comment usecase errors to HTTP status codes
+func commentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrCommentNotFound), errors.Is(err, usecase.ErrBlogNotFound), errors.Is(err, usecase.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, usecase.ErrBlogForbidden), errors.Is(err, usecase.ErrCommentForbidden), errors.Is(err, usecase.ErrCommentsClosed):
+		return http.StatusForbidden
+	case errors.Is(err, usecase.ErrNotPending):
+		return http.StatusConflict
+	case errors.Is(err, usecase.ErrInvalidAction):
+		return http.StatusBadRequest
+	case errors.Is(err, usecase.ErrCommentDeleted):
+		return http.StatusGone
+	case errors.Is(err, usecase.ErrThreadTooDeep):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
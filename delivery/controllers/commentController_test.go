@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestNewCommentIgnoresServerFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	userID := primitive.NewObjectID()
+	blogID := primitive.NewObjectID()
+
+	tests := []struct {
+		name     string
+		body     string
+		wantOK   bool
+		wantCode int
+	}{
+		{
+			name:   "content only",
+			body:   `{"content":"hello"}`,
+			wantOK: true,
+		},
+		{
+			name:   "parent, depth and tombstone are dropped",
+			body:   `{"content":"hello","parent_id":"` + primitive.NewObjectID().Hex() + `","depth":9,"deleted":true,"status":"approved","user_id":"` + primitive.NewObjectID().Hex() + `"}`,
+			wantOK: true,
+		},
+		{
+			name:     "missing content",
+			body:     `{"parent_id":"` + primitive.NewObjectID().Hex() + `"}`,
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rec)
+			c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Params = gin.Params{{Key: "id", Value: blogID.Hex()}}
+			c.Set("id", userID.Hex())
+
+			comment, ok := newComment(c)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				if rec.Code != tt.wantCode {
+					t.Errorf("status = %d, want %d", rec.Code, tt.wantCode)
+				}
+				return
+			}
+
+			if comment.Content != "hello" {
+				t.Errorf("content = %q", comment.Content)
+			}
+			if comment.UserID != userID || comment.BlogID != blogID {
+				t.Errorf("comment not attributed to the caller and blog: %+v", comment)
+			}
+			if !comment.ParentID.IsZero() || comment.Depth != 0 || comment.Deleted || comment.Status != "" {
+				t.Errorf("client set server fields: %+v", comment)
+			}
+		})
+	}
+}
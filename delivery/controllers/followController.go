@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sol-tad/Blog-post-Api/usecase"
+)
+
+type FollowController struct {
+	FollowUsecase *usecase.FollowUsecase
+}
+
+func NewFollowController(followUsecase *usecase.FollowUsecase) *FollowController {
+	return &FollowController{
+		FollowUsecase: followUsecase,
+	}
+}
+
+func (fc *FollowController) Follow(c *gin.Context) {
+	if err := fc.FollowUsecase.Follow(c, c.GetString("id"), c.Param("id")); err != nil {
+		c.JSON(followErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User followed successfully"})
+}
+
+func (fc *FollowController) Unfollow(c *gin.Context) {
+	if err := fc.FollowUsecase.Unfollow(c, c.GetString("id"), c.Param("id")); err != nil {
+		c.JSON(followErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User unfollowed successfully"})
+}
+
+func (fc *FollowController) GetProfile(c *gin.Context) {
+	profile, err := fc.FollowUsecase.GetProfile(c, c.Param("id"), c.GetString("id"))
+	if err != nil {
+		c.JSON(followErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+func (fc *FollowController) GetFollowers(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	followers, total, err := fc.FollowUsecase.GetFollowers(c, c.Param("id"), page, limit)
+	if err != nil {
+		c.JSON(followErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": followers,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+func (fc *FollowController) GetFollowing(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	following, total, err := fc.FollowUsecase.GetFollowing(c, c.Param("id"), page, limit)
+	if err != nil {
+		c.JSON(followErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": following,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// GetFeed returns blogs from followed authors, newest first. Pass the
+// returned next_cursor back as ?cursor= to fetch the following page.
+func (fc *FollowController) GetFeed(c *gin.Context) {
+	format, ok := contentFormat(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be raw, html or both"})
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	blogs, next, err := fc.FollowUsecase.GetFeed(c.GetString("id"), c.Query("cursor"), limit)
+	if err != nil {
+		c.JSON(followErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":        formatBlogs(blogs, format),
+		"next_cursor": next,
+	})
+}
+
+// followErrorStatus maps follow usecase errors to HTTP status codes
+func followErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, usecase.ErrFollowSelf), errors.Is(err, usecase.ErrInvalidCursor):
+		return http.StatusBadRequest
+	case errors.Is(err, usecase.ErrNotFollowing):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sol-tad/Blog-post-Api/infrastructure"
+)
+
+// jwksMaxAge is how long verifiers may cache the key set. Rotations
+// should activate new keys no sooner than this after creating them.
+const jwksMaxAge = "300"
+
+type JWKSController struct {
+	Keys *infrastructure.KeySet
+}
+
+func NewJWKSController(keys *infrastructure.KeySet) *JWKSController {
+	return &JWKSController{
+		Keys: keys,
+	}
+}
+
+// GetJWKS publishes the public keys tokens are signed with, so other
+// services can verify tokens without sharing a secret
+func (jc *JWKSController) GetJWKS(c *gin.Context) {
+	if jc.Keys == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "signing keys are not loaded"})
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age="+jwksMaxAge)
+	c.JSON(http.StatusOK, jc.Keys.JWKS())
+}
@@ -31,7 +31,7 @@ func (oauc *OAuthController) Login(c *gin.Context){
 func (oauc *OAuthController) Callback(c *gin.Context){
 	code:=c.Query("code")
 	log.Println("**************----",code,"-----------------------****")
-	user,accessToken,refreshToken,err:=oauc.OAuthUsecase.HandleGoogleCallback(code)
+	user,result,err:=oauc.OAuthUsecase.HandleGoogleCallback(code, sessionClient(c, ""))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "OAuth failed"})
 		return
@@ -40,8 +40,12 @@ func (oauc *OAuthController) Callback(c *gin.Context){
 	c.JSON(http.StatusOK, gin.H{
 		"message": "OAuth login successful",
 		"user":    user,
-		"access_token":accessToken,
-		"refresh_token":refreshToken,
+		"access_token":result.AccessToken,
+		"refresh_token":result.RefreshToken,
+		// Two-factor users finish at /login/2fa with this token
+		"challenge_token":result.ChallengeToken,
+		"two_factor_required":result.TwoFactorRequired,
+		"two_factor_setup_required":result.TwoFactorSetupRequired,
 	})
 
 }
\ No newline at end of file
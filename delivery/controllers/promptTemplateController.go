@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"github.com/sol-tad/Blog-post-Api/usecase"
+)
+
+type PromptTemplateController struct {
+	TemplateUsecase *usecase.PromptTemplateUsecase
+}
+
+func NewPromptTemplateController(templateUsecase *usecase.PromptTemplateUsecase) *PromptTemplateController {
+	return &PromptTemplateController{
+		TemplateUsecase: templateUsecase,
+	}
+}
+
+func (tc *PromptTemplateController) ListTemplates(c *gin.Context) {
+	templates, err := tc.TemplateUsecase.ListTemplates()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": templates})
+}
+
+// GetTemplate returns the latest version, or the one given by ?version=
+func (tc *PromptTemplateController) GetTemplate(c *gin.Context) {
+	ref := c.Param("name")
+	if version := c.Query("version"); version != "" {
+		ref += "@" + version
+	}
+
+	template, err := tc.TemplateUsecase.GetTemplate(ref)
+	if err != nil {
+		c.JSON(templateErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+func (tc *PromptTemplateController) ListVersions(c *gin.Context) {
+	versions, err := tc.TemplateUsecase.ListVersions(c.Param("name"))
+	if err != nil {
+		c.JSON(templateErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": versions})
+}
+
+func (tc *PromptTemplateController) CreateTemplate(c *gin.Context) {
+	var req struct {
+		Name        string `json:"name" binding:"required"`
+		Kind        string `json:"kind" binding:"required"`
+		Description string `json:"description"`
+		Body        string `json:"body" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := tc.TemplateUsecase.CreateTemplate(&domain.PromptTemplate{
+		Name:        req.Name,
+		Kind:        req.Kind,
+		Description: req.Description,
+		Body:        req.Body,
+	}, c.GetString("id"))
+	if err != nil {
+		c.JSON(templateErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+// UpdateTemplate saves a new version of the template
+func (tc *PromptTemplateController) UpdateTemplate(c *gin.Context) {
+	var req struct {
+		Kind        string `json:"kind"`
+		Description string `json:"description"`
+		Body        string `json:"body"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := tc.TemplateUsecase.UpdateTemplate(c.Param("name"), domain.PromptTemplate{
+		Kind:        req.Kind,
+		Description: req.Description,
+		Body:        req.Body,
+	}, c.GetString("id"))
+	if err != nil {
+		c.JSON(templateErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// templateErrorStatus maps prompt template errors to HTTP status codes
+func templateErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrTemplateNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, usecase.ErrInvalidTemplate), errors.Is(err, usecase.ErrTemplateKind):
+		return http.StatusBadRequest
+	case errors.Is(err, usecase.ErrTemplateExists):
+		return http.StatusConflict
+	case errors.Is(err, usecase.ErrInvalidAIUser):
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
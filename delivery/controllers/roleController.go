@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"github.com/sol-tad/Blog-post-Api/usecase"
+)
+
+type RoleController struct {
+	RoleUsecase *usecase.RoleUsecase
+}
+
+func NewRoleController(roleUsecase *usecase.RoleUsecase) *RoleController {
+	return &RoleController{
+		RoleUsecase: roleUsecase,
+	}
+}
+
+// ListRoles lists the roles and what each may do
+func (rc *RoleController) ListRoles(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": rc.RoleUsecase.Roles()})
+}
+
+// AssignRole gives a user a role
+func (rc *RoleController) AssignRole(c *gin.Context) {
+	var req struct {
+		Role string `json:"role" binding:"required"`
+		// Reason is kept in the audit trail
+		Reason string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rc.assign(c, req.Role, req.Reason)
+}
+
+// PromoteUser makes a user an admin
+func (rc *RoleController) PromoteUser(c *gin.Context) {
+	rc.assign(c, domain.RoleAdmin, "promoted")
+}
+
+// DemoteUser makes a user an author, the role every account starts with
+func (rc *RoleController) DemoteUser(c *gin.Context) {
+	rc.assign(c, domain.DefaultRole, "demoted")
+}
+
+func (rc *RoleController) assign(c *gin.Context, role, reason string) {
+	change, err := rc.RoleUsecase.AssignRole(c, c.GetString("id"), c.Param("id"), role, reason)
+	if err != nil {
+		c.JSON(roleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, change)
+}
+
+// ListRoleChanges returns the role change audit trail, optionally for one
+// user
+func (rc *RoleController) ListRoleChanges(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	changes, total, err := rc.RoleUsecase.ListRoleChanges(c, c.Query("user_id"), page, limit)
+	if err != nil {
+		c.JSON(roleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  changes,
+		"total": total,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+		},
+	})
+}
+
+func roleErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, usecase.ErrInvalidRole):
+		return http.StatusBadRequest
+	case errors.Is(err, usecase.ErrOwnRole):
+		return http.StatusForbidden
+	case errors.Is(err, usecase.ErrRoleUnchanged):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"github.com/sol-tad/Blog-post-Api/usecase"
+)
+
+type SessionController struct {
+	SessionUsecase *usecase.SessionUsecase
+}
+
+func NewSessionController(sessionUsecase *usecase.SessionUsecase) *SessionController {
+	return &SessionController{
+		SessionUsecase: sessionUsecase,
+	}
+}
+
+// ListSessions lists the devices the caller is signed in on
+func (sc *SessionController) ListSessions(c *gin.Context) {
+	sessions, err := sc.SessionUsecase.ListSessions(c, c.GetString("id"), c.GetString("session_id"))
+	if err != nil {
+		c.JSON(sessionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": sessions})
+}
+
+// RevokeSession signs the caller out on one device, revoking its access
+// tokens too
+func (sc *SessionController) RevokeSession(c *gin.Context) {
+	if err := sc.SessionUsecase.RevokeSession(c, c.GetString("id"), c.Param("id")); err != nil {
+		c.JSON(sessionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+// sessionClient describes the device a request comes from; device is the
+// name the client gave itself, if any
+func sessionClient(c *gin.Context, device string) domain.SessionClient {
+	return domain.SessionClient{
+		Device:    device,
+		UserAgent: c.Request.UserAgent(),
+		IP:        c.ClientIP(),
+	}
+}
+
+func sessionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrSessionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, usecase.ErrInvalidRefreshToken), errors.Is(err, usecase.ErrRefreshTokenReused):
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sol-tad/Blog-post-Api/usecase"
+)
+
+type TwoFactorController struct {
+	TwoFactorUsecase *usecase.TwoFactorUsecase
+}
+
+func NewTwoFactorController(twoFactorUsecase *usecase.TwoFactorUsecase) *TwoFactorController {
+	return &TwoFactorController{
+		TwoFactorUsecase: twoFactorUsecase,
+	}
+}
+
+type twoFactorCodeRequest struct {
+	// Code is a code from the authenticator app, or a recovery code where
+	// one is accepted
+	Code string `json:"code" binding:"required"`
+}
+
+// Status tells the caller whether two-factor authentication is on and
+// whether their role requires it
+func (tc *TwoFactorController) Status(c *gin.Context) {
+	status, err := tc.TwoFactorUsecase.Status(c, c.GetString("id"))
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// Enroll starts setting up an authenticator app
+func (tc *TwoFactorController) Enroll(c *gin.Context) {
+	enrollment, err := tc.TwoFactorUsecase.Enroll(c, c.GetString("id"))
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// Confirm turns two-factor authentication on and hands out the recovery
+// codes
+func (tc *TwoFactorController) Confirm(c *gin.Context) {
+	var req twoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := tc.TwoFactorUsecase.Confirm(c, c.GetString("id"), req.Code)
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Two-factor authentication enabled. Store the recovery codes somewhere safe; they are not shown again.",
+		"recovery_codes": codes,
+	})
+}
+
+// Disable turns two-factor authentication off
+func (tc *TwoFactorController) Disable(c *gin.Context) {
+	var req twoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := tc.TwoFactorUsecase.Disable(c, c.GetString("id"), req.Code); err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes replaces the caller's recovery codes
+func (tc *TwoFactorController) RegenerateRecoveryCodes(c *gin.Context) {
+	var req twoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := tc.TwoFactorUsecase.RegenerateRecoveryCodes(c, c.GetString("id"), req.Code)
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// GetPolicy returns the roles that require two-factor authentication
+func (tc *TwoFactorController) GetPolicy(c *gin.Context) {
+	policy, err := tc.TwoFactorUsecase.Policy(c)
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// SetPolicy changes the roles that require two-factor authentication
+func (tc *TwoFactorController) SetPolicy(c *gin.Context) {
+	var req struct {
+		RequiredRoles []string `json:"required_roles"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy, err := tc.TwoFactorUsecase.SetPolicy(c, c.GetString("id"), c.GetStringSlice("auth_methods"), req.RequiredRoles)
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+func twoFactorErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidChallengeToken), errors.Is(err, usecase.ErrInvalidTwoFactorCode):
+		return http.StatusUnauthorized
+	case errors.Is(err, usecase.ErrTwoFactorLocked):
+		return http.StatusTooManyRequests
+	case errors.Is(err, usecase.ErrTwoFactorAlreadyEnabled):
+		return http.StatusConflict
+	case errors.Is(err, usecase.ErrTwoFactorNotEnrolled), errors.Is(err, usecase.ErrTwoFactorNotEnabled), errors.Is(err, usecase.ErrInvalidRole):
+		return http.StatusBadRequest
+	case errors.Is(err, usecase.ErrTwoFactorSetupFirst):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
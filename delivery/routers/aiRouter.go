@@ -1,29 +1,69 @@
 package routers
 
 import (
+	"log"
+
 	"github.com/gin-gonic/gin"
+	"github.com/sol-tad/Blog-post-Api/config"
 	"github.com/sol-tad/Blog-post-Api/delivery/controllers"
+	"github.com/sol-tad/Blog-post-Api/domain"
 	"github.com/sol-tad/Blog-post-Api/infrastructure"
+	"github.com/sol-tad/Blog-post-Api/middlewares"
+	"github.com/sol-tad/Blog-post-Api/repository"
 	"github.com/sol-tad/Blog-post-Api/usecase"
 )
 
-func SetupAI(router *gin.Engine) {
-    // 1. Create the Gemini Adapter (infrastructure layer)
-    adapter, err := infrastructure.NewGeminiAdapter()
+func SetupAI(router *gin.Engine, blogUC *usecase.BlogUseCase) {
+    // 1. Create the configured AI provider (infrastructure layer).
+    // Without one the routes stay up and answer 503.
+    adapter, err := infrastructure.NewAIProviderFromEnv()
     if err != nil {
-        panic("Failed to initialize Gemini Adapter: " + err.Error())
+        log.Println("AI features disabled:", err)
+        adapter = nil
     }
 
-    // 2. Create the Use Case with the adapter (business logic layer)
-    aiUC := usecase.NewAIUseCases(adapter)
+    // 2. Create the Use Case with the adapter (business logic layer).
+    // Generated drafts are saved through the shared blog use case.
+    usageRepo := repository.NewAIUsageRepository(config.AIUsageCollection)
+    templateUC := usecase.NewPromptTemplateUsecase(repository.NewPromptTemplateRepository(config.PromptTemplateCollection))
+    aiUC := usecase.NewAIUseCases(adapter, blogUC, usageRepo, infrastructure.AIQuotasFromEnv(), templateUC)
 
     // 3. Create the Controller with the use case (interface layer)
     aiController := controllers.NewAIController(aiUC)
+    templateController := controllers.NewPromptTemplateController(templateUC)
 
-    // 4. Define route group for AI endpoints
+    // 4. Define route group for AI endpoints. Every call counts against
+    // the caller's quota, so all of them need a signed in user.
     aiGroup := router.Group("/ai")
+    aiGroup.Use(middlewares.AuthMiddleware())
+    {
+        useAI := middlewares.RequirePermission(domain.PermAIUse)
+        aiGroup.POST("/generate", useAI, aiController.GenerateBlog)
+        aiGroup.POST("/generate/stream", useAI, aiController.GenerateBlogStream)
+        aiGroup.POST("/drafts", useAI, middlewares.RequirePermission(domain.PermBlogsCreate), aiController.GenerateDraft)
+        aiGroup.POST("/summarize", useAI, aiController.SummarizeBlog)
+
+        // Usage report across all users
+        aiGroup.GET("/usage", middlewares.RequirePermission(domain.PermAIManage), aiController.GetUsageReport)
+
+        // Prompt templates; anyone may pick one, admins manage them
+        aiGroup.GET("/templates", templateController.ListTemplates)
+        aiGroup.GET("/templates/:name", templateController.GetTemplate)
+        aiGroup.GET("/templates/:name/versions", middlewares.RequirePermission(domain.PermAIManage), templateController.ListVersions)
+        aiGroup.POST("/templates", middlewares.RequirePermission(domain.PermAIManage), templateController.CreateTemplate)
+        aiGroup.PUT("/templates/:name", middlewares.RequirePermission(domain.PermAIManage), templateController.UpdateTemplate)
+    }
+
+    router.GET("/me/ai-usage", middlewares.AuthMiddleware(), aiController.GetMyUsage)
+
+    // Summaries and suggestions for the author of a stored blog
+    router.POST("/blogs/:id/summary", middlewares.AuthMiddleware(), middlewares.RequirePermission(domain.PermAIUse), aiController.SummarizeStoredBlog)
+
+    blogAI := router.Group("/blogs/:id/ai")
+    blogAI.Use(middlewares.AuthMiddleware(), middlewares.RequirePermission(domain.PermAIUse))
     {
-        aiGroup.POST("/generate", aiController.GenerateBlog)
-        aiGroup.POST("/summarize", aiController.SummarizeBlog)
+        blogAI.POST("/suggest-tags", aiController.SuggestTags)
+        blogAI.POST("/suggest-titles", aiController.SuggestTitles)
+        blogAI.POST("/translate", aiController.TranslateBlog)
     }
 }
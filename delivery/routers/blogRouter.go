@@ -1,15 +1,22 @@
 package routers
 
 import (
+	"log"
+
 	"github.com/gin-gonic/gin"
 	"github.com/sol-tad/Blog-post-Api/config"
 	"github.com/sol-tad/Blog-post-Api/delivery/controllers"
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"github.com/sol-tad/Blog-post-Api/infrastructure"
 	"github.com/sol-tad/Blog-post-Api/middlewares"
 	"github.com/sol-tad/Blog-post-Api/repository"
 	"github.com/sol-tad/Blog-post-Api/usecase"
 )
 
-func SetupBlogRoutes(router *gin.Engine) {
+// NewBlogUseCase builds the blog use case with its repositories, content
+// moderator and semantic search. It is built once and shared by every
+// route group and background job that works on blogs.
+func NewBlogUseCase() *usecase.BlogUseCase {
 	blogRepo := repository.NewBlogRepo(config.BlogCollection)
 	userRepo:=repository.NewUserRepository(config.UserCollection)
 	interactionRepo := repository.NewInteractionRepository(
@@ -17,21 +24,76 @@ func SetupBlogRoutes(router *gin.Engine) {
 		config.InteractionCollection,
 	)
 	
-	blogUsecase := usecase.NewBlogUseCase(blogRepo, interactionRepo,userRepo)
+	revisionRepo := repository.NewRevisionRepository(config.RevisionCollection)
+	bookmarkRepo := repository.NewBookmarkRepository(
+		config.BlogCollection,
+		config.BookmarkCollection,
+		config.ReadingListCollection,
+		config.BookmarkPositionCollection,
+	)
+	
+	translationRepo := repository.NewTranslationRepository(config.TranslationCollection)
+
+	return usecase.NewBlogUseCase(blogRepo, interactionRepo,userRepo, revisionRepo, bookmarkRepo, translationRepo, infrastructure.NewContentModeratorFromEnv(), NewSemanticSearch(blogRepo))
+}
+
+func SetupBlogRoutes(router *gin.Engine, blogUsecase *usecase.BlogUseCase) {
 	blogController := controllers.NewBlogController(blogUsecase)
 
 	blogRoutes := router.Group("/blogs")
 	{
-		blogRoutes.GET("", blogController.ListBlogs)
-		blogRoutes.GET("/:id", blogController.GetBlog)
+		blogRoutes.GET("", middlewares.OptionalAuthMiddleware(), blogController.ListBlogs)
+		// Embedding the query may call a paid AI provider, so searching
+		// needs an account
+		blogRoutes.GET("/semantic-search", middlewares.AuthMiddleware(), blogController.SemanticSearch)
+		blogRoutes.GET("/:id", middlewares.OptionalAuthMiddleware(), blogController.GetBlog)
+		blogRoutes.GET("/:id/translations", middlewares.OptionalAuthMiddleware(), blogController.ListTranslations)
+		blogRoutes.GET("/:id/related", middlewares.OptionalAuthMiddleware(), blogController.RelatedBlogs)
 		
-		// Protected routes
+		// Protected routes. Changing a blog needs a role that writes blogs,
+		// even for blogs written before the user's role was lowered.
 		protected := blogRoutes.Group("")
 		protected.Use(middlewares.AuthMiddleware())
+		write := middlewares.RequirePermission(domain.PermBlogsCreate)
+		publish := middlewares.RequirePermission(domain.PermBlogsPublish)
 		{
-			protected.POST("/create", blogController.CreateBlog)
-			protected.PUT("/:id", blogController.UpdateBlog)
+			protected.POST("/create", write, blogController.CreateBlog)
+			protected.PUT("/:id", write, blogController.UpdateBlog)
+			// Owners may always remove their blogs
 			protected.DELETE("/:id", blogController.DeleteBlog)
+
+			// Lifecycle
+			protected.GET("/mine", blogController.ListMyBlogs)
+			protected.PUT("/:id/submit", write, blogController.SubmitBlog)
+			protected.PUT("/:id/publish", publish, blogController.PublishBlog)
+			protected.PUT("/:id/archive", write, blogController.ArchiveBlog)
+			protected.PUT("/:id/unpublish", write, blogController.UnpublishBlog)
+
+			// Scheduled publishing
+			protected.PUT("/:id/schedule", publish, blogController.ScheduleBlog)
+			protected.PATCH("/:id/schedule", publish, blogController.RescheduleBlog)
+			protected.DELETE("/:id/schedule", write, blogController.CancelSchedule)
+
+			// Revision history
+			protected.GET("/:id/revisions", blogController.ListRevisions)
+			protected.GET("/:id/revisions/diff", blogController.DiffRevisions)
+			protected.GET("/:id/revisions/:rev", blogController.GetRevision)
+			protected.POST("/:id/revisions/:rev/restore", write, blogController.RestoreRevision)
+
+			protected.PUT("/:id/comment-policy", write, blogController.SetCommentPolicy)
+			protected.PUT("/:id/ai/confirm", write, blogController.ConfirmAIDraft)
+			protected.PUT("/:id/translations/:lang", write, blogController.SaveTranslation)
 		}
 	}
-}
\ No newline at end of file
+}
+
+// NewSemanticSearch builds semantic search on the configured embedder.
+// Without one the search routes stay up and answer 503.
+func NewSemanticSearch(blogRepo usecase.IBlogRepo) *usecase.SemanticSearchUsecase {
+	embedder, err := infrastructure.NewEmbedderFromEnv()
+	if err != nil {
+		log.Println("semantic search disabled:", err)
+		embedder = nil
+	}
+	return usecase.NewSemanticSearchUsecase(embedder, repository.NewEmbeddingRepository(config.EmbeddingCollection), blogRepo)
+}
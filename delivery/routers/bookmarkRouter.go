@@ -0,0 +1,27 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sol-tad/Blog-post-Api/delivery/controllers"
+	"github.com/sol-tad/Blog-post-Api/middlewares"
+	"github.com/sol-tad/Blog-post-Api/usecase"
+)
+
+func SetupBookmarkRoutes(router *gin.Engine, blogUsecase *usecase.BlogUseCase) {
+	bookmarkUsecase := usecase.NewBookmarkUsecase(blogUsecase.BookmarkRepo, blogUsecase.Repo)
+	bookmarkController := controllers.NewBookmarkController(bookmarkUsecase)
+
+	meRoutes := router.Group("/me")
+	meRoutes.Use(middlewares.AuthMiddleware())
+	{
+		meRoutes.GET("/bookmarks", bookmarkController.ListBookmarks)
+		meRoutes.POST("/bookmarks", bookmarkController.AddBookmark)
+		meRoutes.PUT("/bookmarks/order", bookmarkController.ReorderBookmarks)
+		meRoutes.DELETE("/bookmarks/:blog_id", bookmarkController.RemoveBookmark)
+
+		meRoutes.GET("/reading-lists", bookmarkController.GetReadingLists)
+		meRoutes.POST("/reading-lists", bookmarkController.CreateReadingList)
+		meRoutes.PUT("/reading-lists/:id", bookmarkController.RenameReadingList)
+		meRoutes.DELETE("/reading-lists/:id", bookmarkController.DeleteReadingList)
+	}
+}
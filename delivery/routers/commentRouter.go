@@ -4,28 +4,38 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/sol-tad/Blog-post-Api/config"
 	"github.com/sol-tad/Blog-post-Api/delivery/controllers"
+	"github.com/sol-tad/Blog-post-Api/domain"
 	"github.com/sol-tad/Blog-post-Api/middlewares"
 	"github.com/sol-tad/Blog-post-Api/repository"
 	"github.com/sol-tad/Blog-post-Api/usecase"
 )
 
-func SetupCommentRoutes(router *gin.Engine) {
-	commentRepo := repository.NewCommentRepository(config.CommentCollection)
-	blogRepo := repository.NewBlogRepo(config.BlogCollection)
-	commentUsecase := usecase.NewCommentUsecase(commentRepo, blogRepo)
+func SetupCommentRoutes(router *gin.Engine, blogUsecase *usecase.BlogUseCase) {
+	commentRepo := repository.NewCommentRepository(config.BlogCollection, config.CommentCollection)
+	commentUsecase := usecase.NewCommentUsecase(commentRepo, blogUsecase.Repo, blogUsecase.UserRepo, blogUsecase.Moderator)
 	commentController := controllers.NewCommentController(commentUsecase)
 
-	commentRoutes := router.Group("/blogs/:blog_id/comments")
+	// The blog wildcard must be named like the one of the blog routes, or
+	// gin refuses to register both
+	commentRoutes := router.Group("/blogs/:id/comments")
 	{
 		commentRoutes.GET("", commentController.GetComments)
+		commentRoutes.GET("/thread", commentController.GetCommentThread)
 		
 		// Protected routes
 		protected := commentRoutes.Group("")
 		protected.Use(middlewares.AuthMiddleware())
 		{
-			protected.POST("", commentController.CreateComment)
-			protected.PUT("/:id", commentController.UpdateComment)
-			protected.DELETE("/:id", commentController.DeleteComment)
+			protected.POST("", middlewares.RequirePermission(domain.PermCommentsCreate), commentController.CreateComment)
+			protected.PUT("/:comment_id", commentController.UpdateComment)
+			protected.DELETE("/:comment_id", commentController.DeleteComment)
+			protected.POST("/:comment_id/replies", middlewares.RequirePermission(domain.PermCommentsCreate), commentController.CreateReply)
+
+			// Moderation by the blog author or a moderator
+			protected.GET("/pending", commentController.ListPendingComments)
+			protected.POST("/moderate", commentController.ModerateComments)
+			protected.PUT("/:comment_id/approve", commentController.ApproveComment)
+			protected.PUT("/:comment_id/reject", commentController.RejectComment)
 		}
 	}
 }
\ No newline at end of file
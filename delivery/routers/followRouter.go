@@ -0,0 +1,29 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sol-tad/Blog-post-Api/config"
+	"github.com/sol-tad/Blog-post-Api/delivery/controllers"
+	"github.com/sol-tad/Blog-post-Api/middlewares"
+	"github.com/sol-tad/Blog-post-Api/repository"
+	"github.com/sol-tad/Blog-post-Api/usecase"
+)
+
+func SetupFollowRoutes(router *gin.Engine, blogUsecase *usecase.BlogUseCase) {
+	followRepo := repository.NewFollowRepository(config.FollowCollection)
+
+	followUsecase := usecase.NewFollowUsecase(followRepo, blogUsecase.UserRepo, blogUsecase.Repo)
+	followController := controllers.NewFollowController(followUsecase)
+
+	userRoutes := router.Group("/user")
+	{
+		userRoutes.GET("/:id", middlewares.OptionalAuthMiddleware(), followController.GetProfile)
+		userRoutes.GET("/:id/followers", followController.GetFollowers)
+		userRoutes.GET("/:id/following", followController.GetFollowing)
+
+		userRoutes.POST("/:id/follow", middlewares.AuthMiddleware(), followController.Follow)
+		userRoutes.POST("/:id/unfollow", middlewares.AuthMiddleware(), followController.Unfollow)
+	}
+
+	router.GET("/feed", middlewares.AuthMiddleware(), followController.GetFeed)
+}
@@ -0,0 +1,13 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sol-tad/Blog-post-Api/delivery/controllers"
+	"github.com/sol-tad/Blog-post-Api/infrastructure"
+)
+
+func SetupJWKSRoutes(router *gin.Engine) {
+	jwksController := controllers.NewJWKSController(infrastructure.SigningKeys)
+
+	router.GET("/.well-known/jwks.json", jwksController.GetJWKS)
+}
@@ -15,7 +15,8 @@ func SetupOAuthRouter(router *gin.Engine) {
 
 	userRepository:=repository.NewUserRepository(userDbCollection)
 
-	oauthUsecase := usecase.NewOAuthUsecase(userRepository)
+	_, twoFactorUsecase := newAuthUsecases(userRepository)
+	oauthUsecase := usecase.NewOAuthUsecase(userRepository, twoFactorUsecase)
 	oauthController:=controllers.NewOAuthController(oauthUsecase)
 
 	oauthRoutes:=router.Group("")
@@ -2,14 +2,28 @@ package routers
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/sol-tad/Blog-post-Api/config"
+	"github.com/sol-tad/Blog-post-Api/middlewares"
+	"github.com/sol-tad/Blog-post-Api/repository"
+	"github.com/sol-tad/Blog-post-Api/usecase"
 )
 
-func SetupRouter() *gin.Engine{
+// SetupRouter registers every route. The route groups share blogUsecase,
+// from NewBlogUseCase, so blogs and AI drafts go through one moderator
+// and one semantic search.
+func SetupRouter(blogUsecase *usecase.BlogUseCase) *gin.Engine{
 	router:=gin.Default()
+
+	// Checked by the auth middleware on every request; logout, password
+	// resets and role changes record revocations here
+	middlewares.TokenRevocations = repository.NewTokenRevocationRepository(config.RevokedTokenCollection)
 	
 	 // user routes
     SetupUserRoutes(router)
 
+	// public keys for verifying tokens
+	SetupJWKSRoutes(router)
+
 	SetupInteractionRoutes(router)
 	
 	//oauth routes
@@ -17,6 +31,18 @@ func SetupRouter() *gin.Engine{
 
 
 	// blog routes
-	SetupBlogRoutes(router)
+	SetupBlogRoutes(router, blogUsecase)
+
+	// comment routes
+	SetupCommentRoutes(router, blogUsecase)
+
+	// bookmark and reading list routes
+	SetupBookmarkRoutes(router, blogUsecase)
+
+	// follow and feed routes
+	SetupFollowRoutes(router, blogUsecase)
+
+	// AI routes
+	SetupAI(router, blogUsecase)
 	return router
 }
\ No newline at end of file
@@ -4,6 +4,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/sol-tad/Blog-post-Api/config"
 	"github.com/sol-tad/Blog-post-Api/delivery/controllers"
+	"github.com/sol-tad/Blog-post-Api/domain"
 	"github.com/sol-tad/Blog-post-Api/middlewares"
 	"github.com/sol-tad/Blog-post-Api/repository"
 	"github.com/sol-tad/Blog-post-Api/usecase"
@@ -15,8 +16,14 @@ func SetupUserRoutes(router *gin.Engine) {
 	userDbCollection:=config.UserCollection
 
 	userRepository:=repository.NewUserRepository(userDbCollection)
-	userUsecase:=usecase.NewUserUsecase(userRepository)
+	followRepository:=repository.NewFollowRepository(config.FollowCollection)
+	sessionUsecase, twoFactorUsecase := newAuthUsecases(userRepository)
+	userUsecase:=usecase.NewUserUsecase(userRepository, followRepository, sessionUsecase, twoFactorUsecase)
 	userController:=controllers.NewUserController(userUsecase)
+	sessionController:=controllers.NewSessionController(sessionUsecase)
+	twoFactorController := controllers.NewTwoFactorController(twoFactorUsecase)
+	roleUsecase := usecase.NewRoleUsecase(userRepository, repository.NewRoleChangeRepository(config.RoleChangeCollection), sessionUsecase)
+	roleController := controllers.NewRoleController(roleUsecase)
 
 	userRoutes:=router.Group("")
 
@@ -26,8 +33,22 @@ func SetupUserRoutes(router *gin.Engine) {
 		userRoutes.POST("/register",userController.Register)
 		userRoutes.POST("/verify-otp",userController.VerifyOTP)
 		userRoutes.POST("/login",userController.Login)
+		userRoutes.POST("/login/2fa", userController.VerifyLogin)
 		userRoutes.POST("/refresh",userController.RefreshTokenController)
 		userRoutes.POST("/logout",middlewares.AuthMiddleware(),userController.Logout)
+
+		// Signed in devices
+		userRoutes.GET("/me/sessions", middlewares.AuthMiddleware(), sessionController.ListSessions)
+		userRoutes.DELETE("/me/sessions/:id", middlewares.AuthMiddleware(), sessionController.RevokeSession)
+
+		// Two-factor authentication
+		userRoutes.GET("/me/2fa", middlewares.AuthMiddleware(), twoFactorController.Status)
+		userRoutes.POST("/me/2fa/enroll", middlewares.AuthMiddleware(), twoFactorController.Enroll)
+		userRoutes.POST("/me/2fa/confirm", middlewares.AuthMiddleware(), twoFactorController.Confirm)
+		userRoutes.POST("/me/2fa/disable", middlewares.AuthMiddleware(), twoFactorController.Disable)
+		userRoutes.POST("/me/2fa/recovery-codes", middlewares.AuthMiddleware(), twoFactorController.RegenerateRecoveryCodes)
+		userRoutes.GET("/security/2fa-policy", middlewares.AuthMiddleware(), middlewares.RequirePermission(domain.PermSecurityManage), twoFactorController.GetPolicy)
+		userRoutes.PUT("/security/2fa-policy", middlewares.AuthMiddleware(), middlewares.RequirePermission(domain.PermSecurityManage), twoFactorController.SetPolicy)
 		
 		userRoutes.POST("/forgot-password", userController.SendResetOTP)
 		userRoutes.POST("/reset-password", userController.ResetPassword)
@@ -35,11 +56,26 @@ func SetupUserRoutes(router *gin.Engine) {
 		userRoutes.PUT("/profile", middlewares.AuthMiddleware(), userController.UpdateProfile)
 
 
-		userRoutes.POST("/user/:id/promote", middlewares.AuthMiddleware(), userController.PromoteUser)
-		userRoutes.POST("/user/:id/demote", middlewares.AuthMiddleware(), userController.DemoteUser)
+		// Roles. RequirePermission checks the token's role, which the
+		// two-factor policy may have withheld
+		manageRoles := middlewares.RequirePermission(domain.PermRolesManage)
+		userRoutes.GET("/admin/roles", middlewares.AuthMiddleware(), manageRoles, roleController.ListRoles)
+		userRoutes.PUT("/admin/users/:id/role", middlewares.AuthMiddleware(), manageRoles, roleController.AssignRole)
+		userRoutes.GET("/admin/role-changes", middlewares.AuthMiddleware(), manageRoles, roleController.ListRoleChanges)
+		userRoutes.POST("/user/:id/promote", middlewares.AuthMiddleware(), manageRoles, roleController.PromoteUser)
+		userRoutes.POST("/user/:id/demote", middlewares.AuthMiddleware(), manageRoles, roleController.DemoteUser)
 
 	}
 }
 
+// newAuthUsecases wires up sessions and two-factor authentication, which
+// the password and Google logins share
+func newAuthUsecases(userRepository domain.UserRepository) (*usecase.SessionUsecase, *usecase.TwoFactorUsecase) {
+	policies := repository.NewTwoFactorPolicyRepository(config.SettingsCollection)
+	sessionUsecase := usecase.NewSessionUsecase(repository.NewSessionRepository(config.SessionCollection), userRepository, middlewares.TokenRevocations, policies)
+	twoFactorUsecase := usecase.NewTwoFactorUsecase(repository.NewTwoFactorRepository(config.TwoFactorCollection), policies, userRepository, sessionUsecase, middlewares.TokenRevocations)
+	return sessionUsecase, twoFactorUsecase
+}
+
 
 
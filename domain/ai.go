@@ -1,5 +1,10 @@
 package domain
 
+import (
+    "context"
+    "time"
+)
+
 // AI Generation Parameters
 type GenerationParams struct {
     Topic     string   `json:"topic"`
@@ -7,6 +12,12 @@ type GenerationParams struct {
     Tags      []string `json:"tags,omitempty"`
     Tone      string   `json:"tone,omitempty"` // e.g., "professional", "casual", "humorous"
     Length    int      `json:"length,omitempty"` // word count
+    // Template names the prompt template to use, as "name" for its latest
+    // version or "name@version"
+    Template  string   `json:"template,omitempty" bson:"template,omitempty"`
+    // Prompt is the prompt rendered from Template; providers build their
+    // default prompt when it is empty
+    Prompt    string   `json:"-" bson:"-"`
 }
 
 // AI Response Structure
@@ -14,8 +25,41 @@ type AIResponse struct {
     Content string `json:"content"`
 }
 
-// AI Service Interface
+// AI provenance of a blog drafted by the AI generator
+type AIProvenance struct {
+    Provider    string           `json:"provider" bson:"provider"`
+    Prompt      string           `json:"prompt" bson:"prompt"`
+    Template    string           `json:"template,omitempty" bson:"template,omitempty"`
+    Params      GenerationParams `json:"params" bson:"params"`
+    GeneratedAt time.Time        `json:"generated_at" bson:"generated_at"`
+    Reviewed    bool             `json:"reviewed" bson:"reviewed"`
+    ReviewedAt  *time.Time       `json:"reviewed_at,omitempty" bson:"reviewed_at,omitempty"`
+}
+
+// AI Service Interface. Calls stop when ctx is cancelled or its deadline
+// passes.
 type AIService interface {
-    GenerateContent(params GenerationParams) (string, error)
-    SummarizeBlog(content string) (string, error)
-}
\ No newline at end of file
+    // Name identifies the provider and model, e.g. "openai:llama3"
+    Name() string
+    GenerateContent(ctx context.Context, params GenerationParams) (string, error)
+    SummarizeBlog(ctx context.Context, content string) (string, error)
+    // Complete answers a prompt rendered from a template
+    Complete(ctx context.Context, prompt string) (string, error)
+    // SuggestTags proposes up to max tags for the content, preferring the
+    // existing tags it is given
+    SuggestTags(ctx context.Context, content string, existing []string, max int) ([]string, error)
+    // SuggestTitles proposes up to count alternative titles
+    SuggestTitles(ctx context.Context, title, content string, count int) ([]string, error)
+    // Translate translates a post into language, a tag such as "fr", and
+    // returns it as Markdown headed by the translated title
+    Translate(ctx context.Context, title, content, language string) (string, error)
+}
+
+// Streaming AI Service Interface, implemented by providers that can push
+// generated text as it is produced. onChunk is called for every piece of
+// text in order; generation stops early if it returns an error or ctx is
+// cancelled. The assembled content is returned.
+type AIStreamService interface {
+    AIService
+    GenerateContentStream(ctx context.Context, params GenerationParams, onChunk func(chunk string) error) (string, error)
+}
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Kinds of AI provider failure. Provider errors wrap one of them, so callers
+// can tell them apart with errors.Is.
+var (
+	ErrAIRateLimited         = errors.New("AI provider is rate limiting requests")
+	ErrAIProviderUnavailable = errors.New("AI provider is unavailable")
+	ErrAICircuitOpen         = errors.New("AI provider keeps failing; calls are paused")
+	ErrAITimeout             = errors.New("AI provider timed out")
+)
+
+// AIProviderError is a failed call to an AI provider
+type AIProviderError struct {
+	// Kind is one of the ErrAI... errors above
+	Kind error
+	// Op is the operation that failed, e.g. "generate"
+	Op string
+	// RetryAfter is how long the provider asked callers to wait, if it did
+	RetryAfter time.Duration
+	// Err is the underlying error, if any
+	Err error
+}
+
+func (e *AIProviderError) Error() string {
+	msg := e.Kind.Error()
+	if e.Op != "" {
+		msg = e.Op + ": " + msg
+	}
+	if e.Err != nil {
+		msg = fmt.Sprintf("%s: %v", msg, e.Err)
+	}
+	return msg
+}
+
+func (e *AIProviderError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
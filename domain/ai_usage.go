@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AI operations recorded in the usage log
+const (
+	AIOperationGenerate       = "generate"
+	AIOperationGenerateStream = "generate_stream"
+	AIOperationDraft          = "draft"
+	AIOperationSummarize      = "summarize"
+	AIOperationSuggestTags    = "suggest_tags"
+	AIOperationSuggestTitles  = "suggest_titles"
+	AIOperationTranslate      = "translate"
+)
+
+// AIUsage records a single call to the AI provider
+type AIUsage struct {
+	ID            primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	UserID        primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Role          string             `json:"role" bson:"role"`
+	Operation     string             `json:"operation" bson:"operation"`
+	Provider      string             `json:"provider" bson:"provider"`
+	PromptChars   int                `json:"prompt_chars" bson:"prompt_chars"`
+	ResponseChars int                `json:"response_chars" bson:"response_chars"`
+	LatencyMs     int64              `json:"latency_ms" bson:"latency_ms"`
+	Success       bool               `json:"success" bson:"success"`
+	CreatedAt     time.Time          `json:"created_at" bson:"created_at"`
+}
+
+// AIQuota limits the number of AI calls a role may make. Zero means no limit.
+type AIQuota struct {
+	Daily   int64 `json:"daily"`
+	Monthly int64 `json:"monthly"`
+}
+
+// AIQuotaStatus reports how much of their quota a user has used
+type AIQuotaStatus struct {
+	Role          string            `json:"role"`
+	Quota         AIQuota           `json:"quota"`
+	UsedToday     int64             `json:"used_today"`
+	UsedThisMonth int64             `json:"used_this_month"`
+	Operations    []*AIUsageSummary `json:"operations"`
+}
+
+// AIUsageFilter selects usage records for a report
+type AIUsageFilter struct {
+	UserID primitive.ObjectID
+	From   time.Time
+	To     time.Time
+}
+
+// AIUsageSummary totals the usage of one user for one operation
+type AIUsageSummary struct {
+	UserID        primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Operation     string             `json:"operation" bson:"operation"`
+	Calls         int64              `json:"calls" bson:"calls"`
+	Failures      int64              `json:"failures" bson:"failures"`
+	PromptChars   int64              `json:"prompt_chars" bson:"prompt_chars"`
+	ResponseChars int64              `json:"response_chars" bson:"response_chars"`
+	AvgLatencyMs  float64            `json:"avg_latency_ms" bson:"avg_latency_ms"`
+}
+
+// THIS IS THE INTERFACE FOR AI USAGE DATA OPERATIONS
+type AIUsageRepository interface {
+	Record(usage *AIUsage) error
+	CountSince(userID primitive.ObjectID, since time.Time) (int64, error)
+	Summarize(filter AIUsageFilter) ([]*AIUsageSummary, error)
+}
@@ -0,0 +1,157 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Roles a user can be assigned
+const (
+	RoleReader    = "reader"
+	RoleAuthor    = "author"
+	RoleEditor    = "editor"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+	// RoleUser is what every account got before roles were split up. It
+	// has the permissions of an author and cannot be assigned any more.
+	RoleUser = "user"
+)
+
+// DefaultRole is given to new accounts
+const DefaultRole = RoleAuthor
+
+// Permission names something a role allows. Permissions ending in _any
+// allow acting on other users' content; owners may always act on their own.
+type Permission string
+
+const (
+	// PermBlogsCreate allows writing blogs and changing one's own
+	PermBlogsCreate Permission = "blogs:create"
+	// PermBlogsPublish allows publishing and scheduling blogs the role may
+	// manage
+	PermBlogsPublish         Permission = "blogs:publish"
+	PermBlogsEditAny         Permission = "blogs:edit_any"
+	PermBlogsDeleteAny       Permission = "blogs:delete_any"
+	PermBlogsViewUnpublished Permission = "blogs:view_unpublished"
+	// PermBlogsPublishFlagged allows publishing blogs held by moderation
+	PermBlogsPublishFlagged Permission = "blogs:publish_flagged"
+	PermCommentsCreate      Permission = "comments:create"
+	PermCommentsModerateAny Permission = "comments:moderate_any"
+	PermCommentsDeleteAny   Permission = "comments:delete_any"
+	PermAIUse               Permission = "ai:use"
+	// PermAIManage covers the usage report and prompt templates
+	PermAIManage       Permission = "ai:manage"
+	PermRolesManage    Permission = "roles:manage"
+	PermSecurityManage Permission = "security:manage"
+)
+
+var (
+	readerPermissions = []Permission{PermCommentsCreate}
+	authorPermissions = append([]Permission{PermBlogsCreate, PermBlogsPublish, PermAIUse}, readerPermissions...)
+)
+
+// rolePermissions maps each role to what it may do. Admins may do
+// everything.
+var rolePermissions = map[string][]Permission{
+	RoleReader: readerPermissions,
+	RoleAuthor: authorPermissions,
+	RoleEditor: append([]Permission{
+		PermBlogsEditAny,
+		PermBlogsDeleteAny,
+		PermBlogsViewUnpublished,
+	}, authorPermissions...),
+	RoleModerator: append([]Permission{
+		PermBlogsPublish,
+		PermBlogsDeleteAny,
+		PermBlogsViewUnpublished,
+		PermBlogsPublishFlagged,
+		PermCommentsModerateAny,
+		PermCommentsDeleteAny,
+	}, readerPermissions...),
+	RoleAdmin: {
+		PermBlogsCreate,
+		PermBlogsPublish,
+		PermBlogsEditAny,
+		PermBlogsDeleteAny,
+		PermBlogsViewUnpublished,
+		PermBlogsPublishFlagged,
+		PermCommentsCreate,
+		PermCommentsModerateAny,
+		PermCommentsDeleteAny,
+		PermAIUse,
+		PermAIManage,
+		PermRolesManage,
+		PermSecurityManage,
+	},
+	RoleUser: authorPermissions,
+}
+
+// AssignableRoles lists the roles in order of increasing privilege
+var AssignableRoles = []string{RoleReader, RoleAuthor, RoleEditor, RoleModerator, RoleAdmin}
+
+// IsAssignableRole reports whether users can be given the role
+func IsAssignableRole(role string) bool {
+	for _, r := range AssignableRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleHas reports whether the role grants the permission
+func RoleHas(role string, permission Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// CanActOn reports whether a user may act on something owned by ownerID:
+// owners always may, anyone else needs the permission
+func CanActOn(userID, role string, ownerID primitive.ObjectID, permission Permission) bool {
+	if userID != "" && ownerID.Hex() == userID {
+		return true
+	}
+	return RoleHas(role, permission)
+}
+
+// RoleDefinition describes a role and its permissions
+type RoleDefinition struct {
+	Name        string       `json:"name"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// RoleDefinitions returns the assignable roles with their permissions
+func RoleDefinitions() []RoleDefinition {
+	roles := make([]RoleDefinition, len(AssignableRoles))
+	for i, role := range AssignableRoles {
+		roles[i] = RoleDefinition{Name: role, Permissions: rolePermissions[role]}
+	}
+	return roles
+}
+
+// RoleChange records one role assignment, for the audit trail
+type RoleChange struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID    primitive.ObjectID `json:"user_id" bson:"user_id"`
+	OldRole   string             `json:"old_role" bson:"old_role"`
+	NewRole   string             `json:"new_role" bson:"new_role"`
+	ChangedBy primitive.ObjectID `json:"changed_by" bson:"changed_by"`
+	Reason    string             `json:"reason,omitempty" bson:"reason,omitempty"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+}
+
+// THIS IS THE INTERFACE FOR ROLE CHANGE AUDIT DATA OPERATIONS
+type RoleChangeRepository interface {
+	Create(ctx context.Context, change *RoleChange) error
+	// Delete removes an entry whose change could not be applied
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	// List returns the changes to one user's role, or to everyone's when
+	// userID is zero, newest first
+	List(ctx context.Context, userID primitive.ObjectID, page, limit int) ([]*RoleChange, int64, error)
+}
@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRoleHas(t *testing.T) {
+	tests := []struct {
+		role       string
+		permission Permission
+		want       bool
+	}{
+		{RoleReader, PermCommentsCreate, true},
+		{RoleReader, PermBlogsCreate, false},
+		{RoleReader, PermBlogsPublish, false},
+		{RoleReader, PermAIUse, false},
+		{RoleAuthor, PermBlogsCreate, true},
+		{RoleAuthor, PermBlogsPublish, true},
+		{RoleAuthor, PermAIUse, true},
+		{RoleAuthor, PermBlogsEditAny, false},
+		{RoleUser, PermBlogsCreate, true},
+		{RoleUser, PermRolesManage, false},
+		{RoleEditor, PermBlogsEditAny, true},
+		{RoleEditor, PermBlogsViewUnpublished, true},
+		{RoleEditor, PermBlogsPublishFlagged, false},
+		{RoleEditor, PermCommentsModerateAny, false},
+		{RoleModerator, PermBlogsPublish, true},
+		{RoleModerator, PermBlogsPublishFlagged, true},
+		{RoleModerator, PermCommentsModerateAny, true},
+		{RoleModerator, PermBlogsCreate, false},
+		{RoleModerator, PermBlogsEditAny, false},
+		{RoleAdmin, PermRolesManage, true},
+		{RoleAdmin, PermSecurityManage, true},
+		{"", PermCommentsCreate, false},
+		{"superuser", PermRolesManage, false},
+	}
+	for _, tt := range tests {
+		if got := RoleHas(tt.role, tt.permission); got != tt.want {
+			t.Errorf("RoleHas(%q, %q) = %v, want %v", tt.role, tt.permission, got, tt.want)
+		}
+	}
+}
+
+func TestAdminHasEveryPermission(t *testing.T) {
+	for role, permissions := range rolePermissions {
+		for _, permission := range permissions {
+			if !RoleHas(RoleAdmin, permission) {
+				t.Errorf("admin lacks %q, which %s has", permission, role)
+			}
+		}
+	}
+}
+
+func TestCanActOn(t *testing.T) {
+	owner := primitive.NewObjectID()
+	other := primitive.NewObjectID().Hex()
+
+	tests := []struct {
+		name   string
+		userID string
+		role   string
+		want   bool
+	}{
+		{"owner without the permission", owner.Hex(), RoleReader, true},
+		{"other user without the permission", other, RoleAuthor, false},
+		{"other user with the permission", other, RoleEditor, true},
+		{"anonymous user", "", RoleReader, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanActOn(tt.userID, tt.role, owner, PermBlogsEditAny); got != tt.want {
+				t.Errorf("CanActOn = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsAssignableRole(t *testing.T) {
+	for _, role := range AssignableRoles {
+		if !IsAssignableRole(role) {
+			t.Errorf("%s is not assignable", role)
+		}
+	}
+	if IsAssignableRole(RoleUser) {
+		t.Error("the legacy user role is assignable")
+	}
+}
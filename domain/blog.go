@@ -1,6 +1,9 @@
 package domain
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -10,18 +13,200 @@ import (
 type Blog struct {
 	ID  primitive.ObjectID        `json:"id,omitempty" bson:"_id,omitempty"`
 	Title string            	  `json:"title,omitempty" bson:"title,omitempty" validate:"required"`
+	Slug  string                  `json:"slug,omitempty" bson:"slug,omitempty"`
+	// OldSlugs keeps slugs from earlier titles so existing links still resolve
+	OldSlugs []string             `json:"-" bson:"old_slugs,omitempty"`
 	Content string 				  `json:"content,omitempty" bson:"content,omitempty" validate:"required"`
+	// ContentHTML caches Content rendered from Markdown into sanitized HTML
+	ContentHTML   string          `json:"content_html,omitempty" bson:"content_html,omitempty"`
+	RenderVersion int             `json:"-" bson:"render_version,omitempty"`
 	AuthorID primitive.ObjectID   `json:"author_id" bson:"author_id" validate:"required"`
 	AuthorName string             `json:"author_name" bson:"author_name" validate:"required"`
 	Tags       []string           `json:"tags" bson:"tags" validate:"required"`
 	CreatedAt   time.Time       `json:"created_at" bson:"created_at"`
 	UpdatedAt  time.Time         `json:"updated_at" bson:"updated_at"`
 	Stats     BlogStats    		`json:"stats" bson:"stats"`
+	Status      string          `json:"status" bson:"status"`
+	PublishedAt *time.Time      `json:"published_at,omitempty" bson:"published_at,omitempty"`
+	PublishAt   *time.Time      `json:"publish_at,omitempty" bson:"publish_at,omitempty"`
+	CommentPolicy string        `json:"comment_policy,omitempty" bson:"comment_policy,omitempty"`
+	// AI is set on blogs drafted by the AI generator
+	AI *AIProvenance            `json:"ai,omitempty" bson:"ai,omitempty"`
+	// Summary is an AI summary of Content; it is dropped when Content changes
+	Summary *BlogSummary        `json:"summary,omitempty" bson:"summary,omitempty"`
+	// Excerpt carries the summary text in blog listings
+	Excerpt string              `json:"excerpt,omitempty" bson:"-"`
+	// Moderation is the verdict on the blog as last written
+	Moderation *ModerationVerdict `json:"moderation,omitempty" bson:"moderation,omitempty"`
+	// Language is the language the blog was written in
+	Language string             `json:"language,omitempty" bson:"language,omitempty"`
+	// Translation is set when the blog is served in another language; its
+	// title and content then replace the blog's own
+	Translation *BlogTranslation `json:"translation,omitempty" bson:"-"`
 
 
 
 }
 
+// Blog lifecycle states. Only published blogs are visible to readers who
+// are neither the author nor an admin.
+const (
+	BlogStatusDraft     = "draft"
+	BlogStatusInReview  = "in_review"
+	BlogStatusPublished = "published"
+	BlogStatusArchived  = "archived"
+	// BlogStatusScheduled blogs are published by the background publisher
+	// once their PublishAt time has passed
+	BlogStatusScheduled = "scheduled"
+)
+
+// blogTransitions lists the states a blog may move to from each state
+var blogTransitions = map[string][]string{
+	BlogStatusDraft:     {BlogStatusInReview, BlogStatusScheduled, BlogStatusPublished, BlogStatusArchived},
+	BlogStatusInReview:  {BlogStatusDraft, BlogStatusScheduled, BlogStatusPublished},
+	BlogStatusScheduled: {BlogStatusDraft, BlogStatusScheduled, BlogStatusPublished},
+	BlogStatusPublished: {BlogStatusDraft, BlogStatusArchived},
+	BlogStatusArchived:  {BlogStatusDraft, BlogStatusPublished},
+}
+
+// IsValidBlogStatus reports whether status is one of the known lifecycle states
+func IsValidBlogStatus(status string) bool {
+	_, ok := blogTransitions[status]
+	return ok
+}
+
+// CanTransitionBlog reports whether a blog may move from one status to another
+func CanTransitionBlog(from, to string) bool {
+	for _, next := range blogTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// BlogSummary is a generated summary of a blog's content. ContentHash
+// identifies the content it was generated from.
+type BlogSummary struct {
+	Text        string    `json:"text" bson:"text"`
+	ContentHash string    `json:"content_hash" bson:"content_hash"`
+	Provider    string    `json:"provider" bson:"provider"`
+	Template    string    `json:"template,omitempty" bson:"template,omitempty"`
+	GeneratedAt time.Time `json:"generated_at" bson:"generated_at"`
+}
+
+// TemplateName returns the name of the prompt template the summary was
+// made with, without its version
+func (s *BlogSummary) TemplateName() string {
+	name, _, _ := strings.Cut(s.Template, "@")
+	return name
+}
+
+// HashContent returns the hash stored with summaries of the content
+func HashContent(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// CurrentSummary returns the blog's summary if it still matches the content
+func (b *Blog) CurrentSummary() *BlogSummary {
+	if b.Summary == nil || b.Summary.ContentHash != HashContent(b.Content) {
+		return nil
+	}
+	return b.Summary
+}
+
+// NeedsAIReview reports whether the blog is AI output its author has not
+// confirmed yet. Such blogs stay drafts.
+func (b *Blog) NeedsAIReview() bool {
+	return b.AI != nil && !b.AI.Reviewed
+}
+
+// CurrentStatus returns the blog status, treating blogs stored before the
+// lifecycle existed as published
+func (b *Blog) CurrentStatus() string {
+	if b.Status == "" {
+		return BlogStatusPublished
+	}
+	return b.Status
+}
+
+// ReservedBlogSlugs are the static paths under /blogs. A blog with one of
+// them as its slug could not be reached by it, so they are never given out.
+var ReservedBlogSlugs = []string{"create", "mine", "semantic-search"}
+
+// IsReservedBlogSlug reports whether slug is one of ReservedBlogSlugs
+func IsReservedBlogSlug(slug string) bool {
+	for _, reserved := range ReservedBlogSlugs {
+		if slug == reserved {
+			return true
+		}
+	}
+	return false
+}
+
+// FeedTime is when the blog went live, or when it was created for blogs
+// published before publish times were recorded. Feeds are ordered by it.
+func (b *Blog) FeedTime() time.Time {
+	if b.PublishedAt != nil {
+		return *b.PublishedAt
+	}
+	return b.CreatedAt
+}
+
+// VisibleTo reports whether the blog can be read by the given user
+func (b *Blog) VisibleTo(userID, role string) bool {
+	if b.CurrentStatus() == BlogStatusPublished {
+		return true
+	}
+	return CanActOn(userID, role, b.AuthorID, PermBlogsViewUnpublished)
+}
+
+// Comment policies decide whether new comments go live immediately, wait
+// for moderation or are refused
+const (
+	CommentPolicyOpen      = "open"
+	CommentPolicyModerated = "moderated"
+	CommentPolicyClosed    = "closed"
+)
+
+// IsValidCommentPolicy reports whether policy is a known comment policy
+func IsValidCommentPolicy(policy string) bool {
+	switch policy {
+	case CommentPolicyOpen, CommentPolicyModerated, CommentPolicyClosed:
+		return true
+	}
+	return false
+}
+
+// CurrentCommentPolicy returns the blog's comment policy, open by default
+func (b *Blog) CurrentCommentPolicy() string {
+	if b.CommentPolicy == "" {
+		return CommentPolicyOpen
+	}
+	return b.CommentPolicy
+}
+
+// Content formats a blog can be returned in
+const (
+	ContentFormatRaw  = "raw"
+	ContentFormatHTML = "html"
+	ContentFormatBoth = "both"
+)
+
+// WithFormat returns a copy of the blog carrying only the requested content
+// representation
+func (b Blog) WithFormat(format string) Blog {
+	switch format {
+	case ContentFormatHTML:
+		b.Content = ""
+	case ContentFormatBoth:
+	default:
+		b.ContentHTML = ""
+	}
+	return b
+}
+
 // BlogStats is the engagement metrics for blogs  
 type BlogStats struct {
 	Views int `json:"views" bson:"views"`
@@ -60,5 +245,11 @@ type BlogFilter struct {
 	 EndDate    time.Time
 	  SortBy    string
 	  SortOrder string  // "asc" or "dsc"
+	  Status    string
+	  // Viewer fields decide which unpublished blogs may be listed
+	  ViewerID  primitive.ObjectID
+	  IncludeUnpublished bool
+	  // OnlyViewer restricts the listing to blogs authored by the viewer
+	  OnlyViewer bool
 }
 
@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCanTransitionBlog(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{BlogStatusDraft, BlogStatusInReview, true},
+		{BlogStatusDraft, BlogStatusScheduled, true},
+		{BlogStatusDraft, BlogStatusPublished, true},
+		{BlogStatusDraft, BlogStatusArchived, true},
+		{BlogStatusDraft, BlogStatusDraft, false},
+		{BlogStatusInReview, BlogStatusDraft, true},
+		{BlogStatusInReview, BlogStatusPublished, true},
+		{BlogStatusInReview, BlogStatusArchived, false},
+		{BlogStatusScheduled, BlogStatusScheduled, true},
+		{BlogStatusScheduled, BlogStatusPublished, true},
+		{BlogStatusScheduled, BlogStatusArchived, false},
+		{BlogStatusPublished, BlogStatusDraft, true},
+		{BlogStatusPublished, BlogStatusArchived, true},
+		{BlogStatusPublished, BlogStatusInReview, false},
+		{BlogStatusPublished, BlogStatusScheduled, false},
+		{BlogStatusArchived, BlogStatusPublished, true},
+		{BlogStatusArchived, BlogStatusDraft, true},
+		{BlogStatusArchived, BlogStatusInReview, false},
+		{"", BlogStatusPublished, false},
+		{BlogStatusDraft, "deleted", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+"->"+tt.to, func(t *testing.T) {
+			if got := CanTransitionBlog(tt.from, tt.to); got != tt.want {
+				t.Errorf("CanTransitionBlog(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsValidBlogStatus(t *testing.T) {
+	for _, status := range []string{BlogStatusDraft, BlogStatusInReview, BlogStatusScheduled, BlogStatusPublished, BlogStatusArchived} {
+		if !IsValidBlogStatus(status) {
+			t.Errorf("%q is not valid", status)
+		}
+	}
+	for _, status := range []string{"", "deleted", "Published"} {
+		if IsValidBlogStatus(status) {
+			t.Errorf("%q is valid", status)
+		}
+	}
+}
+
+func TestBlogVisibleTo(t *testing.T) {
+	author := primitive.NewObjectID()
+	other := primitive.NewObjectID().Hex()
+	tests := []struct {
+		name   string
+		status string
+		userID string
+		role   string
+		want   bool
+	}{
+		{"published to anyone", BlogStatusPublished, "", "", true},
+		{"legacy blog to anyone", "", "", "", true},
+		{"draft to anonymous", BlogStatusDraft, "", "", false},
+		{"draft to another author", BlogStatusDraft, other, RoleAuthor, false},
+		{"draft to its author", BlogStatusDraft, author.Hex(), RoleAuthor, true},
+		{"draft to an editor", BlogStatusDraft, other, RoleEditor, true},
+		{"scheduled to a reader", BlogStatusScheduled, other, RoleReader, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blog := &Blog{Status: tt.status, AuthorID: author}
+			if got := blog.VisibleTo(tt.userID, tt.role); got != tt.want {
+				t.Errorf("VisibleTo = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
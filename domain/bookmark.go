@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BOOKMARK STRUCT
+
+// Bookmark saves a blog for a user. Bookmarks without a ListID belong to
+// the user's default "saved" list.
+type Bookmark struct {
+	ID        primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	UserID    primitive.ObjectID `json:"user_id" bson:"user_id"`
+	BlogID    primitive.ObjectID `json:"blog_id" bson:"blog_id"`
+	ListID    primitive.ObjectID `json:"list_id" bson:"list_id"`
+	Position  int                `json:"position" bson:"position"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+	Blog      *Blog              `json:"blog,omitempty" bson:"blog,omitempty"`
+}
+
+// ReadingList is a named collection of bookmarks
+type ReadingList struct {
+	ID        primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	UserID    primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Name      string             `json:"name" bson:"name"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// BookmarkOrder returns the blogs of a list in their new order: the
+// requested blogs that are in the list first, then the others in their
+// current order
+func BookmarkOrder(current, requested []primitive.ObjectID) []primitive.ObjectID {
+	inList := make(map[primitive.ObjectID]bool, len(current))
+	for _, id := range current {
+		inList[id] = true
+	}
+
+	order := make([]primitive.ObjectID, 0, len(current))
+	placed := make(map[primitive.ObjectID]bool, len(current))
+	for _, id := range requested {
+		if inList[id] && !placed[id] {
+			order = append(order, id)
+			placed[id] = true
+		}
+	}
+	for _, id := range current {
+		if !placed[id] {
+			order = append(order, id)
+		}
+	}
+	return order
+}
+
+// THIS IS THE INTERFACE FOR BOOKMARK DATA OPERATIONS
+type BookmarkRepository interface {
+	Add(bookmark *Bookmark) error
+	Remove(userID, blogID, listID primitive.ObjectID) error
+	List(userID, listID primitive.ObjectID, page, limit int) ([]*Bookmark, int64, error)
+	Reorder(userID, listID primitive.ObjectID, blogIDs []primitive.ObjectID) error
+	DeleteByBlog(blogID primitive.ObjectID) error
+
+	CreateList(list *ReadingList) error
+	GetList(id primitive.ObjectID) (*ReadingList, error)
+	ListLists(userID primitive.ObjectID) ([]*ReadingList, error)
+	RenameList(id primitive.ObjectID, name string) error
+	DeleteList(id primitive.ObjectID) error
+}
@@ -16,8 +16,35 @@ type Comment struct {
 	Content string 					  `json:"content" bson:"content"`
 	CreatedAt time.Time         	  `json:"created_at" bson:"created_at"`
 	UpdatedAt time.Time         	  `json:"updated_at" bson:"updated_at"`               
+	ParentID primitive.ObjectID       `json:"parent_id,omitempty" bson:"parent_id,omitempty"`
+	Depth    int                      `json:"depth" bson:"depth"`
+	// Deleted marks a tombstone left in place of a comment that has replies
+	Deleted  bool                     `json:"deleted,omitempty" bson:"deleted,omitempty"`
+	Replies  []*Comment               `json:"replies,omitempty" bson:"-"`
+	Status   string                   `json:"status,omitempty" bson:"status,omitempty"`
+	// Moderation is the verdict on the comment as last written
+	Moderation *ModerationVerdict     `json:"moderation,omitempty" bson:"moderation,omitempty"`
 }
 
+// Comment moderation states
+const (
+	CommentStatusPending  = "pending"
+	CommentStatusApproved = "approved"
+	CommentStatusRejected = "rejected"
+)
+
+// CurrentStatus returns the moderation status, treating comments stored
+// before moderation existed as approved
+func (c *Comment) CurrentStatus() string {
+	if c.Status == "" {
+		return CommentStatusApproved
+	}
+	return c.Status
+}
+
+// MaxCommentDepth is the deepest a reply can be nested below a top-level comment
+const MaxCommentDepth = 10
+
 // THIS IS THE INTERFACE FOR COMMENT DATA OPERATIONS 
 type CommentRepository interface {
 	Create(comment *Comment) error
@@ -25,6 +52,12 @@ type CommentRepository interface {
 	GetByBlog( blogID string, page, limit int) ( []*Comment, error)
 	Update(comment *Comment) error 
 	Delete(id string) error 
+	GetThread(blogID string, maxDepth int) ([]*Comment, error)
+	CountReplies(id string) (int64, error)
+	GetByStatus(blogID string, status string, page, limit int) ([]*Comment, error)
+	// SetStatus changes the status only if the comment is currently in the from
+	// status and reports whether it did
+	SetStatus(id string, from, to string) (bool, error)
 	IncrementCommentCount(id string) error
 	DecrementCommentCount(id string) error
 }
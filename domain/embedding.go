@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Embedder turns text into a vector. Texts about similar things get vectors
+// pointing in similar directions.
+type Embedder interface {
+	// EmbeddingModel identifies the model and its settings. Vectors of
+	// different models cannot be compared.
+	EmbeddingModel() string
+	Embed(ctx context.Context, text string) ([]float32, error)
+}
+
+// BlogEmbedding is the vector of a blog's title, tags and content
+type BlogEmbedding struct {
+	BlogID primitive.ObjectID `json:"blog_id" bson:"_id"`
+	Model  string             `json:"model" bson:"model"`
+	Vector []float32          `json:"vector" bson:"vector"`
+	// SourceHash is the hash of the text the vector was computed from
+	SourceHash string    `json:"source_hash" bson:"source_hash"`
+	UpdatedAt  time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// ScoredBlog is a search result with its similarity to the query, from -1
+// to 1
+type ScoredBlog struct {
+	Blog  *Blog   `json:"blog"`
+	Score float64 `json:"score"`
+}
+
+// THIS IS THE INTERFACE FOR BLOG EMBEDDING DATA OPERATIONS
+type BlogEmbeddingRepository interface {
+	// Save creates or replaces the embedding of its blog
+	Save(embedding *BlogEmbedding) error
+	Get(blogID primitive.ObjectID) (*BlogEmbedding, error)
+	// ListByModel returns up to limit embeddings made by the model, in blog
+	// ID order, starting after the given blog ID
+	ListByModel(model string, after primitive.ObjectID, limit int) ([]*BlogEmbedding, error)
+	Delete(blogID primitive.ObjectID) error
+}
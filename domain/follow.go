@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// FOLLOW STRUCT
+
+// Follow records that one user follows another
+type Follow struct {
+	ID         primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	FollowerID primitive.ObjectID `json:"follower_id" bson:"follower_id"`
+	FolloweeID primitive.ObjectID `json:"followee_id" bson:"followee_id"`
+	CreatedAt  time.Time          `json:"created_at" bson:"created_at"`
+}
+
+// UserProfile is the public view of a user
+type UserProfile struct {
+	ID             primitive.ObjectID `json:"id"`
+	Username       string             `json:"username"`
+	FullName       string             `json:"full_name,omitempty"`
+	Bio            string             `json:"bio,omitempty"`
+	ProfilePicture string             `json:"profile_picture,omitempty"`
+	FollowersCount int64              `json:"followers_count"`
+	FollowingCount int64              `json:"following_count"`
+	IsFollowing    bool               `json:"is_following"`
+}
+
+// FeedCursor marks the last blog of a feed page; the next page starts
+// right after it
+type FeedCursor struct {
+	PublishedAt time.Time
+	ID          primitive.ObjectID
+}
+
+// THIS IS THE INTERFACE FOR FOLLOW DATA OPERATIONS
+type FollowRepository interface {
+	Follow(followerID, followeeID primitive.ObjectID) (bool, error)
+	Unfollow(followerID, followeeID primitive.ObjectID) (bool, error)
+	IsFollowing(followerID, followeeID primitive.ObjectID) (bool, error)
+	FollowingIDs(followerID primitive.ObjectID) ([]primitive.ObjectID, error)
+	Followers(followeeID primitive.ObjectID, page, limit int) ([]primitive.ObjectID, error)
+	Following(followerID primitive.ObjectID, page, limit int) ([]primitive.ObjectID, error)
+	CountFollowers(userID primitive.ObjectID) (int64, error)
+	CountFollowing(userID primitive.ObjectID) (int64, error)
+}
@@ -0,0 +1,40 @@
+package domain
+
+import "time"
+
+// Moderation verdicts
+const (
+	ModerationAllow = "allow"
+	ModerationFlag  = "flag"
+)
+
+// Moderation categories
+const (
+	ModerationCategoryHarassment = "harassment"
+	ModerationCategoryHate       = "hate"
+	ModerationCategoryViolence   = "violence"
+	ModerationCategorySexual     = "sexual"
+	ModerationCategorySpam       = "spam"
+)
+
+// ModerationVerdict is the outcome of checking a piece of content. It is
+// stored on the checked document for audit.
+type ModerationVerdict struct {
+	Verdict    string   `json:"verdict" bson:"verdict"`
+	Categories []string `json:"categories,omitempty" bson:"categories,omitempty"`
+	// Reason explains the verdict, e.g. the rule that matched
+	Reason string `json:"reason,omitempty" bson:"reason,omitempty"`
+	// Moderator names the classifier that reached the verdict
+	Moderator string    `json:"moderator" bson:"moderator"`
+	CheckedAt time.Time `json:"checked_at" bson:"checked_at"`
+}
+
+// Flagged reports whether the content must be reviewed before going live
+func (v *ModerationVerdict) Flagged() bool {
+	return v != nil && v.Verdict == ModerationFlag
+}
+
+// ContentModerator classifies user submitted text
+type ContentModerator interface {
+	Moderate(text string) (*ModerationVerdict, error)
+}
@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Prompt template kinds
+const (
+	PromptKindGenerate  = "generate"
+	PromptKindSummarize = "summarize"
+)
+
+// PromptTemplate is one version of a named prompt. Editing a template adds
+// a new version; earlier versions stay available.
+type PromptTemplate struct {
+	ID          primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	Name        string             `json:"name" bson:"name"`
+	Version     int                `json:"version" bson:"version"`
+	Kind        string             `json:"kind" bson:"kind"`
+	Description string             `json:"description,omitempty" bson:"description,omitempty"`
+	Body        string             `json:"body" bson:"body"`
+	CreatedBy   primitive.ObjectID `json:"created_by" bson:"created_by"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+}
+
+// Ref identifies this version, e.g. "outline@2"
+func (t *PromptTemplate) Ref() string {
+	return t.Name + "@" + strconv.Itoa(t.Version)
+}
+
+// PromptData is bound to template variables: the generation parameters,
+// such as {{.Topic}} and {{.Tags}}, and {{.Content}} for summaries
+type PromptData struct {
+	GenerationParams
+	Content string
+}
+
+// THIS IS THE INTERFACE FOR PROMPT TEMPLATE DATA OPERATIONS
+type PromptTemplateRepository interface {
+	Create(template *PromptTemplate) error
+	Latest(name string) (*PromptTemplate, error)
+	GetVersion(name string, version int) (*PromptTemplate, error)
+	ListLatest() ([]*PromptTemplate, error)
+	Versions(name string) ([]*PromptTemplate, error)
+}
@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BlogRevision is a snapshot of a blog taken right before it was updated
+type BlogRevision struct {
+	ID       primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	BlogID   primitive.ObjectID `json:"blog_id" bson:"blog_id"`
+	Revision int                `json:"revision" bson:"revision"`
+	Title    string             `json:"title" bson:"title"`
+	Content  string             `json:"content" bson:"content"`
+	Tags     []string           `json:"tags" bson:"tags"`
+	EditedBy primitive.ObjectID `json:"edited_by" bson:"edited_by"`
+	EditedAt time.Time          `json:"edited_at" bson:"edited_at"`
+}
+
+// Line diff operations
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+// DiffLine is one line of a line-level diff between two texts
+type DiffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// RevisionDiff compares two versions of a blog. A To of 0 means the current
+// version of the blog.
+type RevisionDiff struct {
+	From    int        `json:"from"`
+	To      int        `json:"to"`
+	Title   []DiffLine `json:"title"`
+	Content []DiffLine `json:"content"`
+}
+
+// THIS IS THE INTERFACE FOR BLOG REVISION DATA OPERATIONS
+type BlogRevisionRepository interface {
+	Create(revision *BlogRevision) error
+	ListByBlog(blogID primitive.ObjectID, page, limit int) ([]*BlogRevision, error)
+	GetByNumber(blogID primitive.ObjectID, revision int) (*BlogRevision, error)
+}
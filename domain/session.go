@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Reasons a session was revoked
+const (
+	SessionRevokedLogout        = "logout"
+	SessionRevokedByUser        = "revoked"
+	SessionRevokedReuse         = "refresh_token_reuse"
+	SessionRevokedPasswordReset = "password_reset"
+)
+
+// Session is one signed in device. Each refresh replaces its refresh token,
+// so the tokens issued to a session form a family; presenting any but the
+// latest means the family leaked and the whole session is revoked.
+type Session struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id"`
+	UserID    primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Device    string             `json:"device" bson:"device"`
+	UserAgent string             `json:"user_agent" bson:"user_agent"`
+	IP        string             `json:"ip" bson:"ip"`
+	// TokenHash is the hash of the only refresh token the session accepts
+	TokenHash string `json:"-" bson:"token_hash"`
+	// AuthMethods lists how the user signed in, such as a password and a
+	// one-time code; tokens of the session carry it in their amr claim
+	AuthMethods   []string   `json:"auth_methods" bson:"auth_methods"`
+	CreatedAt     time.Time  `json:"created_at" bson:"created_at"`
+	LastUsedAt    time.Time  `json:"last_used_at" bson:"last_used_at"`
+	ExpiresAt     time.Time  `json:"expires_at" bson:"expires_at"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty" bson:"revoked_at,omitempty"`
+	RevokedReason string     `json:"revoked_reason,omitempty" bson:"revoked_reason,omitempty"`
+	// Current marks the session of the caller when listing sessions
+	Current bool `json:"current" bson:"-"`
+}
+
+// Active reports whether the session can still be refreshed
+func (s *Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// SessionClient describes the device a session is started or used from
+type SessionClient struct {
+	Device    string
+	UserAgent string
+	IP        string
+}
+
+// THIS IS THE INTERFACE FOR SESSION DATA OPERATIONS
+type SessionRepository interface {
+	Create(ctx context.Context, session *Session) error
+	Get(ctx context.Context, id primitive.ObjectID) (*Session, error)
+	// Rotate swaps the refresh token of an active session, only if oldHash
+	// is still its current one. It reports whether the swap happened.
+	Rotate(ctx context.Context, id primitive.ObjectID, oldHash, newHash string, client SessionClient, usedAt, expiresAt time.Time) (bool, error)
+	// ListActive returns the user's unrevoked, unexpired sessions, most
+	// recently used first
+	ListActive(ctx context.Context, userID primitive.ObjectID, now time.Time) ([]*Session, error)
+	Revoke(ctx context.Context, id primitive.ObjectID, reason string, at time.Time) error
+	RevokeAllForUser(ctx context.Context, userID primitive.ObjectID, reason string, at time.Time) error
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Algorithms tokens can be signed with
+const (
+	SigningAlgorithmRS256 = "RS256"
+	SigningAlgorithmEdDSA = "EdDSA"
+)
+
+// SigningKey is a key pair tokens are signed with. A key signs from
+// NotBefore until it is retired, and tokens signed with it are accepted
+// until it expires.
+type SigningKey struct {
+	ID        string `json:"kid" bson:"_id"`
+	Algorithm string `json:"alg" bson:"algorithm"`
+	// PrivateKey is PEM encoded PKCS #8, encrypted with the key encryption
+	// key from the environment. PublicKey is PEM encoded PKIX.
+	PrivateKey string     `json:"-" bson:"private_key"`
+	PublicKey  string     `json:"public_key" bson:"public_key"`
+	CreatedAt  time.Time  `json:"created_at" bson:"created_at"`
+	NotBefore  time.Time  `json:"not_before" bson:"not_before"`
+	RetiredAt  *time.Time `json:"retired_at,omitempty" bson:"retired_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" bson:"expires_at,omitempty"`
+	// Bootstrap marks the key created on first start, of which there can
+	// only be one
+	Bootstrap bool `json:"-" bson:"bootstrap,omitempty"`
+}
+
+// CanSign reports whether new tokens may be signed with the key
+func (k *SigningKey) CanSign(now time.Time) bool {
+	return !now.Before(k.NotBefore) && (k.RetiredAt == nil || now.Before(*k.RetiredAt)) && k.CanVerify(now)
+}
+
+// CanVerify reports whether tokens signed with the key are still accepted
+func (k *SigningKey) CanVerify(now time.Time) bool {
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+// THIS IS THE INTERFACE FOR SIGNING KEY DATA OPERATIONS
+type SigningKeyRepository interface {
+	Create(ctx context.Context, key *SigningKey) error
+	// CreateBootstrap stores the first key, unless another instance stored
+	// one first. It reports whether the key was stored.
+	CreateBootstrap(ctx context.Context, key *SigningKey) (bool, error)
+	// List returns every key that has not expired, newest first
+	List(ctx context.Context, now time.Time) ([]*SigningKey, error)
+	// Retire stops the key signing at retireAt and verifying at expiresAt,
+	// unless it was retired already
+	Retire(ctx context.Context, id string, retireAt, expiresAt time.Time) error
+	// ReplacePrivateKey swaps the stored private key, provided it is still
+	// the old one
+	ReplacePrivateKey(ctx context.Context, id, old, replacement string) error
+}
@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// AccessClaims are the claims of a verified access token
+type AccessClaims struct {
+	UserID    string
+	Role      string
+	SessionID string
+	TokenID   string
+	// AuthMethods lists how the session was authenticated, see AuthMethodOTP
+	AuthMethods []string
+	IssuedAt    time.Time
+	ExpiresAt   time.Time
+}
+
+// Revocation keys name what a revocation applies to: one access token, every
+// access token of a session, of a user, or of everyone with a role
+func TokenRevocationKey(jti string) string   { return "token:" + jti }
+func SessionRevocationKey(sid string) string { return "session:" + sid }
+func UserRevocationKey(userID string) string { return "user:" + userID }
+func RoleRevocationKey(role string) string   { return "role:" + role }
+
+// RevocationKeys returns the keys a revocation of the token could be
+// recorded under
+func (c *AccessClaims) RevocationKeys() []string {
+	keys := []string{UserRevocationKey(c.UserID)}
+	if c.Role != "" {
+		keys = append(keys, RoleRevocationKey(c.Role))
+	}
+	if c.TokenID != "" {
+		keys = append(keys, TokenRevocationKey(c.TokenID))
+	}
+	if c.SessionID != "" {
+		keys = append(keys, SessionRevocationKey(c.SessionID))
+	}
+	return keys
+}
+
+// THIS IS THE INTERFACE FOR ACCESS TOKEN REVOCATION DATA OPERATIONS
+type TokenRevocationRepository interface {
+	// Revoke revokes the access tokens under key issued up to at. The entry
+	// is only needed until expiresAt, when those tokens have expired anyway.
+	Revoke(ctx context.Context, key string, at, expiresAt time.Time) error
+	// IsRevoked reports whether a token issued at issuedAt was revoked
+	// under any of keys
+	IsRevoked(ctx context.Context, issuedAt time.Time, keys ...string) (bool, error)
+}
@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Translation origins
+const (
+	TranslationMachine = "machine"
+	TranslationHuman   = "human"
+)
+
+// DefaultBlogLanguage is the language of blogs that do not name one
+const DefaultBlogLanguage = "en"
+
+// BlogTranslation is a blog's title and content in another language
+type BlogTranslation struct {
+	ID          primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	BlogID      primitive.ObjectID `json:"blog_id" bson:"blog_id"`
+	Language    string             `json:"language" bson:"language"`
+	Title       string             `json:"title,omitempty" bson:"title"`
+	Content     string             `json:"content,omitempty" bson:"content"`
+	ContentHTML string             `json:"content_html,omitempty" bson:"content_html,omitempty"`
+	// Origin is machine for AI translations and human once a person has
+	// written or edited the translation
+	Origin   string             `json:"origin" bson:"origin"`
+	Provider string             `json:"provider,omitempty" bson:"provider,omitempty"`
+	EditedBy primitive.ObjectID `json:"edited_by,omitempty" bson:"edited_by,omitempty"`
+	// SourceUpdatedAt is the UpdatedAt of the blog the translation was made from
+	SourceUpdatedAt time.Time `json:"source_updated_at" bson:"source_updated_at"`
+	CreatedAt       time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" bson:"updated_at"`
+	// Stale is set when the blog changed after the translation was made
+	Stale bool `json:"stale" bson:"-"`
+}
+
+var languagePattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+
+// NormalizeLanguage lowercases a language tag such as "pt-BR" and reports
+// whether it is well formed
+func NormalizeLanguage(language string) (string, bool) {
+	language = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(language), "_", "-"))
+	return language, languagePattern.MatchString(language)
+}
+
+// BaseLanguage returns the primary subtag of a language tag, "pt" for "pt-br"
+func BaseLanguage(language string) string {
+	base, _, _ := strings.Cut(language, "-")
+	return base
+}
+
+// THIS IS THE INTERFACE FOR BLOG TRANSLATION DATA OPERATIONS
+type BlogTranslationRepository interface {
+	// Save creates or replaces the translation of its blog into its language
+	Save(translation *BlogTranslation) error
+	Get(blogID primitive.ObjectID, language string) (*BlogTranslation, error)
+	ListByBlog(blogID primitive.ObjectID) ([]*BlogTranslation, error)
+	DeleteByBlog(blogID primitive.ObjectID) error
+}
+
+// CurrentLanguage returns the blog's language, DefaultBlogLanguage for blogs
+// that do not name one
+func (b *Blog) CurrentLanguage() string {
+	if b.Language == "" {
+		return DefaultBlogLanguage
+	}
+	return b.Language
+}
+
+// Translate replaces the blog's title and content with the translation's.
+// The translation is kept on the blog without its text to describe it.
+func (b *Blog) Translate(translation *BlogTranslation) {
+	b.Title = translation.Title
+	b.Content = translation.Content
+	b.ContentHTML = translation.ContentHTML
+
+	info := *translation
+	info.Title, info.Content, info.ContentHTML = "", "", ""
+	info.Stale = b.UpdatedAt.After(translation.SourceUpdatedAt)
+	b.Translation = &info
+}
@@ -0,0 +1,133 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Ways a session was authenticated, recorded in the amr claim (RFC 8176)
+const (
+	AuthMethodPassword = "pwd"
+	AuthMethodGoogle   = "google"
+	AuthMethodOTP      = "otp"
+)
+
+// HasAuthMethod reports whether methods includes method
+func HasAuthMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// TwoFactor is a user's TOTP enrollment. Until it is confirmed with a code
+// from the authenticator app it is pending and login works without it.
+type TwoFactor struct {
+	UserID primitive.ObjectID `json:"-" bson:"_id"`
+	// Secret is the base32 TOTP secret shared with the authenticator app
+	Secret    string     `json:"-" bson:"secret"`
+	Enabled   bool       `json:"enabled" bson:"enabled"`
+	CreatedAt time.Time  `json:"created_at" bson:"created_at"`
+	EnabledAt *time.Time `json:"enabled_at,omitempty" bson:"enabled_at,omitempty"`
+	// RecoveryCodes are bcrypt hashes of the unused recovery codes
+	RecoveryCodes []string `json:"-" bson:"recovery_codes"`
+	// LastUsedStep is the time step of the last accepted code, so a code
+	// cannot be used twice
+	LastUsedStep   int64      `json:"-" bson:"last_used_step"`
+	FailedAttempts int        `json:"-" bson:"failed_attempts"`
+	LockedUntil    *time.Time `json:"-" bson:"locked_until,omitempty"`
+}
+
+// Locked reports whether too many wrong codes were entered recently
+func (t *TwoFactor) Locked(now time.Time) bool {
+	return t.LockedUntil != nil && now.Before(*t.LockedUntil)
+}
+
+// TwoFactorStatus is what a user sees of their own two-factor setup
+type TwoFactorStatus struct {
+	Enabled           bool       `json:"enabled"`
+	EnabledAt         *time.Time `json:"enabled_at,omitempty"`
+	RecoveryCodesLeft int        `json:"recovery_codes_left"`
+	// Required is set when the user's role requires two-factor
+	// authentication
+	Required bool `json:"required"`
+}
+
+// TwoFactorEnrollment is handed to the user to set up an authenticator app
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	// URI is the otpauth:// URI, usually shown as a QR code
+	URI string `json:"otpauth_uri"`
+}
+
+// TwoFactorPolicy lists the roles that need two-factor authentication.
+// Sessions of those roles that were not authenticated with a second factor
+// get the privileges of a reader.
+type TwoFactorPolicy struct {
+	RequiredRoles []string           `json:"required_roles" bson:"required_roles"`
+	UpdatedAt     time.Time          `json:"updated_at" bson:"updated_at"`
+	UpdatedBy     primitive.ObjectID `json:"updated_by,omitempty" bson:"updated_by,omitempty"`
+}
+
+// Requires reports whether the role needs two-factor authentication
+func (p *TwoFactorPolicy) Requires(role string) bool {
+	if p == nil {
+		return false
+	}
+	for _, r := range p.RequiredRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// LoginResult is the outcome of the first login step. Accounts with
+// two-factor authentication get a challenge token instead of tokens, to be
+// exchanged together with a code.
+type LoginResult struct {
+	AccessToken    string `json:"access_token,omitempty"`
+	RefreshToken   string `json:"refresh_token,omitempty"`
+	ChallengeToken string `json:"challenge_token,omitempty"`
+	// TwoFactorRequired is set when a code is needed to finish the login
+	TwoFactorRequired bool `json:"two_factor_required"`
+	// TwoFactorSetupRequired is set when the user's role requires two-factor
+	// authentication they have not set up; the tokens carry the privileges
+	// of a reader until they do
+	TwoFactorSetupRequired bool `json:"two_factor_setup_required,omitempty"`
+}
+
+// THIS IS THE INTERFACE FOR TWO-FACTOR DATA OPERATIONS
+type TwoFactorRepository interface {
+	// Get returns mongo.ErrNoDocuments if the user never enrolled
+	Get(ctx context.Context, userID primitive.ObjectID) (*TwoFactor, error)
+	// StartEnrollment replaces any pending enrollment with a new secret,
+	// unless two-factor authentication is already enabled. It reports
+	// whether the enrollment was stored.
+	StartEnrollment(ctx context.Context, enrollment *TwoFactor) (bool, error)
+	Enable(ctx context.Context, userID primitive.ObjectID, recoveryCodes []string, at time.Time) error
+	Delete(ctx context.Context, userID primitive.ObjectID) error
+	ReplaceRecoveryCodes(ctx context.Context, userID primitive.ObjectID, recoveryCodes []string) error
+	// UseStep records a code's time step as used, only if it is later than
+	// the last one used. It reports whether it was.
+	UseStep(ctx context.Context, userID primitive.ObjectID, step int64) (bool, error)
+	// UseRecoveryCode removes the hash of a recovery code, reporting whether
+	// it was still there
+	UseRecoveryCode(ctx context.Context, userID primitive.ObjectID, hash string) (bool, error)
+	// RecordFailure counts a wrong code and returns the count so far
+	RecordFailure(ctx context.Context, userID primitive.ObjectID) (int, error)
+	// Lock rejects codes until the given time and resets the failure count
+	Lock(ctx context.Context, userID primitive.ObjectID, until time.Time) error
+	ResetFailures(ctx context.Context, userID primitive.ObjectID) error
+}
+
+// THIS IS THE INTERFACE FOR TWO-FACTOR POLICY DATA OPERATIONS
+type TwoFactorPolicyRepository interface {
+	// Get returns an empty policy if none was saved
+	Get(ctx context.Context) (*TwoFactorPolicy, error)
+	Save(ctx context.Context, policy *TwoFactorPolicy) error
+}
@@ -14,7 +14,6 @@ type User struct {
 	Email      string             `bson:"email" json:"email"`
 	Password 	string `json:"password" bson:"password" validate:"required,min=6,max=50"`
 	Role 		string `json:"role" bson:"role"`
-	RefreshToken string `bson:"refresh_token,omitempty"`
 	OTPCode    string             `bson:"otp_code"`
 	ResetOTP     string             `bson:"reset_otp"` 
 	IsVerified bool               `bson:"is_verified"`
@@ -22,15 +21,15 @@ type User struct {
 	ProfilePicture string            `json:"profile_picture,omitempty" bson:"profile_picture,omitempty"`
 	ContactInfo   string             `json:"contact_info,omitempty" bson:"contact_info,omitempty"`
 	GoogleID       string             `json:"google_id,omitempty" bson:"google_id,omitempty"`
+	// Follow counts are computed from the follows collection, never stored
+	FollowersCount int64              `json:"followers_count" bson:"-"`
+	FollowingCount int64              `json:"following_count" bson:"-"`
 
 }
 
 type UserRepository interface {
 	Register(ctx context.Context,user User) (User, error)
 	Login(ctx context.Context,username string) (User, error)
-	SaveRefreshToken(ctx context.Context, userID string, token string) error
-	VerifyRefreshToken(ctx context.Context, userID string, refreshToken string) (bool, error)
-	DeleteRefreshToken(ctx context.Context, userID string) error
 	FindByID(ctx context.Context, userID string) (User, error)
 	FindByEmail(ctx context.Context, email string) (*User, error)
 	VerifyUserOTP(ctx context.Context, email, otp string) error
@@ -0,0 +1,114 @@
+package infrastructure
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+)
+
+// BuildPrompt returns the prompt used to generate a blog post: the one
+// rendered from the caller's template, or the default one
+func BuildPrompt(params domain.GenerationParams) string {
+	if params.Prompt != "" {
+		return params.Prompt
+	}
+
+	var sb strings.Builder
+
+	sb.WriteString("Write a comprehensive blog post with these requirements:\n")
+	sb.WriteString(fmt.Sprintf("- Topic: %s\n", params.Topic))
+
+	if params.Title != "" {
+		sb.WriteString(fmt.Sprintf("- Title: %s\n", params.Title))
+	}
+
+	if len(params.Tags) > 0 {
+		sb.WriteString(fmt.Sprintf("- Tags: %s\n", strings.Join(params.Tags, ", ")))
+	}
+
+	sb.WriteString(fmt.Sprintf("- Tone: %s\n", params.Tone))
+	sb.WriteString(fmt.Sprintf("- Length: %d words\n", params.Length))
+
+	sb.WriteString("\nStructure:\n")
+	sb.WriteString("1. Engaging introduction\n")
+	sb.WriteString("2. 3-5 main sections with subheadings\n")
+	sb.WriteString("3. Conclusion with key takeaways\n")
+
+	return sb.String()
+}
+
+// BuildSummaryPrompt builds the prompt used to summarize a blog post
+func BuildSummaryPrompt(content string) string {
+	return fmt.Sprintf(
+		"Summarize this blog post in 3 bullet points:\n\n%s",
+		content,
+	)
+}
+
+// BuildTagPrompt builds the prompt used to suggest tags for a blog post
+func BuildTagPrompt(content string, existing []string, max int) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Suggest up to %d short, lowercase tags for this blog post.\n", max))
+	if len(existing) > 0 {
+		sb.WriteString("Prefer tags from this list when they fit:\n")
+		sb.WriteString(strings.Join(existing, ", "))
+		sb.WriteString("\n")
+	}
+	sb.WriteString("Answer with one tag per line and nothing else.\n\n")
+	sb.WriteString(content)
+	return sb.String()
+}
+
+// BuildTitlePrompt builds the prompt used to suggest titles for a blog post
+func BuildTitlePrompt(title, content string, count int) string {
+	return fmt.Sprintf(
+		"Suggest %d alternative titles for this blog post, currently titled %q.\n"+
+			"Answer with one title per line and nothing else.\n\n%s",
+		count, title, content,
+	)
+}
+
+// BuildTranslatePrompt builds the prompt used to translate a blog post
+func BuildTranslatePrompt(title, content, language string) string {
+	return fmt.Sprintf(
+		"Translate this blog post into the language with the code %q. Keep the Markdown formatting, code and links unchanged.\n"+
+			"Answer with the translated title as a \"# \" heading followed by the translated post, and nothing else.\n\n# %s\n\n%s",
+		language, title, content,
+	)
+}
+
+// BuildModerationPrompt builds the prompt used to classify user content
+func BuildModerationPrompt(text string) string {
+	return fmt.Sprintf(
+		"You moderate a blog platform. Decide whether the text below must be held for review.\n"+
+			"Flag harassment, hate, violence, sexual content and spam; allow everything else.\n"+
+			"Answer with JSON only, in the form "+
+			`{"verdict": "allow" or "flag", "categories": [...], "reason": "..."}`+
+			", using categories from: %s.\n\nText:\n%s",
+		strings.Join(moderationCategories, ", "), text,
+	)
+}
+
+var listMarkerPattern = regexp.MustCompile(`^(?:[-*•]|\d{1,2}[.)])\s+`)
+
+// parseSuggestions reads one suggestion per line, dropping list markers,
+// numbering and quotes, and keeps at most max distinct entries
+func parseSuggestions(text string, max int) []string {
+	suggestions := []string{}
+	seen := map[string]bool{}
+	for _, line := range strings.Split(text, "\n") {
+		line = listMarkerPattern.ReplaceAllString(strings.TrimSpace(line), "")
+		line = strings.TrimSpace(strings.Trim(line, "\"'`*#"))
+		if line == "" || seen[strings.ToLower(line)] {
+			continue
+		}
+		seen[strings.ToLower(line)] = true
+		suggestions = append(suggestions, line)
+		if len(suggestions) == max {
+			break
+		}
+	}
+	return suggestions
+}
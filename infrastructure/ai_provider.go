@@ -0,0 +1,67 @@
+package infrastructure
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+)
+
+// AIProviderFactory builds an AI provider, reading its settings from the
+// environment
+type AIProviderFactory func() (domain.AIService, error)
+
+var ErrNoAIProvider = errors.New("no AI provider configured")
+
+var aiProviders = map[string]AIProviderFactory{
+	"gemini": NewGeminiAdapter,
+	"openai": NewOpenAIProvider,
+	"fake":   NewFakeAIProvider,
+}
+
+// RegisterAIProvider makes a provider selectable by name. It is meant to be
+// called during startup, before any provider is built.
+func RegisterAIProvider(name string, factory AIProviderFactory) {
+	aiProviders[strings.ToLower(name)] = factory
+}
+
+// AIProviderNames lists the registered provider names
+func AIProviderNames() []string {
+	names := make([]string, 0, len(aiProviders))
+	for name := range aiProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewAIProvider builds the named provider
+func NewAIProvider(name string) (domain.AIService, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || name == "none" {
+		return nil, ErrNoAIProvider
+	}
+	factory, ok := aiProviders[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown AI provider %q (available: %s)", name, strings.Join(AIProviderNames(), ", "))
+	}
+	return factory()
+}
+
+// NewAIProviderFromEnv builds the provider named by AI_PROVIDER. When it is
+// unset, Gemini is used if GEMINI_API_KEY is present. The provider is
+// wrapped in a ResilientAIService configured by ResilienceConfigFromEnv.
+func NewAIProviderFromEnv() (domain.AIService, error) {
+	name, ok := os.LookupEnv("AI_PROVIDER")
+	if !ok && os.Getenv("GEMINI_API_KEY") != "" {
+		name = "gemini"
+	}
+	provider, err := NewAIProvider(name)
+	if err != nil {
+		return nil, err
+	}
+	return NewResilientAIService(provider, ResilienceConfigFromEnv()), nil
+}
@@ -0,0 +1,124 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+)
+
+func TestNewAIProvider(t *testing.T) {
+	tests := []struct {
+		name     string
+		wantName string
+		wantErr  bool
+	}{
+		{"fake", "fake", false},
+		{" FAKE ", "fake", false},
+		{"", "", true},
+		{"none", "", true},
+		{"no-such-provider", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := NewAIProvider(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && provider.Name() != tt.wantName {
+				t.Errorf("provider = %q, want %q", provider.Name(), tt.wantName)
+			}
+		})
+	}
+	if _, err := NewAIProvider("none"); !errors.Is(err, ErrNoAIProvider) {
+		t.Errorf("err = %v, want %v", err, ErrNoAIProvider)
+	}
+}
+
+func TestFakeAIProviderGenerateContent(t *testing.T) {
+	ctx := context.Background()
+	fake := &FakeAIProvider{}
+
+	tests := []struct {
+		name         string
+		params       domain.GenerationParams
+		wantTitle    string
+		wantSections []string
+	}{
+		{"topic only", domain.GenerationParams{Topic: "caching"}, "# Notes on caching", []string{"## Introduction", "## Key ideas", "## Conclusion"}},
+		{"with title", domain.GenerationParams{Topic: "caching", Title: "Cache Rules"}, "# Cache Rules", []string{"## Introduction", "## Conclusion"}},
+		{"with tags", domain.GenerationParams{Topic: "go", Tags: []string{"testing", "tooling"}}, "# Notes on go", []string{"## About testing", "## About tooling"}},
+		{"no topic", domain.GenerationParams{}, "# Notes on this topic", []string{"## Introduction"}},
+		{"with prompt", domain.GenerationParams{Topic: "go", Prompt: "Write about go\nin a casual tone"}, "# Notes on go", []string{"> Write about go", "> in a casual tone", "## Introduction"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, err := fake.GenerateContent(ctx, tt.params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(content, tt.wantTitle+"\n") {
+				t.Errorf("content starts with %q, want %q", strings.SplitN(content, "\n", 2)[0], tt.wantTitle)
+			}
+			for _, section := range tt.wantSections {
+				if !strings.Contains(content, "\n"+section+"\n") {
+					t.Errorf("content has no %q section", section)
+				}
+			}
+			again, _ := fake.GenerateContent(ctx, tt.params)
+			if again != content {
+				t.Error("same request gave different content")
+			}
+
+			var streamed strings.Builder
+			full, err := fake.GenerateContentStream(ctx, tt.params, func(chunk string) error {
+				streamed.WriteString(chunk)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if full != content || streamed.String() != content {
+				t.Error("streamed content differs from generated content")
+			}
+		})
+	}
+}
+
+func TestFakeAIProviderLength(t *testing.T) {
+	for _, length := range []int{50, 400, 1200} {
+		content, _ := (&FakeAIProvider{}).GenerateContent(context.Background(), domain.GenerationParams{Topic: "databases", Length: length})
+		words := 0
+		for _, line := range strings.Split(content, "\n") {
+			if !strings.HasPrefix(line, "#") {
+				words += len(strings.Fields(line))
+			}
+		}
+		if words < length || words > length+40 {
+			t.Errorf("length %d gave %d words", length, words)
+		}
+	}
+}
+
+func TestFakeAIProviderSuggestions(t *testing.T) {
+	ctx := context.Background()
+	fake := &FakeAIProvider{}
+
+	summary, _ := fake.SummarizeBlog(ctx, "# Title\nFirst point. Second point! Third point? Fourth point.")
+	if want := "- First point\n- Second point\n- Third point"; summary != want {
+		t.Errorf("summary = %q, want %q", summary, want)
+	}
+
+	tags, _ := fake.SuggestTags(ctx, "Golang channels and golang goroutines. Channels everywhere.", []string{"Go", "goroutines"}, 3)
+	if want := []string{"goroutines", "channels", "golang"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("tags = %v, want %v", tags, want)
+	}
+
+	titles, _ := fake.SuggestTitles(ctx, "Caching", "", 2)
+	if want := []string{"A Practical Guide to Caching", "Caching, Explained"}; !reflect.DeepEqual(titles, want) {
+		t.Errorf("titles = %v, want %v", titles, want)
+	}
+}
@@ -0,0 +1,53 @@
+package infrastructure
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+)
+
+// DefaultAIQuotas apply to roles without configured quotas
+var DefaultAIQuotas = map[string]domain.AIQuota{
+	domain.RoleAuthor:    {Daily: 20, Monthly: 300},
+	domain.RoleEditor:    {Daily: 20, Monthly: 300},
+	domain.RoleModerator: {Daily: 20, Monthly: 300},
+	domain.RoleUser:      {Daily: 20, Monthly: 300},
+	domain.RoleAdmin:     {},
+}
+
+// AIQuotasFromEnv reads per-role quotas from AI_QUOTA_<ROLE>_DAILY and
+// AI_QUOTA_<ROLE>_MONTHLY, e.g. AI_QUOTA_AUTHOR_DAILY=20. A value of 0 lifts
+// the limit.
+func AIQuotasFromEnv() map[string]domain.AIQuota {
+	quotas := make(map[string]domain.AIQuota, len(DefaultAIQuotas))
+	for role, quota := range DefaultAIQuotas {
+		quotas[role] = quota
+	}
+
+	for _, env := range os.Environ() {
+		key, value, _ := strings.Cut(env, "=")
+		rest, ok := strings.CutPrefix(key, "AI_QUOTA_")
+		if !ok {
+			continue
+		}
+		limit, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil || limit < 0 {
+			continue
+		}
+
+		if role, ok := strings.CutSuffix(rest, "_DAILY"); ok {
+			role = strings.ToLower(role)
+			quota := quotas[role]
+			quota.Daily = limit
+			quotas[role] = quota
+		} else if role, ok := strings.CutSuffix(rest, "_MONTHLY"); ok {
+			role = strings.ToLower(role)
+			quota := quotas[role]
+			quota.Monthly = limit
+			quotas[role] = quota
+		}
+	}
+	return quotas
+}
@@ -0,0 +1,44 @@
+package infrastructure
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+)
+
+func TestAIQuotasFromEnv(t *testing.T) {
+	// Quotas set in the developer's environment would leak into the
+	// cases; empty values are ignored like invalid ones
+	for _, env := range os.Environ() {
+		if key, _, _ := strings.Cut(env, "="); strings.HasPrefix(key, "AI_QUOTA_") {
+			t.Setenv(key, "")
+		}
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		role string
+		want domain.AIQuota
+	}{
+		{"default", nil, domain.RoleAuthor, DefaultAIQuotas[domain.RoleAuthor]},
+		{"author quota", map[string]string{"AI_QUOTA_AUTHOR_DAILY": "5", "AI_QUOTA_AUTHOR_MONTHLY": "50"}, domain.RoleAuthor, domain.AIQuota{Daily: 5, Monthly: 50}},
+		{"only daily set", map[string]string{"AI_QUOTA_EDITOR_DAILY": "7"}, domain.RoleEditor, domain.AIQuota{Daily: 7, Monthly: 300}},
+		{"no limit", map[string]string{"AI_QUOTA_MODERATOR_DAILY": "0"}, domain.RoleModerator, domain.AIQuota{Daily: 0, Monthly: 300}},
+		{"legacy role only", map[string]string{"AI_QUOTA_USER_DAILY": "1"}, domain.RoleAuthor, DefaultAIQuotas[domain.RoleAuthor]},
+		{"invalid value ignored", map[string]string{"AI_QUOTA_AUTHOR_DAILY": "many"}, domain.RoleAuthor, DefaultAIQuotas[domain.RoleAuthor]},
+		{"negative value ignored", map[string]string{"AI_QUOTA_AUTHOR_DAILY": "-1"}, domain.RoleAuthor, DefaultAIQuotas[domain.RoleAuthor]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			if got := AIQuotasFromEnv()[tt.role]; got != tt.want {
+				t.Errorf("quota for %s = %+v, want %+v", tt.role, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+)
+
+const defaultHashDimensions = 384
+
+// HashEmbedder is an offline embedder based on feature hashing: words, word
+// pairs and character trigrams are hashed into a fixed number of
+// dimensions. It relates posts that share vocabulary rather than meaning,
+// but needs no model or network and always gives the same vector for the
+// same text.
+type HashEmbedder struct {
+	dimensions int
+}
+
+func NewHashEmbedder(dimensions int) *HashEmbedder {
+	if dimensions <= 0 {
+		dimensions = defaultHashDimensions
+	}
+	return &HashEmbedder{dimensions: dimensions}
+}
+
+func (h *HashEmbedder) EmbeddingModel() string {
+	return fmt.Sprintf("hash-v1-%d", h.dimensions)
+}
+
+func (h *HashEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	vector := make([]float64, h.dimensions)
+	add := func(feature string, weight float64) {
+		hash := fnv.New64a()
+		hash.Write([]byte(feature))
+		sum := hash.Sum64()
+		// The top bit picks the sign so unrelated features cancel out
+		// instead of piling up in shared buckets
+		if sum>>63 == 1 {
+			weight = -weight
+		}
+		vector[sum%uint64(h.dimensions)] += weight
+	}
+
+	words := embeddingWords(text)
+	for i, word := range words {
+		add("w:"+word, 1)
+		if i > 0 {
+			add("p:"+words[i-1]+" "+word, 0.5)
+		}
+		runes := []rune(" " + word + " ")
+		for j := 0; j+3 <= len(runes); j++ {
+			add("t:"+string(runes[j:j+3]), 0.2)
+		}
+	}
+
+	var norm float64
+	for _, v := range vector {
+		norm += v * v
+	}
+	norm = math.Sqrt(norm)
+	result := make([]float32, h.dimensions)
+	if norm == 0 {
+		return result, nil
+	}
+	for i, v := range vector {
+		result[i] = float32(v / norm)
+	}
+	return result, nil
+}
+
+var embeddingStopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true,
+	"but": true, "by": true, "for": true, "from": true, "has": true, "have": true, "how": true,
+	"i": true, "in": true, "is": true, "it": true, "its": true, "of": true, "on": true,
+	"or": true, "that": true, "the": true, "this": true, "to": true, "was": true, "we": true,
+	"what": true, "when": true, "which": true, "with": true, "you": true, "your": true,
+}
+
+// embeddingWords lowercases text, splits it into words, drops stop words
+// and folds simple plurals
+func embeddingWords(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	words := make([]string, 0, len(fields))
+	for _, word := range fields {
+		if embeddingStopWords[word] {
+			continue
+		}
+		switch {
+		case len(word) > 4 && strings.HasSuffix(word, "ies"):
+			word = word[:len(word)-3] + "y"
+		case len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss"):
+			word = word[:len(word)-1]
+		}
+		words = append(words, word)
+	}
+	return words
+}
+
+// NewEmbedderFromEnv builds the embedder named by EMBEDDING_PROVIDER:
+// "hash", the default, sized by EMBEDDING_DIMENSIONS, or an AI provider
+// that can embed, such as openai or gemini
+func NewEmbedderFromEnv() (domain.Embedder, error) {
+	name := strings.ToLower(strings.TrimSpace(os.Getenv("EMBEDDING_PROVIDER")))
+	if name == "" || name == "hash" {
+		dimensions, _ := strconv.Atoi(os.Getenv("EMBEDDING_DIMENSIONS"))
+		return NewHashEmbedder(dimensions), nil
+	}
+
+	provider, err := NewAIProvider(name)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := provider.(domain.Embedder); !ok {
+		return nil, fmt.Errorf("AI provider %q cannot compute embeddings", name)
+	}
+	return NewResilientAIService(provider, ResilienceConfigFromEnv()), nil
+}
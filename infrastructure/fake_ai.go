@@ -0,0 +1,203 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+)
+
+// FakeAIProvider is an offline provider for tests and local development.
+// Its output depends only on its input, so the same request always gets the
+// same response.
+type FakeAIProvider struct{}
+
+func NewFakeAIProvider() (domain.AIService, error) {
+	return &FakeAIProvider{}, nil
+}
+
+var fakeSentences = []string{
+	"This post looks at %s from a practical point of view.",
+	"Most teams run into %s sooner than they expect.",
+	"The details of %s matter more than the tooling around it.",
+	"A small, well understood change to %s beats a large rewrite.",
+	"It pays to measure %s before trying to improve it.",
+	"Good documentation makes %s easier for everyone involved.",
+}
+
+func (f *FakeAIProvider) Name() string {
+	return "fake"
+}
+
+func (f *FakeAIProvider) GenerateContent(ctx context.Context, params domain.GenerationParams) (string, error) {
+	topic := strings.TrimSpace(params.Topic)
+	if topic == "" {
+		topic = "this topic"
+	}
+	title := params.Title
+	if title == "" {
+		title = "Notes on " + topic
+	}
+	length := params.Length
+	if length <= 0 {
+		length = 800
+	}
+
+	sections := []string{"Introduction"}
+	for _, tag := range params.Tags {
+		sections = append(sections, "About "+tag)
+	}
+	if len(sections) == 1 {
+		sections = append(sections, "Key ideas")
+	}
+	sections = append(sections, "Conclusion")
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s\n", title)
+	// Echo a prompt rendered from a template, so callers can see which
+	// one the post was written from
+	if prompt := strings.TrimSpace(params.Prompt); prompt != "" {
+		sb.WriteString("\n")
+		for _, line := range strings.Split(prompt, "\n") {
+			fmt.Fprintf(&sb, "> %s\n", line)
+		}
+	}
+
+	seed := fakeSeed(topic + "|" + params.Tone)
+	words := 0
+	for i, section := range sections {
+		fmt.Fprintf(&sb, "\n## %s\n\n", section)
+		// spread the requested length evenly over the sections
+		for words < length*(i+1)/len(sections) {
+			sentence := fmt.Sprintf(fakeSentences[(seed+uint32(words))%uint32(len(fakeSentences))], topic)
+			sb.WriteString(sentence + " ")
+			words += len(strings.Fields(sentence))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String(), nil
+}
+
+// GenerateContentStream streams the same content GenerateContent returns,
+// one word at a time
+func (f *FakeAIProvider) GenerateContentStream(ctx context.Context, params domain.GenerationParams, onChunk func(string) error) (string, error) {
+	content, _ := f.GenerateContent(ctx, params)
+
+	var sb strings.Builder
+	for _, chunk := range strings.SplitAfter(content, " ") {
+		if err := ctx.Err(); err != nil {
+			return sb.String(), err
+		}
+		sb.WriteString(chunk)
+		if err := onChunk(chunk); err != nil {
+			return sb.String(), err
+		}
+	}
+	return sb.String(), nil
+}
+
+// SummarizeBlog returns the first three sentences of the content as bullet
+// points, skipping headings
+func (f *FakeAIProvider) SummarizeBlog(ctx context.Context, content string) (string, error) {
+	var bullets []string
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		for _, sentence := range strings.FieldsFunc(line, func(r rune) bool {
+			return r == '.' || r == '!' || r == '?'
+		}) {
+			sentence = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(sentence), "*->"))
+			if sentence == "" {
+				continue
+			}
+			bullets = append(bullets, "- "+sentence)
+			if len(bullets) == 3 {
+				return strings.Join(bullets, "\n"), nil
+			}
+		}
+	}
+	return strings.Join(bullets, "\n"), nil
+}
+
+// Complete answers any prompt with a summary of the prompt itself
+func (f *FakeAIProvider) Complete(ctx context.Context, prompt string) (string, error) {
+	return f.SummarizeBlog(ctx, prompt)
+}
+
+// SuggestTags picks the existing tags mentioned in the content, then the
+// content's most frequent longer words
+func (f *FakeAIProvider) SuggestTags(ctx context.Context, content string, existing []string, max int) ([]string, error) {
+	words := strings.FieldsFunc(strings.ToLower(content), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	counts := map[string]int{}
+	for _, word := range words {
+		counts[word]++
+	}
+
+	var tags []string
+	for _, tag := range existing {
+		if counts[strings.ToLower(tag)] > 0 && len(tags) < max {
+			tags = append(tags, tag)
+		}
+	}
+
+	var candidates []string
+	for word, n := range counts {
+		if len([]rune(word)) >= 5 && n > 1 {
+			candidates = append(candidates, word)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if counts[candidates[i]] != counts[candidates[j]] {
+			return counts[candidates[i]] > counts[candidates[j]]
+		}
+		return candidates[i] < candidates[j]
+	})
+	return parseSuggestions(strings.Join(append(tags, candidates...), "\n"), max), nil
+}
+
+// SuggestTitles returns fixed variations of the current title
+func (f *FakeAIProvider) SuggestTitles(ctx context.Context, title, content string, count int) ([]string, error) {
+	subject := strings.TrimSpace(title)
+	if subject == "" {
+		subject = "This Topic"
+	}
+	variants := []string{
+		"A Practical Guide to " + subject,
+		subject + ", Explained",
+		"What You Should Know About " + subject,
+		"Getting Started with " + subject,
+		subject + ": Lessons Learned",
+	}
+	if count < len(variants) {
+		variants = variants[:count]
+	}
+	return variants, nil
+}
+
+// Translate marks the title with the target language and leaves the
+// content as it is
+func (f *FakeAIProvider) Translate(ctx context.Context, title, content, language string) (string, error) {
+	return fmt.Sprintf("# [%s] %s\n\n%s", language, title, content), nil
+}
+
+func (f *FakeAIProvider) EmbeddingModel() string {
+	return "fake:" + NewHashEmbedder(defaultHashDimensions).EmbeddingModel()
+}
+
+// Embed uses the offline hash embedder
+func (f *FakeAIProvider) Embed(ctx context.Context, text string) ([]float32, error) {
+	return NewHashEmbedder(defaultHashDimensions).Embed(ctx, text)
+}
+
+func fakeSeed(s string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(s))
+	return h.Sum32()
+}
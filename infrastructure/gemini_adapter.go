@@ -2,6 +2,7 @@ package infrastructure
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -9,15 +10,26 @@ import (
 	"github.com/sol-tad/Blog-post-Api/domain"
 
 	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 )
 
+const (
+	geminiModel          = "gemini-1.5-flash"
+	geminiEmbeddingModel = "text-embedding-004"
+)
+
 type GeminiAdapter struct {
-	model *genai.GenerativeModel
+	model    *genai.GenerativeModel
+	embedder *genai.EmbeddingModel
 }
 
 func NewGeminiAdapter() (domain.AIService, error) {
 	apiKey := os.Getenv("GEMINI_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("Gemini client error: GEMINI_API_KEY is not set")
+	}
 	ctx := context.Background()
 
 	client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
@@ -26,61 +38,103 @@ func NewGeminiAdapter() (domain.AIService, error) {
 	}
 
 	return &GeminiAdapter{
-		model: client.GenerativeModel("models/gemini-1.5-flash"),
+		model:    client.GenerativeModel("models/" + geminiModel),
+		embedder: client.EmbeddingModel(geminiEmbeddingModel),
 	}, nil
 }
 
-func (g *GeminiAdapter) GenerateContent(params domain.GenerationParams) (string, error) {
-	ctx := context.Background()
+func (g *GeminiAdapter) Name() string {
+	return "gemini:" + geminiModel
+}
 
-	// Build detailed prompt
-	prompt := buildPrompt(params)
+func (g *GeminiAdapter) GenerateContent(ctx context.Context, params domain.GenerationParams) (string, error) {
+	return g.Complete(ctx, BuildPrompt(params))
+}
 
-	resp, err := g.model.GenerateContent(ctx, genai.Text(prompt))
-	if err != nil {
-		return "", err
-	}
+func (g *GeminiAdapter) GenerateContentStream(ctx context.Context, params domain.GenerationParams, onChunk func(string) error) (string, error) {
+	iter := g.model.GenerateContentStream(ctx, genai.Text(BuildPrompt(params)))
 
-	return extractResponse(resp), nil
+	var sb strings.Builder
+	for {
+		resp, err := iter.Next()
+		if err == iterator.Done {
+			return sb.String(), nil
+		}
+		if err != nil {
+			return sb.String(), geminiError(err)
+		}
+		chunk := extractResponse(resp)
+		if chunk == "" {
+			continue
+		}
+		sb.WriteString(chunk)
+		if err := onChunk(chunk); err != nil {
+			return sb.String(), err
+		}
+	}
 }
 
-func (g *GeminiAdapter) SummarizeBlog(content string) (string, error) {
-	prompt := fmt.Sprintf(
-		"Summarize this blog post in 3 bullet points:\n\n%s",
-		content,
-	)
-	resp, err := g.model.GenerateContent(context.Background(), genai.Text(prompt))
-	return extractResponse(resp), err
+func (g *GeminiAdapter) SummarizeBlog(ctx context.Context, content string) (string, error) {
+	return g.Complete(ctx, BuildSummaryPrompt(content))
 }
 
-// Helper to build sophisticated prompt
-func buildPrompt(params domain.GenerationParams) string {
-	var sb strings.Builder
-
-	sb.WriteString("Write a comprehensive blog post with these requirements:\n")
-	sb.WriteString(fmt.Sprintf("- Topic: %s\n", params.Topic))
+func (g *GeminiAdapter) SuggestTags(ctx context.Context, content string, existing []string, max int) ([]string, error) {
+	text, err := g.Complete(ctx, BuildTagPrompt(content, existing, max))
+	if err != nil {
+		return nil, err
+	}
+	return parseSuggestions(text, max), nil
+}
 
-	if params.Title != "" {
-		sb.WriteString(fmt.Sprintf("- Title: %s\n", params.Title))
+func (g *GeminiAdapter) SuggestTitles(ctx context.Context, title, content string, count int) ([]string, error) {
+	text, err := g.Complete(ctx, BuildTitlePrompt(title, content, count))
+	if err != nil {
+		return nil, err
 	}
+	return parseSuggestions(text, count), nil
+}
+
+func (g *GeminiAdapter) Translate(ctx context.Context, title, content, language string) (string, error) {
+	return g.Complete(ctx, BuildTranslatePrompt(title, content, language))
+}
 
-	if len(params.Tags) > 0 {
-		sb.WriteString(fmt.Sprintf("- Tags: %s\n", strings.Join(params.Tags, ", ")))
+func (g *GeminiAdapter) Complete(ctx context.Context, prompt string) (string, error) {
+	resp, err := g.model.GenerateContent(ctx, genai.Text(prompt))
+	if err != nil {
+		return "", geminiError(err)
 	}
+	return extractResponse(resp), nil
+}
 
-	sb.WriteString(fmt.Sprintf("- Tone: %s\n", params.Tone))
-	sb.WriteString(fmt.Sprintf("- Length: %d words\n", params.Length))
+func (g *GeminiAdapter) EmbeddingModel() string {
+	return "gemini:" + geminiEmbeddingModel
+}
 
-	sb.WriteString("\nStructure:\n")
-	sb.WriteString("1. Engaging introduction\n")
-	sb.WriteString("2. 3-5 main sections with subheadings\n")
-	sb.WriteString("3. Conclusion with key takeaways\n")
+func (g *GeminiAdapter) Embed(ctx context.Context, text string) ([]float32, error) {
+	resp, err := g.embedder.EmbedContent(ctx, genai.Text(text))
+	if err != nil {
+		return nil, geminiError(err)
+	}
+	if resp == nil || resp.Embedding == nil || len(resp.Embedding.Values) == 0 {
+		return nil, errors.New("Gemini error: empty embedding")
+	}
+	return resp.Embedding.Values, nil
+}
 
-	return sb.String()
+// geminiError marks API failures that may succeed when retried
+func geminiError(err error) error {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return httpStatusError(apiErr.Code, apiErr.Header, err)
+	}
+	return err
 }
 
 func extractResponse(resp *genai.GenerateContentResponse) string {
-	if len(resp.Candidates) > 0 && len(resp.Candidates[0].Content.Parts) > 0 {
+	if resp == nil {
+		return ""
+	}
+	if len(resp.Candidates) > 0 && resp.Candidates[0].Content != nil && len(resp.Candidates[0].Content.Parts) > 0 {
 		return fmt.Sprintf("%v", resp.Candidates[0].Content.Parts[0])
 	}
 	return ""
@@ -0,0 +1,375 @@
+package infrastructure
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sol-tad/Blog-post-Api/domain"
+)
+
+const (
+	defaultSigningAlgorithm = domain.SigningAlgorithmEdDSA
+	rsaKeyBits              = 2048
+)
+
+var (
+	ErrUnsupportedAlgorithm = errors.New("signing algorithm must be RS256 or EdDSA")
+	ErrNoSigningKey         = errors.New("no signing key is available")
+	errUnknownKey           = errors.New("token was signed with an unknown key")
+)
+
+// SigningKeys signs and verifies every token. It is set up at start up.
+var SigningKeys *KeySet
+
+// KeySet holds the parsed signing keys. Tokens are signed with the newest
+// key that can sign and carry its ID in the kid header; every key that has
+// not expired verifies, so tokens outlive a rotation.
+type KeySet struct {
+	repo domain.SigningKeyRepository
+	// kek encrypts the private keys in the repository
+	kek []byte
+
+	mu sync.RWMutex
+	// keys is ordered newest first
+	keys []*parsedKey
+}
+
+type parsedKey struct {
+	*domain.SigningKey
+	method  jwt.SigningMethod
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+func NewKeySet(repo domain.SigningKeyRepository, kek []byte) *KeySet {
+	return &KeySet{repo: repo, kek: kek}
+}
+
+// Load reads the keys from the repository, creating a first key with the
+// algorithm in JWT_SIGNING_ALGORITHM if none can sign
+func (s *KeySet) Load(ctx context.Context) error {
+	keys, err := s.repo.List(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if !anyCanSign(keys, time.Now()) {
+		algorithm := os.Getenv("JWT_SIGNING_ALGORITHM")
+		if algorithm == "" {
+			algorithm = defaultSigningAlgorithm
+		}
+		key, err := GenerateSigningKey(algorithm, time.Now(), s.kek)
+		if err != nil {
+			return err
+		}
+		// Another instance may have created the first key meanwhile; then
+		// that one is used
+		created, err := s.repo.CreateBootstrap(ctx, key)
+		if err != nil {
+			return err
+		}
+		if created {
+			log.Println("created signing key", key.ID)
+		}
+		if keys, err = s.repo.List(ctx, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	parsed := make([]*parsedKey, 0, len(keys))
+	for _, key := range keys {
+		if !isSealedKey(key.PrivateKey) {
+			s.sealStoredKey(ctx, key)
+		}
+		p, err := parseSigningKey(key, s.kek)
+		if err != nil {
+			log.Println("skipping signing key", key.ID, err)
+			continue
+		}
+		parsed = append(parsed, p)
+	}
+
+	s.mu.Lock()
+	s.keys = parsed
+	s.mu.Unlock()
+	return nil
+}
+
+// sealStoredKey encrypts a private key stored before keys were encrypted
+func (s *KeySet) sealStoredKey(ctx context.Context, key *domain.SigningKey) {
+	sealed, err := sealPrivateKey(s.kek, key.ID, key.PrivateKey)
+	if err == nil {
+		err = s.repo.ReplacePrivateKey(ctx, key.ID, key.PrivateKey, sealed)
+	}
+	if err != nil {
+		log.Println("encrypting signing key", key.ID, "failed:", err)
+		return
+	}
+	log.Println("encrypted signing key", key.ID)
+	key.PrivateKey = sealed
+}
+
+// Watch reloads the keys every interval so rotations made elsewhere are
+// picked up, until ctx is done
+func (s *KeySet) Watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Load(ctx); err != nil {
+				log.Println("reloading signing keys failed:", err)
+			}
+		}
+	}
+}
+
+func anyCanSign(keys []*domain.SigningKey, now time.Time) bool {
+	for _, key := range keys {
+		if key.CanSign(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// Sign signs the claims with the current signing key
+func (s *KeySet) Sign(claims jwt.MapClaims) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	for _, key := range s.keys {
+		if key.CanSign(now) {
+			token := jwt.NewWithClaims(key.method, claims)
+			token.Header["kid"] = key.ID
+			return token.SignedString(key.private)
+		}
+	}
+	return "", ErrNoSigningKey
+}
+
+// Parse verifies a token of the given type and returns its claims
+func (s *KeySet) Parse(tokenStr, tokenType string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key := s.verifyingKey(kid)
+		if key == nil {
+			return nil, errUnknownKey
+		}
+		if token.Method.Alg() != key.method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return key.public, nil
+	}, jwt.WithValidMethods([]string{domain.SigningAlgorithmRS256, domain.SigningAlgorithmEdDSA}))
+	if err != nil || !token.Valid {
+		return nil, errors.Join(errUnknownKey, err)
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errUnknownKey
+	}
+	// Access and refresh tokens share keys, so one must not pass for the
+	// other
+	if typ, _ := claims["token_type"].(string); typ != tokenType {
+		return nil, fmt.Errorf("expected a %s token", tokenType)
+	}
+	return claims, nil
+}
+
+func (s *KeySet) verifyingKey(kid string) *parsedKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	for _, key := range s.keys {
+		if key.ID == kid && key.CanVerify(now) {
+			return key
+		}
+	}
+	return nil
+}
+
+// JWK is the public half of a signing key as a JSON Web Key (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	// RSA modulus and exponent
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519 curve and public key
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns every key tokens may be verified with, including keys that
+// will only start signing later, so verifiers can fetch them in advance
+func (s *KeySet) JWKS() JWKSet {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	set := JWKSet{Keys: []JWK{}}
+	now := time.Now()
+	for _, key := range s.keys {
+		if !key.CanVerify(now) {
+			continue
+		}
+		jwk := JWK{Use: "sig", Alg: key.Algorithm, Kid: key.ID}
+		switch public := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// GenerateSigningKey creates a key pair that may sign from notBefore, with
+// the private key encrypted with kek
+func GenerateSigningKey(algorithm string, notBefore time.Time, kek []byte) (*domain.SigningKey, error) {
+	var private crypto.Signer
+	var err error
+	switch algorithm {
+	case domain.SigningAlgorithmRS256:
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case domain.SigningAlgorithmEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, ErrUnsupportedAlgorithm
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(private.Public())
+	if err != nil {
+		return nil, err
+	}
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	id := now.UTC().Format("20060102") + "-" + hex.EncodeToString(suffix)
+	sealed, err := sealPrivateKey(kek, id, string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER})))
+	if err != nil {
+		return nil, err
+	}
+	return &domain.SigningKey{
+		ID:         id,
+		Algorithm:  algorithm,
+		PrivateKey: sealed,
+		PublicKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})),
+		CreatedAt:  now,
+		NotBefore:  notBefore,
+	}, nil
+}
+
+// RotateSigningKey creates a key that starts signing after activateIn and
+// retires the current keys at that moment. Retired keys keep verifying for
+// RefreshTokenTTL, the longest any token lives. Waiting before activating
+// gives verifiers time to fetch the new key.
+func RotateSigningKey(ctx context.Context, repo domain.SigningKeyRepository, kek []byte, algorithm string, activateIn time.Duration) (*domain.SigningKey, error) {
+	now := time.Now()
+	key, err := GenerateSigningKey(algorithm, now.Add(activateIn), kek)
+	if err != nil {
+		return nil, err
+	}
+	current, err := repo.List(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := repo.Create(ctx, key); err != nil {
+		return nil, err
+	}
+	for _, old := range current {
+		if old.RetiredAt != nil {
+			continue
+		}
+		if err := repo.Retire(ctx, old.ID, key.NotBefore, key.NotBefore.Add(RefreshTokenTTL)); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+func parseSigningKey(key *domain.SigningKey, kek []byte) (*parsedKey, error) {
+	var method jwt.SigningMethod
+	switch key.Algorithm {
+	case domain.SigningAlgorithmRS256:
+		method = jwt.SigningMethodRS256
+	case domain.SigningAlgorithmEdDSA:
+		method = jwt.SigningMethodEdDSA
+	default:
+		return nil, ErrUnsupportedAlgorithm
+	}
+
+	privatePEM := key.PrivateKey
+	if isSealedKey(privatePEM) {
+		var err error
+		if privatePEM, err = openPrivateKey(kek, key.ID, privatePEM); err != nil {
+			return nil, err
+		}
+	}
+	block, _ := pem.Decode([]byte(privatePEM))
+	if block == nil {
+		return nil, errors.New("private key is not PEM encoded")
+	}
+	private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("private key cannot sign")
+	}
+
+	block, _ = pem.Decode([]byte(key.PublicKey))
+	if block == nil {
+		return nil, errors.New("public key is not PEM encoded")
+	}
+	public, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	return &parsedKey{SigningKey: key, method: method, private: signer, public: public}, nil
+}
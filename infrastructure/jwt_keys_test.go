@@ -0,0 +1,257 @@
+package infrastructure
+
+import (
+	"context"
+	"crypto/rand"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sol-tad/Blog-post-Api/domain"
+)
+
+// memorySigningKeys keeps signing keys in memory and, like the unique index
+// in Mongo, lets only one bootstrap key in
+type memorySigningKeys struct {
+	mu   sync.Mutex
+	keys []*domain.SigningKey
+}
+
+func (r *memorySigningKeys) Create(ctx context.Context, key *domain.SigningKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *key
+	r.keys = append([]*domain.SigningKey{&copied}, r.keys...)
+	return nil
+}
+
+func (r *memorySigningKeys) CreateBootstrap(ctx context.Context, key *domain.SigningKey) (bool, error) {
+	r.mu.Lock()
+	for _, existing := range r.keys {
+		if existing.Bootstrap {
+			r.mu.Unlock()
+			return false, nil
+		}
+	}
+	r.mu.Unlock()
+	key.Bootstrap = true
+	return true, r.Create(ctx, key)
+}
+
+func (r *memorySigningKeys) List(ctx context.Context, now time.Time) ([]*domain.SigningKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	keys := make([]*domain.SigningKey, 0, len(r.keys))
+	for _, key := range r.keys {
+		copied := *key
+		keys = append(keys, &copied)
+	}
+	return keys, nil
+}
+
+func (r *memorySigningKeys) Retire(ctx context.Context, id string, retireAt, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, key := range r.keys {
+		if key.ID == id && key.RetiredAt == nil {
+			key.RetiredAt, key.ExpiresAt = &retireAt, &expiresAt
+		}
+	}
+	return nil
+}
+
+func (r *memorySigningKeys) ReplacePrivateKey(ctx context.Context, id, old, replacement string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, key := range r.keys {
+		if key.ID == id && key.PrivateKey == old {
+			key.PrivateKey = replacement
+		}
+	}
+	return nil
+}
+
+func newKEK(t *testing.T) []byte {
+	t.Helper()
+	kek := make([]byte, 32)
+	if _, err := rand.Read(kek); err != nil {
+		t.Fatal(err)
+	}
+	return kek
+}
+
+func TestSealPrivateKey(t *testing.T) {
+	kek := newKEK(t)
+	sealed, err := sealPrivateKey(kek, "kid-1", "secret pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isSealedKey(sealed) || strings.Contains(sealed, "secret pem") {
+		t.Fatalf("key is not sealed: %q", sealed)
+	}
+
+	tests := []struct {
+		name    string
+		kek     []byte
+		kid     string
+		sealed  string
+		want    string
+		wantErr bool
+	}{
+		{"round trip", kek, "kid-1", sealed, "secret pem", false},
+		{"wrong key encryption key", newKEK(t), "kid-1", sealed, "", true},
+		{"moved to another key id", kek, "kid-2", sealed, "", true},
+		{"tampered", kek, "kid-1", sealed[:len(sealed)-4] + "AAAA", "", true},
+		{"short key encryption key", kek[:16], "kid-1", sealed, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := openPrivateKey(tt.kek, tt.kid, tt.sealed)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyEncryptionKeyFromEnv(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantErr bool
+	}{
+		{"", true},
+		{"not base64!", true},
+		{"c2hvcnQ=", true},
+		{"MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=", false},
+	}
+	for _, tt := range tests {
+		t.Setenv("JWT_KEY_ENCRYPTION_KEY", tt.value)
+		if _, err := KeyEncryptionKeyFromEnv(); (err != nil) != tt.wantErr {
+			t.Errorf("%q: err = %v, wantErr %v", tt.value, err, tt.wantErr)
+		}
+	}
+}
+
+func TestLoadStoresEncryptedKeysAndSigns(t *testing.T) {
+	for _, algorithm := range []string{domain.SigningAlgorithmEdDSA, domain.SigningAlgorithmRS256} {
+		t.Run(algorithm, func(t *testing.T) {
+			t.Setenv("JWT_SIGNING_ALGORITHM", algorithm)
+			repo := &memorySigningKeys{}
+			keys := NewKeySet(repo, newKEK(t))
+			if err := keys.Load(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			stored, _ := repo.List(context.Background(), time.Now())
+			if len(stored) != 1 {
+				t.Fatalf("got %d keys, want 1", len(stored))
+			}
+			if strings.Contains(stored[0].PrivateKey, "PRIVATE KEY") || !isSealedKey(stored[0].PrivateKey) {
+				t.Error("private key stored in plain text")
+			}
+
+			token, err := keys.Sign(jwt.MapClaims{"token_type": "test"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := keys.Parse(token, "test"); err != nil {
+				t.Errorf("token does not verify: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadCreatesOneBootstrapKey(t *testing.T) {
+	repo := &memorySigningKeys{}
+	kek := newKEK(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := NewKeySet(repo, kek).Load(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	stored, _ := repo.List(context.Background(), time.Now())
+	if len(stored) != 1 {
+		t.Errorf("got %d keys, want 1", len(stored))
+	}
+}
+
+func TestLoadEncryptsPlainKeys(t *testing.T) {
+	kek := newKEK(t)
+	key, err := GenerateSigningKey(domain.SigningAlgorithmEdDSA, time.Now(), kek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A key as stored before private keys were encrypted
+	key.PrivateKey, err = openPrivateKey(kek, key.ID, key.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &memorySigningKeys{}
+	repo.Create(context.Background(), key)
+
+	keys := NewKeySet(repo, kek)
+	if err := keys.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, _ := repo.List(context.Background(), time.Now())
+	if len(stored) != 1 || !isSealedKey(stored[0].PrivateKey) {
+		t.Fatal("plain key was not encrypted")
+	}
+	if _, err := keys.Sign(jwt.MapClaims{}); err != nil {
+		t.Errorf("cannot sign with the migrated key: %v", err)
+	}
+}
+
+func TestRotateSigningKey(t *testing.T) {
+	repo := &memorySigningKeys{}
+	kek := newKEK(t)
+	keys := NewKeySet(repo, kek)
+	if err := keys.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	oldToken, err := keys.Sign(jwt.MapClaims{"token_type": "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated, err := RotateSigningKey(context.Background(), repo, kek, domain.SigningAlgorithmRS256, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := keys.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	newToken, err := keys.Sign(jwt.MapClaims{"token_type": "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, jwt.MapClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Header["kid"] != rotated.ID {
+		t.Errorf("signed with %v, want the rotated key %s", parsed.Header["kid"], rotated.ID)
+	}
+	// Tokens signed before the rotation keep verifying until they expire
+	if _, err := keys.Parse(oldToken, "test"); err != nil {
+		t.Errorf("token signed with the retired key does not verify: %v", err)
+	}
+	if got := len(keys.JWKS().Keys); got != 2 {
+		t.Errorf("JWKS has %d keys, want 2", got)
+	}
+}
@@ -1,44 +1,186 @@
 package infrastructure
 
 import (
-	"os"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"math"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/sol-tad/Blog-post-Api/domain"
 )
 
-var JWT_ACCESS_TOKEN_SECRET = os.Getenv("JWT_ACCESS_TOKEN_SECRET")
-var JWT_REFRESH_TOKEN_SECRET = os.Getenv("JWT_REFRESH_TOKEN_SECRET")
+const (
+	// AccessTokenTTL is how long an access token works; revocations of
+	// access tokens only need to be kept this long
+	AccessTokenTTL = 15 * time.Minute
+	// RefreshTokenTTL is how long a session lasts without being refreshed
+	RefreshTokenTTL = 7 * 24 * time.Hour
+	// ChallengeTokenTTL is how long a user has to enter their second factor
+	// after entering their password
+	ChallengeTokenTTL = 5 * time.Minute
+)
+
+// Token types, stored in the token_type claim
+const (
+	tokenTypeAccess    = "access"
+	tokenTypeRefresh   = "refresh"
+	tokenTypeChallenge = "2fa_challenge"
+)
+
+var (
+	ErrInvalidAccessToken    = errors.New("invalid or expired access token")
+	ErrInvalidRefreshToken   = errors.New("invalid or expired refresh token")
+	ErrInvalidChallengeToken = errors.New("invalid or expired two-factor challenge; log in again")
+)
 
-func GenerateAccessToken(userID, role string) (string, error) {
+// GenerateAccessToken signs an access token for the session's user. The jti
+// identifies the token for revocation, and iat is kept to the millisecond
+// so a token issued right after a revocation is not caught by it. amr lists
+// how the session was authenticated.
+func GenerateAccessToken(userID, role, sessionID string, authMethods []string) (string, error) {
+	jti, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
 	claims := jwt.MapClaims{
-		"user_id": userID,
-		"role":    role,
-		"exp":     time.Now().Add(time.Minute * 15).Unix(),
+		"user_id":    userID,
+		"role":       role,
+		"sid":        sessionID,
+		"amr":        authMethods,
+		"jti":        jti,
+		"token_type": tokenTypeAccess,
+		"iat":        float64(now.UnixMilli()) / 1000,
+		"exp":        now.Add(AccessTokenTTL).Unix(),
+	}
+	return signingKeys().Sign(claims)
+}
+
+// VerifyAccessToken checks the signature and expiry of an access token and
+// returns its claims
+func VerifyAccessToken(tokenStr string) (*domain.AccessClaims, error) {
+	mapClaims, err := signingKeys().Parse(tokenStr, tokenTypeAccess)
+	if err != nil {
+		return nil, ErrInvalidAccessToken
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(JWT_ACCESS_TOKEN_SECRET))
+	claims := &domain.AccessClaims{}
+	claims.UserID, _ = mapClaims["user_id"].(string)
+	claims.Role, _ = mapClaims["role"].(string)
+	claims.SessionID, _ = mapClaims["sid"].(string)
+	claims.TokenID, _ = mapClaims["jti"].(string)
+	claims.AuthMethods = stringsClaim(mapClaims["amr"])
+	if claims.UserID == "" {
+		return nil, ErrInvalidAccessToken
+	}
+	// Tokens from before revocation existed have no iat; they count as
+	// issued at the start of their 15 minutes
+	if exp, err := mapClaims.GetExpirationTime(); err == nil && exp != nil {
+		claims.ExpiresAt = exp.Time
+		claims.IssuedAt = exp.Time.Add(-AccessTokenTTL)
+	}
+	// Read iat directly; the jwt package truncates it to whole seconds
+	if iat, ok := mapClaims["iat"].(float64); ok {
+		claims.IssuedAt = time.UnixMilli(int64(math.Round(iat * 1000)))
+	}
+	return claims, nil
 }
 
-func GenerateRefreshToken(userID string) (string, error) {
+// GenerateRefreshToken signs a refresh token for the session. The random
+// jti makes every token unique, even two issued in the same second.
+func GenerateRefreshToken(userID, sessionID string) (string, error) {
+	jti, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
 	claims := jwt.MapClaims{
-		"user_id": userID,
-		"exp":     time.Now().Add(time.Hour * 24 * 7).Unix(),
+		"user_id":    userID,
+		"sid":        sessionID,
+		"jti":        jti,
+		"token_type": tokenTypeRefresh,
+		"exp":        time.Now().Add(RefreshTokenTTL).Unix(),
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(JWT_REFRESH_TOKEN_SECRET)) 
+	return signingKeys().Sign(claims)
 }
 
-func VerifyRefreshToken(tokenStr string) (string, error) {
-	
-	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-		return []byte(JWT_REFRESH_TOKEN_SECRET), nil 
-	})
-	if err != nil || !token.Valid {
+// VerifyRefreshToken checks the signature and expiry of a refresh token and
+// returns the user and session it was issued to
+func VerifyRefreshToken(tokenStr string) (userID, sessionID string, err error) {
+	claims, err := signingKeys().Parse(tokenStr, tokenTypeRefresh)
+	if err != nil {
+		return "", "", ErrInvalidRefreshToken
+	}
+	userID, _ = claims["user_id"].(string)
+	sessionID, _ = claims["sid"].(string)
+	if userID == "" || sessionID == "" {
+		return "", "", ErrInvalidRefreshToken
+	}
+	return userID, sessionID, nil
+}
+
+// GenerateChallengeToken signs the token that carries a login from the
+// password step to the second factor step. amr lists the factors the user
+// passed so far.
+func GenerateChallengeToken(userID string, authMethods []string) (string, error) {
+	jti, err := newTokenID()
+	if err != nil {
 		return "", err
 	}
-	
-	claims := token.Claims.(jwt.MapClaims)
-	return claims["user_id"].(string), nil
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"user_id":    userID,
+		"amr":        authMethods,
+		"jti":        jti,
+		"token_type": tokenTypeChallenge,
+		"iat":        now.Unix(),
+		"exp":        now.Add(ChallengeTokenTTL).Unix(),
+	}
+	return signingKeys().Sign(claims)
+}
+
+// VerifyChallengeToken checks a challenge token and returns the user, the
+// factors passed so far and the token's ID and expiry
+func VerifyChallengeToken(tokenStr string) (userID string, authMethods []string, tokenID string, expiresAt time.Time, err error) {
+	claims, err := signingKeys().Parse(tokenStr, tokenTypeChallenge)
+	if err != nil {
+		return "", nil, "", time.Time{}, ErrInvalidChallengeToken
+	}
+	userID, _ = claims["user_id"].(string)
+	tokenID, _ = claims["jti"].(string)
+	exp, err := claims.GetExpirationTime()
+	if userID == "" || tokenID == "" || err != nil || exp == nil {
+		return "", nil, "", time.Time{}, ErrInvalidChallengeToken
+	}
+	return userID, stringsClaim(claims["amr"]), tokenID, exp.Time, nil
+}
+
+// stringsClaim reads a claim holding a list of strings
+func stringsClaim(claim interface{}) []string {
+	values, _ := claim.([]interface{})
+	strs := make([]string, 0, len(values))
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			strs = append(strs, s)
+		}
+	}
+	return strs
+}
+
+func newTokenID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// signingKeys returns SigningKeys, or an empty key set that signs and
+// verifies nothing if it was never set up
+func signingKeys() *KeySet {
+	if SigningKeys == nil {
+		return &KeySet{}
+	}
+	return SigningKeys
 }
@@ -0,0 +1,79 @@
+package infrastructure
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"os"
+	"strings"
+)
+
+// sealedKeyPrefix marks private keys encrypted with the key encryption key;
+// keys stored before encryption existed are plain PEM
+const sealedKeyPrefix = "aes256gcm:"
+
+var (
+	ErrNoKeyEncryptionKey = errors.New("JWT_KEY_ENCRYPTION_KEY must hold 32 base64 encoded bytes, e.g. from openssl rand -base64 32")
+	errSealedKey          = errors.New("private key cannot be decrypted with the key encryption key")
+)
+
+// KeyEncryptionKeyFromEnv reads the key that signing keys are encrypted
+// with in the database. It stays in the environment, so reading the
+// database alone is not enough to forge tokens.
+func KeyEncryptionKeyFromEnv() ([]byte, error) {
+	kek, err := base64.StdEncoding.DecodeString(strings.TrimSpace(os.Getenv("JWT_KEY_ENCRYPTION_KEY")))
+	if err != nil || len(kek) != 32 {
+		return nil, ErrNoKeyEncryptionKey
+	}
+	return kek, nil
+}
+
+// sealPrivateKey encrypts a PEM private key with AES-256-GCM. The key ID is
+// authenticated along with it, so a sealed key cannot be swapped onto
+// another key's record.
+func sealPrivateKey(kek []byte, kid, privatePEM string) (string, error) {
+	aead, err := newKeyAEAD(kek)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(privatePEM), []byte(kid))
+	return sealedKeyPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// openPrivateKey decrypts a private key sealed by sealPrivateKey
+func openPrivateKey(kek []byte, kid, sealed string) (string, error) {
+	aead, err := newKeyAEAD(kek)
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(sealed, sealedKeyPrefix))
+	if err != nil || len(data) < aead.NonceSize() {
+		return "", errSealedKey
+	}
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], []byte(kid))
+	if err != nil {
+		return "", errSealedKey
+	}
+	return string(plain), nil
+}
+
+func isSealedKey(privateKey string) bool {
+	return strings.HasPrefix(privateKey, sealedKeyPrefix)
+}
+
+func newKeyAEAD(kek []byte) (cipher.AEAD, error) {
+	if len(kek) != 32 {
+		return nil, ErrNoKeyEncryptionKey
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,90 @@
+package infrastructure
+
+import (
+	"strings"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+)
+
+// maxDiffCells caps the size of the LCS table. Past it the changed lines are
+// shown as removed and re-added instead of being matched up, which keeps a
+// diff of two very long, very different revisions cheap.
+const maxDiffCells = 1_000_000
+
+// DiffLines returns a line-level diff that turns oldText into newText,
+// based on the longest common subsequence of their lines
+func DiffLines(oldText, newText string) []domain.DiffLine {
+	a := strings.Split(oldText, "\n")
+	b := strings.Split(newText, "\n")
+	diff := make([]domain.DiffLine, 0, len(a)+len(b))
+
+	// Lines shared at the start and end are equal in any LCS; only the
+	// changed middle needs the table
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		diff = append(diff, domain.DiffLine{Op: domain.DiffEqual, Text: a[prefix]})
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	diff = diffMiddle(diff, a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+	for _, line := range a[len(a)-suffix:] {
+		diff = append(diff, domain.DiffLine{Op: domain.DiffEqual, Text: line})
+	}
+	return diff
+}
+
+// diffMiddle appends the diff of a and b to diff
+func diffMiddle(diff []domain.DiffLine, a, b []string) []domain.DiffLine {
+	if (len(a)+1)*(len(b)+1) > maxDiffCells {
+		for _, line := range a {
+			diff = append(diff, domain.DiffLine{Op: domain.DiffDelete, Text: line})
+		}
+		for _, line := range b {
+			diff = append(diff, domain.DiffLine{Op: domain.DiffInsert, Text: line})
+		}
+		return diff
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			diff = append(diff, domain.DiffLine{Op: domain.DiffEqual, Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, domain.DiffLine{Op: domain.DiffDelete, Text: a[i]})
+			i++
+		default:
+			diff = append(diff, domain.DiffLine{Op: domain.DiffInsert, Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		diff = append(diff, domain.DiffLine{Op: domain.DiffDelete, Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		diff = append(diff, domain.DiffLine{Op: domain.DiffInsert, Text: b[j]})
+	}
+	return diff
+}
@@ -0,0 +1,70 @@
+package infrastructure
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+)
+
+func equal(text string) domain.DiffLine  { return domain.DiffLine{Op: domain.DiffEqual, Text: text} }
+func insert(text string) domain.DiffLine { return domain.DiffLine{Op: domain.DiffInsert, Text: text} }
+func remove(text string) domain.DiffLine { return domain.DiffLine{Op: domain.DiffDelete, Text: text} }
+
+// sides rebuilds the old and new text from a diff
+func sides(diff []domain.DiffLine) (string, string) {
+	var a, b []string
+	for _, line := range diff {
+		if line.Op != domain.DiffInsert {
+			a = append(a, line.Text)
+		}
+		if line.Op != domain.DiffDelete {
+			b = append(b, line.Text)
+		}
+	}
+	return strings.Join(a, "\n"), strings.Join(b, "\n")
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     []domain.DiffLine
+	}{
+		{"unchanged", "a\nb", "a\nb", []domain.DiffLine{equal("a"), equal("b")}},
+		{"line added", "a\nc", "a\nb\nc", []domain.DiffLine{equal("a"), insert("b"), equal("c")}},
+		{"line removed", "a\nb\nc", "a\nc", []domain.DiffLine{equal("a"), remove("b"), equal("c")}},
+		{"line changed", "a\nb\nc", "a\nx\nc", []domain.DiffLine{equal("a"), remove("b"), insert("x"), equal("c")}},
+		{"from empty", "", "a", []domain.DiffLine{remove(""), insert("a")}},
+		{"repeated lines", "x\na\nx", "x\nx", []domain.DiffLine{equal("x"), remove("a"), equal("x")}},
+		{"moved line", "a\nb\nc", "b\nc\na", []domain.DiffLine{remove("a"), equal("b"), equal("c"), insert("a")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DiffLines(tt.old, tt.new)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffLines(%q, %q) = %v, want %v", tt.old, tt.new, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffLinesLargeInputs(t *testing.T) {
+	var a, b []string
+	for i := 0; i < 5000; i++ {
+		a = append(a, fmt.Sprint("old ", i))
+		b = append(b, fmt.Sprint("new ", i))
+	}
+	oldText := "title\n" + strings.Join(a, "\n") + "\nend"
+	newText := "title\n" + strings.Join(b, "\n") + "\nend"
+
+	diff := DiffLines(oldText, newText)
+	if gotOld, gotNew := sides(diff); gotOld != oldText || gotNew != newText {
+		t.Fatal("diff does not rebuild both texts")
+	}
+	if first, last := diff[0], diff[len(diff)-1]; first != equal("title") || last != equal("end") {
+		t.Errorf("shared lines not kept: first %v, last %v", first, last)
+	}
+}
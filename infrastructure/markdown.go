@@ -0,0 +1,368 @@
+package infrastructure
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// MarkdownRendererVersion changes whenever the rendered output changes, so
+// cached HTML produced by an older renderer gets rebuilt
+const MarkdownRendererVersion = 2
+
+// The renderer never passes input HTML through. Every piece of text is
+// escaped and the only tags it emits are:
+//
+//	h1-h6 p br hr strong em code pre blockquote ul ol li a img
+//
+// Links and images are limited to http, https, mailto and relative URLs.
+
+var (
+	headingPattern   = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	hrPattern        = regexp.MustCompile(`^ {0,3}(?:(?:- *){3,}|(?:\* *){3,}|(?:_ *){3,})$`)
+	unorderedPattern = regexp.MustCompile(`^\s{0,3}[-*+]\s+(.*)$`)
+	orderedPattern   = regexp.MustCompile(`^\s{0,3}\d{1,9}[.)]\s+(.*)$`)
+	fencePattern     = regexp.MustCompile("^\\s{0,3}(```+|~~~+)\\s*([A-Za-z0-9_+-]*)")
+)
+
+type markdownRenderer struct {
+	sb      strings.Builder
+	anchors map[string]int
+}
+
+// RenderMarkdown converts Markdown into sanitized HTML
+func RenderMarkdown(src string) string {
+	r := &markdownRenderer{anchors: map[string]int{}}
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	r.renderBlocks(strings.Split(src, "\n"))
+	return r.sb.String()
+}
+
+func (r *markdownRenderer) renderBlocks(lines []string) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			i++
+
+		case fencePattern.MatchString(line):
+			i = r.renderFence(lines, i)
+
+		case headingPattern.MatchString(trimmed):
+			m := headingPattern.FindStringSubmatch(trimmed)
+			level := len(m[1])
+			anchor := r.anchor(m[2])
+			fmt.Fprintf(&r.sb, "<h%d id=\"%s\">%s</h%d>\n", level, anchor, renderInline(m[2]), level)
+			i++
+
+		case hrPattern.MatchString(line):
+			r.sb.WriteString("<hr>\n")
+			i++
+
+		case strings.HasPrefix(trimmed, ">"):
+			var quoted []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				q := strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")
+				quoted = append(quoted, strings.TrimPrefix(q, " "))
+			}
+			r.sb.WriteString("<blockquote>\n")
+			r.renderBlocks(quoted)
+			r.sb.WriteString("</blockquote>\n")
+
+		case unorderedPattern.MatchString(line):
+			i = r.renderList(lines, i, unorderedPattern, "ul")
+
+		case orderedPattern.MatchString(line):
+			i = r.renderList(lines, i, orderedPattern, "ol")
+
+		default:
+			var para []string
+			for ; i < len(lines) && !startsBlock(lines[i]); i++ {
+				para = append(para, lines[i])
+			}
+			r.sb.WriteString("<p>")
+			r.sb.WriteString(renderParagraph(para))
+			r.sb.WriteString("</p>\n")
+		}
+	}
+}
+
+func (r *markdownRenderer) renderFence(lines []string, i int) int {
+	m := fencePattern.FindStringSubmatch(lines[i])
+	fence, lang := m[1], m[2]
+
+	var code []string
+	for i++; i < len(lines); i++ {
+		if strings.HasPrefix(strings.TrimSpace(lines[i]), fence) {
+			i++
+			break
+		}
+		code = append(code, lines[i])
+	}
+
+	if lang != "" {
+		fmt.Fprintf(&r.sb, "<pre><code class=\"language-%s\">", html.EscapeString(lang))
+	} else {
+		r.sb.WriteString("<pre><code>")
+	}
+	r.sb.WriteString(html.EscapeString(strings.Join(code, "\n")))
+	r.sb.WriteString("</code></pre>\n")
+	return i
+}
+
+func (r *markdownRenderer) renderList(lines []string, i int, item *regexp.Regexp, tag string) int {
+	r.sb.WriteString("<" + tag + ">\n")
+	for i < len(lines) {
+		m := item.FindStringSubmatch(lines[i])
+		if m == nil {
+			break
+		}
+		text := []string{m[1]}
+		// indented lines continue the current item
+		for i++; i < len(lines); i++ {
+			next := lines[i]
+			if strings.TrimSpace(next) == "" || item.MatchString(next) || !strings.HasPrefix(next, " ") {
+				break
+			}
+			text = append(text, strings.TrimSpace(next))
+		}
+		r.sb.WriteString("<li>")
+		r.sb.WriteString(renderParagraph(text))
+		r.sb.WriteString("</li>\n")
+	}
+	r.sb.WriteString("</" + tag + ">\n")
+	return i
+}
+
+// anchor returns a unique heading id derived from the heading text
+func (r *markdownRenderer) anchor(text string) string {
+	base := Slugify(text)
+	r.anchors[base]++
+	if n := r.anchors[base]; n > 1 {
+		return fmt.Sprintf("%s-%d", base, n-1)
+	}
+	return base
+}
+
+func startsBlock(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed == "" ||
+		strings.HasPrefix(trimmed, ">") ||
+		headingPattern.MatchString(trimmed) ||
+		hrPattern.MatchString(line) ||
+		fencePattern.MatchString(line) ||
+		unorderedPattern.MatchString(line) ||
+		orderedPattern.MatchString(line)
+}
+
+// renderParagraph joins paragraph lines, turning a trailing double space
+// into a line break
+func renderParagraph(lines []string) string {
+	var sb strings.Builder
+	for i, line := range lines {
+		hardBreak := strings.HasSuffix(line, "  ")
+		sb.WriteString(renderInline(strings.TrimSpace(line)))
+		if i < len(lines)-1 {
+			if hardBreak {
+				sb.WriteString("<br>")
+			}
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+// renderInline handles code spans, emphasis, links, images and autolinks,
+// escaping everything else
+func renderInline(text string) string {
+	var sb strings.Builder
+	// missing remembers, per closing delimiter, a position after which it
+	// does not occur. Later openers start further right, so they skip the
+	// search instead of rescanning the rest of the text each time.
+	missing := map[string]int{}
+	closer := func(from int, delim string) int {
+		if p, ok := missing[delim]; ok && from >= p {
+			return -1
+		}
+		end := strings.Index(text[from:], delim)
+		if end < 0 {
+			missing[delim] = from
+		}
+		return end
+	}
+	for i := 0; i < len(text); {
+		c := text[i]
+		rest := text[i:]
+
+		switch {
+		case c == '\\' && i+1 < len(text) && strings.IndexByte("\\`*_{}[]()#+-.!>|~<", text[i+1]) >= 0:
+			sb.WriteString(html.EscapeString(text[i+1 : i+2]))
+			i += 2
+			continue
+
+		case c == '`':
+			ticks := len(rest) - len(strings.TrimLeft(rest, "`"))
+			delim := rest[:ticks]
+			if end := closer(i+ticks, delim); end >= 0 {
+				code := strings.TrimSpace(rest[ticks : ticks+end])
+				sb.WriteString("<code>" + html.EscapeString(code) + "</code>")
+				i += ticks + end + ticks
+				continue
+			}
+			sb.WriteString(delim)
+			i += ticks
+			continue
+
+		case c == '!' && strings.HasPrefix(rest, "!["):
+			if label, dest, n, ok := parseLink(rest[1:]); ok {
+				if safe, ok := safeURL(dest, false); ok {
+					fmt.Fprintf(&sb, "<img src=\"%s\" alt=\"%s\">", html.EscapeString(safe), html.EscapeString(label))
+				} else {
+					sb.WriteString(html.EscapeString(label))
+				}
+				i += 1 + n
+				continue
+			}
+
+		case c == '[':
+			if label, dest, n, ok := parseLink(rest); ok {
+				if safe, ok := safeURL(dest, true); ok {
+					fmt.Fprintf(&sb, "<a href=\"%s\" rel=\"nofollow noopener noreferrer\">%s</a>", html.EscapeString(safe), renderInline(label))
+				} else {
+					sb.WriteString(renderInline(label))
+				}
+				i += n
+				continue
+			}
+
+		case c == '<':
+			if end := closer(i, ">"); end > 0 {
+				dest := rest[1:end]
+				if strings.HasPrefix(dest, "http://") || strings.HasPrefix(dest, "https://") {
+					if safe, ok := safeURL(dest, true); ok {
+						fmt.Fprintf(&sb, "<a href=\"%s\" rel=\"nofollow noopener noreferrer\">%s</a>", html.EscapeString(safe), html.EscapeString(dest))
+						i += end + 1
+						continue
+					}
+				}
+			}
+
+		case c == '*' || c == '_':
+			if c == '_' && i > 0 && isWordByte(text[i-1]) {
+				break
+			}
+			delim := string(c)
+			tag := "em"
+			if strings.HasPrefix(rest, delim+delim) {
+				delim += delim
+				tag = "strong"
+			}
+			inner := rest[len(delim):]
+			if inner == "" || unicode.IsSpace(rune(inner[0])) {
+				break
+			}
+			if end := closer(i+len(delim), delim); end > 0 {
+				sb.WriteString("<" + tag + ">" + renderInline(inner[:end]) + "</" + tag + ">")
+				i += len(delim)*2 + end
+				continue
+			}
+		}
+
+		sb.WriteString(html.EscapeString(text[i : i+1]))
+		i++
+	}
+	return sb.String()
+}
+
+// Link labels and destinations are only looked for this far, so a run of
+// unclosed brackets costs a bounded scan each rather than one to the end of
+// the text. Labels have the same limit as in CommonMark.
+const (
+	maxLinkLabel       = 999
+	maxLinkDestination = 2048
+)
+
+// parseLink parses "[label](destination)" at the start of s and returns the
+// number of bytes consumed
+func parseLink(s string) (label, dest string, n int, ok bool) {
+	depth := 0
+	closeLabel := -1
+	for i := 0; i < len(s) && i <= maxLinkLabel+1; i++ {
+		switch s[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		}
+		if depth == 0 {
+			closeLabel = i
+			break
+		}
+	}
+	if closeLabel < 0 || closeLabel+1 >= len(s) || s[closeLabel+1] != '(' {
+		return "", "", 0, false
+	}
+
+	// the destination may itself contain balanced parentheses
+	end, parens := -1, 0
+	for i, ch := range s[closeLabel+2:] {
+		if i > maxLinkDestination {
+			break
+		}
+		if ch == '(' {
+			parens++
+		} else if ch == ')' {
+			if parens == 0 {
+				end = i
+				break
+			}
+			parens--
+		}
+	}
+	if end < 0 {
+		return "", "", 0, false
+	}
+	dest = strings.TrimSpace(s[closeLabel+2 : closeLabel+2+end])
+	// drop an optional link title
+	if sp := strings.IndexAny(dest, " \t"); sp >= 0 {
+		dest = dest[:sp]
+	}
+	return s[1:closeLabel], dest, closeLabel + 3 + end, true
+}
+
+// safeURL accepts relative URLs and http(s) URLs, plus mailto for links
+func safeURL(raw string, allowMailto bool) (string, bool) {
+	raw = strings.Trim(raw, "<>")
+	if raw == "" {
+		return "", false
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", false
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+	case "mailto":
+		if !allowMailto {
+			return "", false
+		}
+	case "":
+		// a relative URL must not smuggle a scheme in through its path
+		if strings.Contains(strings.SplitN(raw, "/", 2)[0], ":") {
+			return "", false
+		}
+	default:
+		return "", false
+	}
+	return u.String(), true
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,126 @@
+package infrastructure
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	tagPattern    = regexp.MustCompile(`</?([A-Za-z][A-Za-z0-9]*)`)
+	attrPattern   = regexp.MustCompile(`\s(href|src)="([^"]*)"`)
+	onAttrPattern = regexp.MustCompile(`<[^>]*\son[a-z]+=`)
+	schemePattern = regexp.MustCompile(`^[a-z][a-z0-9+.\-]*:`)
+	allowedTags   = map[string]bool{
+		"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+		"p": true, "br": true, "hr": true, "strong": true, "em": true, "code": true,
+		"pre": true, "blockquote": true, "ul": true, "ol": true, "li": true, "a": true, "img": true,
+	}
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"paragraph", "hello world", "<p>hello world</p>\n"},
+		{"emphasis", "*a* **b** _c_", "<p><em>a</em> <strong>b</strong> <em>c</em></p>\n"},
+		{"intraword underscore", "snake_case_name", "<p>snake_case_name</p>\n"},
+		{"code span", "use `<b>` here", "<p>use <code>&lt;b&gt;</code> here</p>\n"},
+		{"link", "[site](https://example.com)", `<p><a href="https://example.com" rel="nofollow noopener noreferrer">site</a></p>` + "\n"},
+		{"image", "![cat](/cat.png)", `<p><img src="/cat.png" alt="cat"></p>` + "\n"},
+		{"autolink", "<https://example.com>", `<p><a href="https://example.com" rel="nofollow noopener noreferrer">https://example.com</a></p>` + "\n"},
+		{"unclosed delimiters", "a * b ` c < d", "<p>a * b ` c &lt; d</p>\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RenderMarkdown(tt.src); got != tt.want {
+				t.Errorf("RenderMarkdown(%q) = %q, want %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderMarkdownEscapesHTML(t *testing.T) {
+	tests := []string{
+		"<script>alert(1)</script>",
+		"<img src=x onerror=alert(1)>",
+		"[x](javascript:alert(1))",
+		"[x](JaVaScRiPt:alert(1))",
+		"[x](data:text/html;base64,PHNjcmlwdD4=)",
+		"![x](javascript:alert(1))",
+		"![x](mailto:a@example.com)",
+		`[x](https://example.com" onmouseover="alert(1))`,
+		"<javascript:alert(1)>",
+		"[x](vbscript:msgbox(1))",
+		"```\n<script>alert(1)</script>\n```",
+		"# <svg onload=alert(1)>",
+		"> <iframe src=//evil.example>",
+		"- <a href=javascript:alert(1)>x</a>",
+		"[<script>](https://example.com)",
+	}
+	for _, src := range tests {
+		t.Run(src, func(t *testing.T) {
+			got := RenderMarkdown(src)
+			for _, tag := range tagPattern.FindAllStringSubmatch(got, -1) {
+				if !allowedTags[strings.ToLower(tag[1])] {
+					t.Errorf("RenderMarkdown(%q) = %q emits <%s>", src, got, tag[1])
+				}
+			}
+			for _, attr := range attrPattern.FindAllStringSubmatch(got, -1) {
+				if scheme := schemePattern.FindString(strings.ToLower(attr[2])); scheme != "" && scheme != "http:" && scheme != "https:" && scheme != "mailto:" {
+					t.Errorf("RenderMarkdown(%q) = %q has %s=%q", src, got, attr[1], attr[2])
+				}
+			}
+			if onAttrPattern.MatchString(got) {
+				t.Errorf("RenderMarkdown(%q) = %q has an event handler", src, got)
+			}
+		})
+	}
+}
+
+func TestSafeURL(t *testing.T) {
+	tests := []struct {
+		raw         string
+		allowMailto bool
+		ok          bool
+	}{
+		{"https://example.com/a?b=c", false, true},
+		{"http://example.com", false, true},
+		{"/relative/path", false, true},
+		{"relative/path", false, true},
+		{"#section", false, true},
+		{"<https://example.com>", false, true},
+		{"mailto:a@example.com", true, true},
+		{"mailto:a@example.com", false, false},
+		{"javascript:alert(1)", true, false},
+		{"JAVASCRIPT:alert(1)", true, false},
+		{"data:text/html,hi", true, false},
+		{"vbscript:msgbox", true, false},
+		{"file:///etc/passwd", true, false},
+		{"java\tscript:alert(1)", true, false},
+		{"", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			if _, ok := safeURL(tt.raw, tt.allowMailto); ok != tt.ok {
+				t.Errorf("safeURL(%q, %v) ok = %v, want %v", tt.raw, tt.allowMailto, ok, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRenderInlineUnclosedDelimitersStayLinear(t *testing.T) {
+	for _, unit := range []string{"<", "[", "[a](", "![a]("} {
+		t.Run(unit, func(t *testing.T) {
+			src := strings.Repeat(unit, 500_000/len(unit))
+			start := time.Now()
+			RenderMarkdown(src)
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("rendering %d bytes of %q took %v", len(src), unit, elapsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package infrastructure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+)
+
+var moderationCategories = []string{
+	domain.ModerationCategoryHarassment,
+	domain.ModerationCategoryHate,
+	domain.ModerationCategoryViolence,
+	domain.ModerationCategorySexual,
+	domain.ModerationCategorySpam,
+}
+
+// ModerationRule flags text matching Pattern under Category
+type ModerationRule struct {
+	Category string
+	Pattern  *regexp.Regexp
+}
+
+// DefaultModerationRules catch the most common kinds of abuse. They are
+// deliberately narrow: anything they flag is held for a human, not rejected.
+var DefaultModerationRules = []ModerationRule{
+	{domain.ModerationCategoryHarassment, regexp.MustCompile(`(?i)\b(?:kill yourself|kys|nobody (?:likes|wants) you)\b`)},
+	{domain.ModerationCategoryHarassment, regexp.MustCompile(`(?i)\byou(?:'re| are|r)? (?:an? )?(?:stupid|idiot|moron|loser|worthless|pathetic|dumbass)\b`)},
+	{domain.ModerationCategoryHate, regexp.MustCompile(`(?i)\b(?:subhumans?|go back to your (?:own )?country)\b`)},
+	{domain.ModerationCategoryHate, regexp.MustCompile(`(?i)\b(?:all|those) \w+s (?:should|must|deserve to) (?:die|be (?:killed|exterminated|wiped out))\b`)},
+	{domain.ModerationCategoryViolence, regexp.MustCompile(`(?i)\b(?:i(?:'ll| will| am going to|'m going to|'m gonna) (?:kill|hurt|murder|shoot|stab|find) you)\b`)},
+	{domain.ModerationCategorySexual, regexp.MustCompile(`(?i)\b(?:porn|xxx|nudes|onlyfans|camgirls?)\b`)},
+	{domain.ModerationCategorySpam, regexp.MustCompile(`(?i)\b(?:buy now|click here|free money|casino bonus|viagra|crypto giveaway|earn \$\d+ (?:a|per) day)\b`)},
+}
+
+var linkPattern = regexp.MustCompile(`(?i)https?://`)
+
+// KeywordModerator flags content with regular expression rules and flags
+// link-heavy content as spam. It never fails and needs no network.
+type KeywordModerator struct {
+	Rules []ModerationRule
+	// MaxLinks is the number of links allowed before content counts as
+	// spam; 0 disables the check
+	MaxLinks int
+}
+
+func NewKeywordModerator() *KeywordModerator {
+	return &KeywordModerator{Rules: DefaultModerationRules, MaxLinks: 5}
+}
+
+func (k *KeywordModerator) Moderate(text string) (*domain.ModerationVerdict, error) {
+	var categories, reasons []string
+	flag := func(category, reason string) {
+		reasons = append(reasons, reason)
+		for _, c := range categories {
+			if c == category {
+				return
+			}
+		}
+		categories = append(categories, category)
+	}
+
+	for _, rule := range k.Rules {
+		if match := rule.Pattern.FindString(text); match != "" {
+			flag(rule.Category, fmt.Sprintf("matched %q", match))
+		}
+	}
+	if links := len(linkPattern.FindAllStringIndex(text, -1)); k.MaxLinks > 0 && links > k.MaxLinks {
+		flag(domain.ModerationCategorySpam, fmt.Sprintf("%d links", links))
+	}
+
+	verdict := &domain.ModerationVerdict{
+		Verdict:   domain.ModerationAllow,
+		Moderator: "keyword",
+		CheckedAt: time.Now(),
+	}
+	if len(categories) > 0 {
+		verdict.Verdict = domain.ModerationFlag
+		verdict.Categories = categories
+		verdict.Reason = strings.Join(reasons, "; ")
+	}
+	return verdict, nil
+}
+
+// AIModerator asks an AI provider to classify content
+type AIModerator struct {
+	service domain.AIService
+}
+
+func NewAIModerator(service domain.AIService) *AIModerator {
+	return &AIModerator{service: service}
+}
+
+var ErrInvalidModerationOutput = errors.New("AI provider returned an unreadable moderation verdict")
+
+func (a *AIModerator) Moderate(text string) (*domain.ModerationVerdict, error) {
+	output, err := a.service.Complete(context.Background(), BuildModerationPrompt(text))
+	if err != nil {
+		return nil, err
+	}
+
+	// Models like to wrap JSON in prose or code fences
+	start, end := strings.Index(output, "{"), strings.LastIndex(output, "}")
+	if start < 0 || end < start {
+		return nil, ErrInvalidModerationOutput
+	}
+	var answer struct {
+		Verdict    string   `json:"verdict"`
+		Categories []string `json:"categories"`
+		Reason     string   `json:"reason"`
+	}
+	if err := json.Unmarshal([]byte(output[start:end+1]), &answer); err != nil {
+		return nil, ErrInvalidModerationOutput
+	}
+
+	verdict := &domain.ModerationVerdict{
+		Verdict:   strings.ToLower(strings.TrimSpace(answer.Verdict)),
+		Reason:    strings.TrimSpace(answer.Reason),
+		Moderator: "ai:" + a.service.Name(),
+		CheckedAt: time.Now(),
+	}
+	if verdict.Verdict != domain.ModerationAllow && verdict.Verdict != domain.ModerationFlag {
+		return nil, ErrInvalidModerationOutput
+	}
+	for _, category := range answer.Categories {
+		category = strings.ToLower(strings.TrimSpace(category))
+		for _, known := range moderationCategories {
+			if category == known {
+				verdict.Categories = append(verdict.Categories, category)
+				break
+			}
+		}
+	}
+	return verdict, nil
+}
+
+// ModeratorChain runs moderators in order and returns the first flag, or the
+// last allow. A moderator that fails is skipped; the chain only fails if
+// every moderator does.
+type ModeratorChain []domain.ContentModerator
+
+func (c ModeratorChain) Moderate(text string) (*domain.ModerationVerdict, error) {
+	var verdict *domain.ModerationVerdict
+	var lastErr error
+	for _, moderator := range c {
+		v, err := moderator.Moderate(text)
+		if err != nil {
+			log.Println("moderation failed:", err)
+			lastErr = err
+			continue
+		}
+		if v.Flagged() {
+			return v, nil
+		}
+		verdict = v
+	}
+	if verdict == nil {
+		if lastErr == nil {
+			lastErr = errors.New("no moderators configured")
+		}
+		return nil, lastErr
+	}
+	return verdict, nil
+}
+
+// NewContentModeratorFromEnv returns the keyword classifier, followed by
+// the configured AI provider when MODERATION_USE_AI is true
+func NewContentModeratorFromEnv() domain.ContentModerator {
+	chain := ModeratorChain{NewKeywordModerator()}
+
+	useAI, _ := strconv.ParseBool(os.Getenv("MODERATION_USE_AI"))
+	if !useAI {
+		return chain
+	}
+	service, err := NewAIProviderFromEnv()
+	if err != nil {
+		log.Println("AI moderation disabled:", err)
+		return chain
+	}
+	return append(chain, NewAIModerator(service))
+}
@@ -0,0 +1,237 @@
+package infrastructure
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+)
+
+// OpenAIProvider talks to any server implementing the OpenAI chat
+// completions API, such as a local model server
+type OpenAIProvider struct {
+	baseURL        string
+	apiKey         string
+	model          string
+	embeddingModel string
+	client         *http.Client
+}
+
+// NewOpenAIProvider reads OPENAI_BASE_URL (for example
+// http://localhost:11434/v1), OPENAI_MODEL and the optional OPENAI_API_KEY
+// and OPENAI_EMBEDDING_MODEL
+func NewOpenAIProvider() (domain.AIService, error) {
+	baseURL := strings.TrimRight(os.Getenv("OPENAI_BASE_URL"), "/")
+	if baseURL == "" {
+		baseURL = "https://api.openai.com/v1"
+	}
+	model := os.Getenv("OPENAI_MODEL")
+	if model == "" {
+		return nil, errors.New("OpenAI provider error: OPENAI_MODEL is not set")
+	}
+
+	embeddingModel := os.Getenv("OPENAI_EMBEDDING_MODEL")
+	if embeddingModel == "" {
+		embeddingModel = "text-embedding-3-small"
+	}
+
+	return &OpenAIProvider{
+		baseURL:        baseURL,
+		apiKey:         os.Getenv("OPENAI_API_KEY"),
+		model:          model,
+		embeddingModel: embeddingModel,
+		client:         &http.Client{Timeout: 2 * time.Minute},
+	}, nil
+}
+
+func (o *OpenAIProvider) Name() string {
+	return "openai:" + o.model
+}
+
+func (o *OpenAIProvider) GenerateContent(ctx context.Context, params domain.GenerationParams) (string, error) {
+	return o.Complete(ctx, BuildPrompt(params))
+}
+
+func (o *OpenAIProvider) SummarizeBlog(ctx context.Context, content string) (string, error) {
+	return o.Complete(ctx, BuildSummaryPrompt(content))
+}
+
+func (o *OpenAIProvider) SuggestTags(ctx context.Context, content string, existing []string, max int) ([]string, error) {
+	text, err := o.Complete(ctx, BuildTagPrompt(content, existing, max))
+	if err != nil {
+		return nil, err
+	}
+	return parseSuggestions(text, max), nil
+}
+
+func (o *OpenAIProvider) SuggestTitles(ctx context.Context, title, content string, count int) ([]string, error) {
+	text, err := o.Complete(ctx, BuildTitlePrompt(title, content, count))
+	if err != nil {
+		return nil, err
+	}
+	return parseSuggestions(text, count), nil
+}
+
+func (o *OpenAIProvider) Translate(ctx context.Context, title, content, language string) (string, error) {
+	return o.Complete(ctx, BuildTranslatePrompt(title, content, language))
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+	Stream   bool          `json:"stream,omitempty"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+		Delta   chatMessage `json:"delta"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Complete sends a single user message and returns the reply
+func (o *OpenAIProvider) Complete(ctx context.Context, prompt string) (string, error) {
+	resp, err := o.post(ctx, prompt, false)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("OpenAI provider error: %s: %w", resp.Status, err)
+	}
+	if len(result.Choices) == 0 {
+		return "", errors.New("OpenAI provider error: empty response")
+	}
+	return result.Choices[0].Message.Content, nil
+}
+
+// GenerateContentStream reads the server-sent events of a streamed chat
+// completion, passing each content delta to onChunk
+func (o *OpenAIProvider) GenerateContentStream(ctx context.Context, params domain.GenerationParams, onChunk func(string) error) (string, error) {
+	resp, err := o.post(ctx, BuildPrompt(params), true)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var sb strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		var event chatResponse
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return sb.String(), fmt.Errorf("OpenAI provider error: %w", err)
+		}
+		if event.Error != nil {
+			return sb.String(), fmt.Errorf("OpenAI provider error: %s", event.Error.Message)
+		}
+		if len(event.Choices) == 0 || event.Choices[0].Delta.Content == "" {
+			continue
+		}
+
+		chunk := event.Choices[0].Delta.Content
+		sb.WriteString(chunk)
+		if err := onChunk(chunk); err != nil {
+			return sb.String(), err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return sb.String(), err
+	}
+	return sb.String(), nil
+}
+
+func (o *OpenAIProvider) EmbeddingModel() string {
+	return "openai:" + o.embeddingModel
+}
+
+// Embed calls the embeddings endpoint
+func (o *OpenAIProvider) Embed(ctx context.Context, text string) ([]float32, error) {
+	resp, err := o.send(ctx, "/embeddings", map[string]string{"model": o.embeddingModel, "input": text})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Data []struct {
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("OpenAI provider error: %s: %w", resp.Status, err)
+	}
+	if len(result.Data) == 0 || len(result.Data[0].Embedding) == 0 {
+		return nil, errors.New("OpenAI provider error: empty embedding")
+	}
+	return result.Data[0].Embedding, nil
+}
+
+// post sends a chat completion request for a single user message
+func (o *OpenAIProvider) post(ctx context.Context, prompt string, stream bool) (*http.Response, error) {
+	return o.send(ctx, "/chat/completions", chatRequest{
+		Model:    o.model,
+		Messages: []chatMessage{{Role: "user", Content: prompt}},
+		Stream:   stream,
+	})
+}
+
+// send posts payload as JSON to path. A non-2xx answer is turned into an
+// error.
+func (o *OpenAIProvider) send(ctx context.Context, path string, payload any) (*http.Response, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if o.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		err := fmt.Errorf("OpenAI provider error: %s", resp.Status)
+		var result chatResponse
+		if json.NewDecoder(resp.Body).Decode(&result) == nil && result.Error != nil {
+			err = fmt.Errorf("OpenAI provider error: %s: %s", resp.Status, result.Error.Message)
+		}
+		return nil, httpStatusError(resp.StatusCode, resp.Header, err)
+	}
+	return resp, nil
+}
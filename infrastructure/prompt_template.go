@@ -0,0 +1,29 @@
+package infrastructure
+
+import (
+	"strings"
+	"text/template"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+)
+
+var promptFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// RenderPrompt fills a prompt template body with the given data. Besides
+// the PromptData fields, templates may use join, upper and lower.
+func RenderPrompt(body string, data domain.PromptData) (string, error) {
+	tmpl, err := template.New("prompt").Funcs(promptFuncs).Option("missingkey=error").Parse(body)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,422 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+)
+
+// Operation names of calls that are not metered as AI usage
+const (
+	aiOperationComplete = "complete"
+	aiOperationEmbed    = "embed"
+)
+
+// ResilienceConfig controls how ResilientAIService calls its provider
+type ResilienceConfig struct {
+	// Timeouts limit each attempt of an operation, keyed by operation name
+	// (domain.AIOperation..., "complete" or "embed"). Operations without
+	// an entry use DefaultTimeout.
+	Timeouts       map[string]time.Duration
+	DefaultTimeout time.Duration
+	// MaxRetries is how many times a transient failure is retried
+	MaxRetries int
+	// Backoff is the wait before the first retry; it doubles with every
+	// retry up to MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// BreakerThreshold consecutive failed calls open the circuit for
+	// BreakerCooldown; 0 disables the breaker
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+// DefaultResilienceConfig returns the settings used unless overridden
+func DefaultResilienceConfig() ResilienceConfig {
+	return ResilienceConfig{
+		Timeouts: map[string]time.Duration{
+			domain.AIOperationGenerate:       60 * time.Second,
+			domain.AIOperationGenerateStream: 2 * time.Minute,
+			domain.AIOperationSummarize:      30 * time.Second,
+			domain.AIOperationSuggestTags:    20 * time.Second,
+			domain.AIOperationSuggestTitles:  20 * time.Second,
+			domain.AIOperationTranslate:      60 * time.Second,
+			aiOperationComplete:              60 * time.Second,
+			aiOperationEmbed:                 20 * time.Second,
+		},
+		DefaultTimeout:   30 * time.Second,
+		MaxRetries:       2,
+		Backoff:          500 * time.Millisecond,
+		MaxBackoff:       5 * time.Second,
+		BreakerThreshold: 5,
+		BreakerCooldown:  30 * time.Second,
+	}
+}
+
+// ResilienceConfigFromEnv overrides the defaults with AI_TIMEOUT,
+// AI_TIMEOUT_<OPERATION> (e.g. AI_TIMEOUT_GENERATE=90s), AI_MAX_RETRIES,
+// AI_RETRY_BACKOFF, AI_RETRY_MAX_BACKOFF, AI_BREAKER_THRESHOLD and
+// AI_BREAKER_COOLDOWN
+func ResilienceConfigFromEnv() ResilienceConfig {
+	config := DefaultResilienceConfig()
+
+	for _, env := range os.Environ() {
+		key, value, _ := strings.Cut(env, "=")
+		operation, ok := strings.CutPrefix(key, "AI_TIMEOUT_")
+		if !ok {
+			continue
+		}
+		if timeout, err := time.ParseDuration(strings.TrimSpace(value)); err == nil && timeout > 0 {
+			config.Timeouts[strings.ToLower(operation)] = timeout
+		}
+	}
+
+	durations := map[string]*time.Duration{
+		"AI_TIMEOUT":           &config.DefaultTimeout,
+		"AI_RETRY_BACKOFF":     &config.Backoff,
+		"AI_RETRY_MAX_BACKOFF": &config.MaxBackoff,
+		"AI_BREAKER_COOLDOWN":  &config.BreakerCooldown,
+	}
+	for key, target := range durations {
+		if d, err := time.ParseDuration(strings.TrimSpace(os.Getenv(key))); err == nil && d > 0 {
+			*target = d
+		}
+	}
+
+	counts := map[string]*int{
+		"AI_MAX_RETRIES":       &config.MaxRetries,
+		"AI_BREAKER_THRESHOLD": &config.BreakerThreshold,
+	}
+	for key, target := range counts {
+		if n, err := strconv.Atoi(strings.TrimSpace(os.Getenv(key))); err == nil && n >= 0 {
+			*target = n
+		}
+	}
+	return config
+}
+
+// ResilientAIService wraps a provider with per-operation timeouts, retries
+// with exponential backoff and a circuit breaker. Transient failures come
+// back as *domain.AIProviderError.
+type ResilientAIService struct {
+	next    domain.AIService
+	config  ResilienceConfig
+	breaker *circuitBreaker
+}
+
+func NewResilientAIService(next domain.AIService, config ResilienceConfig) *ResilientAIService {
+	return &ResilientAIService{
+		next:   next,
+		config: config,
+		breaker: &circuitBreaker{
+			threshold: config.BreakerThreshold,
+			cooldown:  config.BreakerCooldown,
+		},
+	}
+}
+
+func (r *ResilientAIService) Name() string {
+	return r.next.Name()
+}
+
+func (r *ResilientAIService) GenerateContent(ctx context.Context, params domain.GenerationParams) (string, error) {
+	return resilientCall(ctx, r, domain.AIOperationGenerate, func(ctx context.Context) (string, error) {
+		return r.next.GenerateContent(ctx, params)
+	})
+}
+
+func (r *ResilientAIService) SummarizeBlog(ctx context.Context, content string) (string, error) {
+	return resilientCall(ctx, r, domain.AIOperationSummarize, func(ctx context.Context) (string, error) {
+		return r.next.SummarizeBlog(ctx, content)
+	})
+}
+
+func (r *ResilientAIService) Complete(ctx context.Context, prompt string) (string, error) {
+	return resilientCall(ctx, r, aiOperationComplete, func(ctx context.Context) (string, error) {
+		return r.next.Complete(ctx, prompt)
+	})
+}
+
+func (r *ResilientAIService) SuggestTags(ctx context.Context, content string, existing []string, max int) ([]string, error) {
+	return resilientCall(ctx, r, domain.AIOperationSuggestTags, func(ctx context.Context) ([]string, error) {
+		return r.next.SuggestTags(ctx, content, existing, max)
+	})
+}
+
+func (r *ResilientAIService) SuggestTitles(ctx context.Context, title, content string, count int) ([]string, error) {
+	return resilientCall(ctx, r, domain.AIOperationSuggestTitles, func(ctx context.Context) ([]string, error) {
+		return r.next.SuggestTitles(ctx, title, content, count)
+	})
+}
+
+func (r *ResilientAIService) Translate(ctx context.Context, title, content, language string) (string, error) {
+	return resilientCall(ctx, r, domain.AIOperationTranslate, func(ctx context.Context) (string, error) {
+		return r.next.Translate(ctx, title, content, language)
+	})
+}
+
+// EmbeddingModel returns the embedding model of the wrapped provider, or
+// "" if it cannot embed
+func (r *ResilientAIService) EmbeddingModel() string {
+	if embedder, ok := r.next.(domain.Embedder); ok {
+		return embedder.EmbeddingModel()
+	}
+	return ""
+}
+
+func (r *ResilientAIService) Embed(ctx context.Context, text string) ([]float32, error) {
+	embedder, ok := r.next.(domain.Embedder)
+	if !ok {
+		return nil, fmt.Errorf("AI provider %s cannot compute embeddings", r.next.Name())
+	}
+	return resilientCall(ctx, r, aiOperationEmbed, func(ctx context.Context) ([]float32, error) {
+		return embedder.Embed(ctx, text)
+	})
+}
+
+// GenerateContentStream streams when the provider can, and otherwise
+// delivers the whole post as one chunk. A stream is only retried if it
+// failed before its first chunk was sent.
+func (r *ResilientAIService) GenerateContentStream(ctx context.Context, params domain.GenerationParams, onChunk func(string) error) (string, error) {
+	streamer, ok := r.next.(domain.AIStreamService)
+	if !ok {
+		content, err := r.GenerateContent(ctx, params)
+		if err != nil {
+			return "", err
+		}
+		return content, onChunk(content)
+	}
+
+	sent := false
+	var partial string
+	_, err := resilientCall(ctx, r, domain.AIOperationGenerateStream, func(ctx context.Context) (string, error) {
+		content, err := streamer.GenerateContentStream(ctx, params, func(chunk string) error {
+			sent = true
+			return onChunk(chunk)
+		})
+		partial = content
+		if err != nil && sent {
+			return content, permanent{err}
+		}
+		return content, err
+	})
+	return partial, err
+}
+
+// permanent marks an error that must not be retried
+type permanent struct{ error }
+
+func (p permanent) Unwrap() error { return p.error }
+
+// resilientCall runs call with the operation's timeout, retrying transient
+// failures while the circuit allows it
+func resilientCall[T any](ctx context.Context, r *ResilientAIService, operation string, call func(context.Context) (T, error)) (T, error) {
+	var zero T
+	timeout, ok := r.config.Timeouts[operation]
+	if !ok {
+		timeout = r.config.DefaultTimeout
+	}
+
+	for attempt := 0; ; attempt++ {
+		wait, trial, ok := r.breaker.allow()
+		if !ok {
+			return zero, &domain.AIProviderError{Kind: domain.ErrAICircuitOpen, Op: operation, RetryAfter: wait}
+		}
+
+		attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+		result, err := call(attemptCtx)
+		cancel()
+		if err == nil {
+			r.breaker.record(breakerSuccess, trial)
+			return result, nil
+		}
+
+		// The caller went away; that says nothing about the provider
+		if ctx.Err() != nil {
+			r.breaker.record(breakerNeutral, trial)
+			return zero, ctx.Err()
+		}
+
+		var stop permanent
+		if errors.As(err, &stop) {
+			err = stop.error
+		}
+		classified := classifyAIError(operation, err)
+		var providerErr *domain.AIProviderError
+		if !errors.As(classified, &providerErr) {
+			// The provider answered; the request itself was bad
+			r.breaker.record(breakerSuccess, trial)
+			return zero, classified
+		}
+
+		wait = r.backoff(attempt)
+		if providerErr.RetryAfter > wait {
+			wait = providerErr.RetryAfter
+		}
+		if stop.error != nil || attempt >= r.config.MaxRetries || wait > r.config.MaxBackoff {
+			r.breaker.record(breakerFailure, trial)
+			return zero, classified
+		}
+		r.breaker.record(breakerNeutral, trial)
+
+		select {
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// backoff returns the wait before retry number attempt+1: the base backoff
+// doubled per attempt, capped, with jitter so callers do not retry in step
+func (r *ResilientAIService) backoff(attempt int) time.Duration {
+	wait := r.config.Backoff << attempt
+	if wait <= 0 || wait > r.config.MaxBackoff {
+		wait = r.config.MaxBackoff
+	}
+	half := wait / 2
+	if half <= 0 {
+		return wait
+	}
+	return half + time.Duration(rand.Int63n(int64(half)))
+}
+
+// classifyAIError turns transient failures into *domain.AIProviderError
+// and returns other errors unchanged
+func classifyAIError(operation string, err error) error {
+	var providerErr *domain.AIProviderError
+	if errors.As(err, &providerErr) {
+		if providerErr.Op == "" {
+			providerErr.Op = operation
+		}
+		return providerErr
+	}
+
+	var kind error
+	var netErr net.Error
+	var coded interface{ HTTPCode() int }
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		kind = domain.ErrAITimeout
+	case errors.As(err, &coded):
+		kind = statusKind(coded.HTTPCode())
+	case errors.As(err, &netErr):
+		kind = domain.ErrAIProviderUnavailable
+		if netErr.Timeout() {
+			kind = domain.ErrAITimeout
+		}
+	}
+	if kind == nil {
+		return err
+	}
+	return &domain.AIProviderError{Kind: kind, Op: operation, Err: err}
+}
+
+// httpStatusError wraps err in a *domain.AIProviderError when status
+// means the call may succeed later
+func httpStatusError(status int, header http.Header, err error) error {
+	kind := statusKind(status)
+	if kind == nil {
+		return err
+	}
+	return &domain.AIProviderError{Kind: kind, RetryAfter: parseRetryAfter(header.Get("Retry-After")), Err: err}
+}
+
+func statusKind(status int) error {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return domain.ErrAIRateLimited
+	case status == http.StatusRequestTimeout, status == http.StatusGatewayTimeout:
+		return domain.ErrAITimeout
+	case status >= 500:
+		return domain.ErrAIProviderUnavailable
+	}
+	return nil
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as a date
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0)
+	}
+	return 0
+}
+
+type breakerOutcome int
+
+const (
+	breakerSuccess breakerOutcome = iota
+	breakerFailure
+	// breakerNeutral releases a trial call without judging the provider
+	breakerNeutral
+)
+
+// circuitBreaker stops calls to a provider after threshold consecutive
+// failures. Once cooldown has passed a single trial call is let through;
+// its outcome closes the circuit or opens it again.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+	trial     bool
+}
+
+// allow reports whether a call may go ahead, and if not, how long until
+// the circuit may let one through. trial is set for the single call let
+// through a half-open circuit.
+func (b *circuitBreaker) allow() (wait time.Duration, trial bool, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.threshold <= 0 || b.failures < b.threshold {
+		return 0, false, true
+	}
+	if wait := time.Until(b.openUntil); wait > 0 {
+		return wait, false, false
+	}
+	if b.trial {
+		return b.cooldown, false, false
+	}
+	b.trial = true
+	return 0, true, true
+}
+
+// record counts the outcome of a call. Only the trial call itself frees the
+// trial slot, so calls started before the circuit opened cannot let a
+// second trial through when they finish.
+func (b *circuitBreaker) record(outcome breakerOutcome, trial bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if trial {
+		b.trial = false
+	}
+	switch outcome {
+	case breakerSuccess:
+		b.failures = 0
+	case breakerFailure:
+		b.failures++
+		if b.threshold > 0 && b.failures >= b.threshold {
+			b.openUntil = time.Now().Add(b.cooldown)
+		}
+	}
+}
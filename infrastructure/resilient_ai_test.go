@@ -0,0 +1,153 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+)
+
+// statusError is a provider error carrying an HTTP status
+type statusError int
+
+func (s statusError) Error() string { return "status " + strconv.Itoa(int(s)) }
+func (s statusError) HTTPCode() int { return int(s) }
+
+// scriptedAI answers Complete with the scripted errors in turn, repeating
+// the last one; a nil entry is a success. With hang set it waits for the
+// attempt's deadline instead.
+type scriptedAI struct {
+	domain.AIService
+	script []error
+	hang   bool
+	calls  int
+}
+
+func (s *scriptedAI) Complete(ctx context.Context, prompt string) (string, error) {
+	s.calls++
+	if s.hang {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}
+	if err := s.script[min(s.calls, len(s.script))-1]; err != nil {
+		return "", err
+	}
+	return "done", nil
+}
+
+func testResilienceConfig() ResilienceConfig {
+	return ResilienceConfig{
+		Timeouts:       map[string]time.Duration{aiOperationComplete: 20 * time.Millisecond},
+		DefaultTimeout: time.Second,
+		MaxRetries:     2,
+		Backoff:        time.Millisecond,
+		MaxBackoff:     10 * time.Millisecond,
+	}
+}
+
+func TestResilientAIRetries(t *testing.T) {
+	unavailable := statusError(http.StatusServiceUnavailable)
+	badRequest := statusError(http.StatusBadRequest)
+
+	tests := []struct {
+		name      string
+		script    []error
+		hang      bool
+		wantErr   error
+		wantCalls int
+	}{
+		{"success", []error{nil}, false, nil, 1},
+		{"recovers after transient failures", []error{unavailable, unavailable, nil}, false, nil, 3},
+		{"rate limit is retried", []error{statusError(http.StatusTooManyRequests), nil}, false, nil, 2},
+		{"retries exhausted", []error{unavailable}, false, domain.ErrAIProviderUnavailable, 3},
+		{"4xx is not retried", []error{badRequest}, false, badRequest, 1},
+		{"gateway timeout", []error{statusError(http.StatusGatewayTimeout)}, false, domain.ErrAITimeout, 3},
+		{"deadline error", []error{context.DeadlineExceeded}, false, domain.ErrAITimeout, 3},
+		{"attempt timeout", nil, true, domain.ErrAITimeout, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &scriptedAI{script: tt.script, hang: tt.hang}
+			service := NewResilientAIService(provider, testResilienceConfig())
+
+			content, err := service.Complete(context.Background(), "prompt")
+			if tt.wantErr == nil {
+				if err != nil || content != "done" {
+					t.Fatalf("Complete() = %q, %v; want done", content, err)
+				}
+			} else if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Complete() error = %v, want %v", err, tt.wantErr)
+			}
+			if provider.calls != tt.wantCalls {
+				t.Errorf("provider called %d times, want %d", provider.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestResilientAICircuitBreaker(t *testing.T) {
+	unavailable := statusError(http.StatusServiceUnavailable)
+	config := testResilienceConfig()
+	config.MaxRetries = 0
+	config.BreakerThreshold = 2
+	config.BreakerCooldown = 30 * time.Millisecond
+	provider := &scriptedAI{script: []error{unavailable}}
+	service := NewResilientAIService(provider, config)
+	ctx := context.Background()
+
+	steps := []struct {
+		name      string
+		wait      time.Duration
+		script    []error
+		wantErr   error
+		wantCalls int
+	}{
+		{"first failure", 0, []error{unavailable}, domain.ErrAIProviderUnavailable, 1},
+		{"threshold reached", 0, []error{unavailable}, domain.ErrAIProviderUnavailable, 2},
+		{"open circuit skips the provider", 0, []error{nil}, domain.ErrAICircuitOpen, 2},
+		{"failed trial opens it again", config.BreakerCooldown, []error{unavailable}, domain.ErrAIProviderUnavailable, 3},
+		{"open again", 0, []error{nil}, domain.ErrAICircuitOpen, 3},
+		{"successful trial closes it", config.BreakerCooldown, []error{nil}, nil, 4},
+		{"closed", 0, []error{unavailable}, domain.ErrAIProviderUnavailable, 5},
+		{"one failure does not open it", 0, []error{nil}, nil, 6},
+	}
+	for _, step := range steps {
+		time.Sleep(step.wait)
+		provider.script = step.script
+		_, err := service.Complete(ctx, "prompt")
+		if !errors.Is(err, step.wantErr) {
+			t.Fatalf("%s: error = %v, want %v", step.name, err, step.wantErr)
+		}
+		if provider.calls != step.wantCalls {
+			t.Fatalf("%s: provider called %d times, want %d", step.name, provider.calls, step.wantCalls)
+		}
+	}
+}
+
+// A call started before the circuit opened must not free the trial slot
+// of the half-open circuit when it finishes
+func TestCircuitBreakerSingleTrial(t *testing.T) {
+	breaker := &circuitBreaker{threshold: 1, cooldown: time.Millisecond}
+	breaker.record(breakerFailure, false)
+	time.Sleep(2 * time.Millisecond)
+
+	if _, trial, ok := breaker.allow(); !ok || !trial {
+		t.Fatalf("allow() = trial %v, ok %v; want the trial call", trial, ok)
+	}
+	for _, outcome := range []breakerOutcome{breakerNeutral, breakerFailure} {
+		breaker.record(outcome, false)
+		time.Sleep(2 * time.Millisecond)
+		if _, _, ok := breaker.allow(); ok {
+			t.Fatalf("a second call was let through after an older call recorded outcome %d", outcome)
+		}
+	}
+
+	breaker.record(breakerSuccess, true)
+	if _, trial, ok := breaker.allow(); !ok || trial {
+		t.Fatalf("allow() = trial %v, ok %v; want a closed circuit", trial, ok)
+	}
+}
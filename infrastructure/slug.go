@@ -0,0 +1,71 @@
+package infrastructure
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const maxSlugLength = 80
+
+// transliterations covers letters that do not decompose into an ASCII base
+// letter plus combining marks
+var transliterations = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'đ': "d", 'ð': "d", 'ł': "l", 'þ': "th", 'ı': "i",
+	// Cyrillic
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh", 'з': "z",
+	'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r",
+	'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch",
+	'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya", 'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g",
+	// Greek
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i", 'θ': "th", 'ι': "i",
+	'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p", 'ρ': "r", 'σ': "s",
+	'ς': "s", 'τ': "t", 'υ': "y", 'φ': "f", 'χ': "ch", 'ψ': "ps", 'ω': "o",
+}
+
+// Slugify turns a title into a lowercase, hyphen separated ASCII slug.
+// Accented letters lose their accents and Cyrillic and Greek letters are
+// transliterated; anything else that is not a letter or digit becomes a
+// separator.
+func Slugify(title string) string {
+	var sb strings.Builder
+	pendingDash := false
+
+	write := func(s string) {
+		if s == "" {
+			return
+		}
+		if pendingDash && sb.Len() > 0 {
+			sb.WriteByte('-')
+		}
+		pendingDash = false
+		sb.WriteString(s)
+	}
+
+	for _, r := range norm.NFD.String(strings.ToLower(title)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// combining accent left over from decomposition
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			write(string(r))
+		case r == '\'' || r == '’':
+			// keep contractions together: "don't" becomes "dont"
+		default:
+			if t, ok := transliterations[r]; ok {
+				write(t)
+			} else {
+				pendingDash = true
+			}
+		}
+	}
+
+	slug := sb.String()
+	if len(slug) > maxSlugLength {
+		slug = strings.TrimRight(slug[:maxSlugLength], "-")
+	}
+	if slug == "" {
+		slug = "post"
+	}
+	return slug
+}
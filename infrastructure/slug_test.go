@@ -0,0 +1,39 @@
+package infrastructure
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Hello, World!", "hello-world"},
+		{"  Leading and trailing  ", "leading-and-trailing"},
+		{"Don't Stop", "dont-stop"},
+		{"Crème brûlée à la carte", "creme-brulee-a-la-carte"},
+		{"Straße", "strasse"},
+		{"Привет мир", "privet-mir"},
+		{"Καλημέρα", "kalimera"},
+		{"Go 1.24 released", "go-1-24-released"},
+		{"???", "post"},
+		{"", "post"},
+		{"日本語", "post"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			if got := Slugify(tt.title); got != tt.want {
+				t.Errorf("Slugify(%q) = %q, want %q", tt.title, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlugifyCapsLength(t *testing.T) {
+	got := Slugify(strings.Repeat("word ", 40))
+	if len(got) > maxSlugLength || strings.HasSuffix(got, "-") {
+		t.Errorf("slug %q is longer than %d or ends in a dash", got, maxSlugLength)
+	}
+}
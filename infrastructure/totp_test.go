@@ -0,0 +1,100 @@
+package infrastructure
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 key of the RFC 6238 test vectors,
+// "12345678901234567890", base32 encoded
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	// The RFC lists 8 digit codes; these are their last 6 digits
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		got, err := TOTPCode(rfc6238Secret, TOTPStep(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("code at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	step := TOTPStep(now)
+	code := func(step int64) string {
+		code, err := TOTPCode(rfc6238Secret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", rfc6238Secret, "081804", step, true},
+		{"spaces typed in", rfc6238Secret, " 081 804 ", step, true},
+		{"lowercase secret", "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", "081804", step, true},
+		{"previous step", rfc6238Secret, code(step - 1), step - 1, true},
+		{"next step", rfc6238Secret, code(step + 1), step + 1, true},
+		{"two steps ago", rfc6238Secret, code(step - 2), 0, false},
+		{"two steps ahead", rfc6238Secret, code(step + 2), 0, false},
+		{"wrong code", rfc6238Secret, "000000", 0, false},
+		{"too short", rfc6238Secret, "08180", 0, false},
+		{"too long", rfc6238Secret, "0818040", 0, false},
+		{"invalid secret", "not base32!", "081804", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, gotOK := ValidateTOTP(tt.secret, tt.code, now)
+			if gotOK != tt.wantOK || gotStep != tt.wantStep {
+				t.Errorf("ValidateTOTP = (%d, %v), want (%d, %v)", gotStep, gotOK, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if len(NormalizeRecoveryCode(code)) != RecoveryCodeLength || seen[code] {
+			t.Fatalf("bad or repeated recovery code %q", code)
+		}
+		seen[code] = true
+	}
+
+	hash, err := HashRecoveryCode(codes[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, typed := range []string{codes[0], " " + codes[0][:5] + " " + codes[0][6:], NormalizeRecoveryCode(codes[0])} {
+		if !CheckRecoveryCode(typed, hash) {
+			t.Errorf("%q does not match its hash", typed)
+		}
+	}
+	if CheckRecoveryCode(codes[1], hash) {
+		t.Error("another code matches the hash")
+	}
+}
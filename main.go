@@ -1,12 +1,17 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/sol-tad/Blog-post-Api/config"
 	"github.com/sol-tad/Blog-post-Api/delivery/routers"
+	"github.com/sol-tad/Blog-post-Api/infrastructure"
+	"github.com/sol-tad/Blog-post-Api/repository"
+	"github.com/sol-tad/Blog-post-Api/usecase"
 )
 
 func main() {
@@ -15,9 +20,39 @@ func main() {
 		log.Fatal("Error loading .env file")
 	}
 	config.ConnectDB()
+
+	// Sign tokens with the shared key pairs, picking up rotations
+	kek, err := infrastructure.KeyEncryptionKeyFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	keys := infrastructure.NewKeySet(repository.NewSigningKeyRepository(config.SigningKeyCollection), kek)
+	if err := keys.Load(context.Background()); err != nil {
+		log.Fatal("Error loading signing keys: ", err)
+	}
+	infrastructure.SigningKeys = keys
+	reload, _ := time.ParseDuration(os.Getenv("JWT_KEYS_RELOAD_INTERVAL"))
+	go keys.Watch(context.Background(), reload)
 	
+	// Shared by the routes and the background jobs below
+	blogUsecase := routers.NewBlogUseCase()
+
+	// Publish scheduled blogs in the background
+	interval, _ := time.ParseDuration(os.Getenv("PUBLISHER_INTERVAL"))
+	publisher := usecase.NewBlogPublisher(blogUsecase.Repo, interval)
+	go publisher.Run(context.Background())
+
+	// Embed blogs stored before semantic search or an embedder change
+	go func() {
+		if count, err := blogUsecase.Search.IndexAll(context.Background()); err != nil {
+			log.Println("semantic search backfill failed:", err)
+		} else {
+			log.Println("semantic search indexed", count, "blogs")
+		}
+	}()
+
 	port := os.Getenv("PORT")
-	router:=routers.SetupRouter()
+	router:=routers.SetupRouter(blogUsecase)
 	router.Run(port)
 
 }
\ No newline at end of file
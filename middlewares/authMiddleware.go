@@ -1,16 +1,19 @@
 package middlewares
 
 import (
-	"fmt"
+	"errors"
+	"log"
 	"net/http"
-	"os"
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"github.com/sol-tad/Blog-post-Api/infrastructure"
 )
 
-var JWT_ACCESS_TOKEN_SECRET = os.Getenv("JWT_ACCESS_TOKEN_SECRET")
+// TokenRevocations is checked for every access token; when nil, tokens are
+// only checked for signature and expiry
+var TokenRevocations domain.TokenRevocationRepository
 
 func AuthMiddleware() gin.HandlerFunc {
   return func(c *gin.Context) {
@@ -23,39 +26,86 @@ func AuthMiddleware() gin.HandlerFunc {
     tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
     tokenStr = strings.TrimSpace(tokenStr) 
 
-	fmt.Println("Token--------------------:", tokenStr)
-
-    token, _ := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-      // Optional: Verify signing method
-      if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-        return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-      }
-      return []byte(JWT_ACCESS_TOKEN_SECRET), nil
-    })
-
-
-    claims, ok := token.Claims.(jwt.MapClaims)
-    if !ok {
-      c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid claims"})
+    claims, err := authenticate(c, tokenStr)
+    if errors.Is(err, errRevocationCheck) {
+      c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+      return
+    }
+    if err != nil {
+      c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
       return
     }
 
-    c.Set("role", claims["role"])
-    c.Set("id", claims["user_id"])
-    c.Set("username", claims["username"])
+    setClaims(c, claims)
     c.Next()
   }
 }
 
 
-func AdminOnly() gin.HandlerFunc {
+// OptionalAuthMiddleware sets the user claims when a valid bearer token is
+// supplied but lets anonymous requests through
+func OptionalAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		role,exists:=c.Get("role")
-		if!exists||role!="admin"{
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+		authHeader := c.GetHeader("Authorization")
+		if !strings.HasPrefix(authHeader, "Bearer ") {
+			c.Next()
 			return
 		}
+
+		tokenStr := strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer "))
+		if claims, err := authenticate(c, tokenStr); err == nil {
+			setClaims(c, claims)
+		}
 		c.Next()
+	}
+}
+
+var (
+	errRevokedToken    = errors.New("token has been revoked")
+	errRevocationCheck = errors.New("could not check whether the token was revoked")
+)
+
+// authenticate verifies the token and checks it was not revoked since it
+// was issued, by logout, a password reset or a role change
+func authenticate(c *gin.Context, tokenStr string) (*domain.AccessClaims, error) {
+	claims, err := infrastructure.VerifyAccessToken(tokenStr)
+	if err != nil {
+		return nil, err
+	}
+	if TokenRevocations == nil {
+		return claims, nil
+	}
+	revoked, err := TokenRevocations.IsRevoked(c.Request.Context(), claims.IssuedAt, claims.RevocationKeys()...)
+	if err != nil {
+		log.Println("checking token revocation failed:", err)
+		return nil, errRevocationCheck
+	}
+	if revoked {
+		return nil, errRevokedToken
+	}
+	return claims, nil
+}
+
+func setClaims(c *gin.Context, claims *domain.AccessClaims) {
+	c.Set("role", claims.Role)
+	c.Set("id", claims.UserID)
+	c.Set("session_id", claims.SessionID)
+	c.Set("token_id", claims.TokenID)
+	c.Set("auth_methods", claims.AuthMethods)
+	c.Set("token_expires_at", claims.ExpiresAt)
+}
 
+// RequirePermission lets a request through only if the caller's role
+// grants the permission. It runs after AuthMiddleware.
+func RequirePermission(permission domain.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !domain.RoleHas(c.GetString("role"), permission) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":      "your role does not allow this",
+				"permission": permission,
+			})
+			return
+		}
+		c.Next()
 	}
-}
\ No newline at end of file
+}
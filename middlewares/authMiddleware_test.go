@@ -0,0 +1,181 @@
+package middlewares
+
+import (
+	"context"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"github.com/sol-tad/Blog-post-Api/infrastructure"
+	"github.com/sol-tad/Blog-post-Api/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memorySigningKeys keeps signing keys in memory
+type memorySigningKeys struct {
+	mu   sync.Mutex
+	keys []*domain.SigningKey
+}
+
+func (r *memorySigningKeys) Create(ctx context.Context, key *domain.SigningKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keys = append([]*domain.SigningKey{key}, r.keys...)
+	return nil
+}
+
+func (r *memorySigningKeys) CreateBootstrap(ctx context.Context, key *domain.SigningKey) (bool, error) {
+	return true, r.Create(ctx, key)
+}
+
+func (r *memorySigningKeys) List(ctx context.Context, now time.Time) ([]*domain.SigningKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*domain.SigningKey(nil), r.keys...), nil
+}
+
+func (r *memorySigningKeys) Retire(ctx context.Context, id string, retireAt, expiresAt time.Time) error {
+	return nil
+}
+
+func (r *memorySigningKeys) ReplacePrivateKey(ctx context.Context, id, old, replacement string) error {
+	return nil
+}
+
+// setupAuth signs tokens with a fresh key and checks them against an
+// in-memory revocation store for the duration of the test
+func setupAuth(t *testing.T) domain.TokenRevocationRepository {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	kek := make([]byte, 32)
+	if _, err := rand.Read(kek); err != nil {
+		t.Fatal(err)
+	}
+	keys := infrastructure.NewKeySet(&memorySigningKeys{}, kek)
+	if err := keys.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	revocations := repository.NewMemoryTokenRevocationRepository()
+
+	previousKeys, previousRevocations := infrastructure.SigningKeys, TokenRevocations
+	infrastructure.SigningKeys, TokenRevocations = keys, revocations
+	t.Cleanup(func() {
+		infrastructure.SigningKeys, TokenRevocations = previousKeys, previousRevocations
+	})
+	return revocations
+}
+
+func accessToken(t *testing.T, userID, role string) string {
+	t.Helper()
+	token, err := infrastructure.GenerateAccessToken(userID, role, "", []string{domain.AuthMethodPassword})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func serve(router *gin.Engine, authorization string) int {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	router.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	revocations := setupAuth(t)
+	userID := primitive.NewObjectID().Hex()
+	revokedUserID := primitive.NewObjectID().Hex()
+
+	revokedToken := accessToken(t, revokedUserID, domain.RoleAuthor)
+	if err := revocations.Revoke(context.Background(), domain.UserRevocationKey(revokedUserID), time.Now(), time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	router.GET("/", AuthMiddleware(), func(c *gin.Context) {
+		if c.GetString("id") != userID || c.GetString("role") != domain.RoleAuthor {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name          string
+		authorization string
+		want          int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"not a bearer token", "Basic " + accessToken(t, userID, domain.RoleAuthor), http.StatusUnauthorized},
+		{"garbage token", "Bearer not-a-token", http.StatusUnauthorized},
+		{"valid token", "Bearer " + accessToken(t, userID, domain.RoleAuthor), http.StatusOK},
+		{"revoked token", "Bearer " + revokedToken, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := serve(router, tt.authorization); got != tt.want {
+				t.Errorf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOptionalAuthMiddlewareLetsAnonymousRequestsThrough(t *testing.T) {
+	setupAuth(t)
+
+	router := gin.New()
+	router.GET("/", OptionalAuthMiddleware(), func(c *gin.Context) {
+		if c.GetString("id") != "" {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	for _, authorization := range []string{"", "Bearer not-a-token"} {
+		if got := serve(router, authorization); got != http.StatusOK {
+			t.Errorf("%q: status = %d, want %d", authorization, got, http.StatusOK)
+		}
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	setupAuth(t)
+	userID := primitive.NewObjectID().Hex()
+
+	tests := []struct {
+		role       string
+		permission domain.Permission
+		want       int
+	}{
+		{domain.RoleReader, domain.PermCommentsCreate, http.StatusOK},
+		{domain.RoleReader, domain.PermBlogsCreate, http.StatusForbidden},
+		{domain.RoleReader, domain.PermBlogsPublish, http.StatusForbidden},
+		{domain.RoleAuthor, domain.PermBlogsPublish, http.StatusOK},
+		{domain.RoleAuthor, domain.PermRolesManage, http.StatusForbidden},
+		{domain.RoleModerator, domain.PermBlogsCreate, http.StatusForbidden},
+		{domain.RoleModerator, domain.PermBlogsPublish, http.StatusOK},
+		{domain.RoleAdmin, domain.PermRolesManage, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.role+" "+string(tt.permission), func(t *testing.T) {
+			router := gin.New()
+			router.GET("/", AuthMiddleware(), RequirePermission(tt.permission), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			if got := serve(router, "Bearer "+accessToken(t, userID, tt.role)); got != tt.want {
+				t.Errorf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type aiUsageRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAIUsageRepository(coll *mongo.Collection) domain.AIUsageRepository {
+	return &aiUsageRepository{
+		collection: coll,
+	}
+}
+
+func (r *aiUsageRepository) Record(usage *domain.AIUsage) error {
+	result, err := r.collection.InsertOne(context.Background(), usage)
+	if err != nil {
+		return err
+	}
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		usage.ID = oid
+	}
+	return nil
+}
+
+// CountSince counts the user's calls made at or after since
+func (r *aiUsageRepository) CountSince(userID primitive.ObjectID, since time.Time) (int64, error) {
+	return r.collection.CountDocuments(
+		context.Background(),
+		bson.M{"user_id": userID, "created_at": bson.M{"$gte": since}},
+	)
+}
+
+// Summarize totals usage per user and operation, busiest users first
+func (r *aiUsageRepository) Summarize(filter domain.AIUsageFilter) ([]*domain.AIUsageSummary, error) {
+	ctx := context.Background()
+	match := bson.M{}
+	if !filter.UserID.IsZero() {
+		match["user_id"] = filter.UserID
+	}
+	created := bson.M{}
+	if !filter.From.IsZero() {
+		created["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		created["$lt"] = filter.To
+	}
+	if len(created) > 0 {
+		match["created_at"] = created
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":            bson.M{"user_id": "$user_id", "operation": "$operation"},
+			"calls":          bson.M{"$sum": 1},
+			"failures":       bson.M{"$sum": bson.M{"$cond": bson.A{"$success", 0, 1}}},
+			"prompt_chars":   bson.M{"$sum": "$prompt_chars"},
+			"response_chars": bson.M{"$sum": "$response_chars"},
+			"avg_latency_ms": bson.M{"$avg": "$latency_ms"},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":            0,
+			"user_id":        "$_id.user_id",
+			"operation":      "$_id.operation",
+			"calls":          1,
+			"failures":       1,
+			"prompt_chars":   1,
+			"response_chars": 1,
+			"avg_latency_ms": 1,
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "calls", Value: -1}, {Key: "user_id", Value: 1}, {Key: "operation", Value: 1}}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	summaries := []*domain.AIUsageSummary{}
+	if err := cursor.All(ctx, &summaries); err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
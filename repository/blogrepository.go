@@ -3,6 +3,8 @@ package repository
 import (
 	"context"
 	"fmt"
+	"log"
+	"time"
 
 	"github.com/sol-tad/Blog-post-Api/domain"
 	"github.com/sol-tad/Blog-post-Api/usecase"
@@ -19,6 +21,26 @@ type BlogRepo struct{
 
 func NewBlogRepo(coll *mongo.Collection) usecase.IBlogRepo {
 	ctx := context.Background()
+	// Slugs are checked before they are written; the index turns away the
+	// second of two writers that picked the same slug. Blogs without a slug
+	// are left out of it.
+	_, err := coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "slug", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"slug": bson.M{"$type": "string"}}),
+	})
+	if err != nil {
+		log.Println("creating blog slug index failed:", err)
+	}
+	// Old slugs keep redirecting to their blog, so no two blogs may share one
+	_, err = coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "old_slugs", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"old_slugs": bson.M{"$type": "string"}}),
+	})
+	if err != nil {
+		log.Println("creating blog old slug index failed:", err)
+	}
 	return &BlogRepo{
 		collection: coll,
 		context: ctx,
@@ -91,6 +113,8 @@ func (b *BlogRepo) UpdateBlog(id primitive.ObjectID, updatedBlog *domain.Blog) e
 	updated := bson.M{
 		"$set":bson.M{
 	"content":updatedBlog.Content,
+	"content_html":updatedBlog.ContentHTML,
+	"render_version":updatedBlog.RenderVersion,
 	"id" : updatedBlog.ID,
 	"title" : updatedBlog.Title,
 	"tags":updatedBlog.Tags,
@@ -98,7 +122,10 @@ func (b *BlogRepo) UpdateBlog(id primitive.ObjectID, updatedBlog *domain.Blog) e
 	"updated_at" : updatedBlog.UpdatedAt,
 	"author_id" : updatedBlog.AuthorID,
 	"author_name": updatedBlog.AuthorName,
+	"slug" : updatedBlog.Slug,
+	"old_slugs" : updatedBlog.OldSlugs,
 	"stats" : updatedBlog.Stats,
+	"moderation" : updatedBlog.Moderation,
 			
 		},
 	}
@@ -130,6 +157,20 @@ func (b *BlogRepo) List(page, limit int, filter domain.BlogFilter) ([]*domain.Bl
     if len(filter.Tag) > 0 {
         query["tags"] = bson.M{"$all": filter.Tag}
     }
+
+    // Restrict to the requested status and to what the viewer may see
+    if filter.Status != "" {
+        query["status"] = statusQuery(filter.Status)
+    }
+    if filter.OnlyViewer {
+        query["author_id"] = filter.ViewerID
+    } else if !filter.IncludeUnpublished {
+        visible := []bson.M{{"status": statusQuery(domain.BlogStatusPublished)}}
+        if !filter.ViewerID.IsZero() {
+            visible = append(visible, bson.M{"author_id": filter.ViewerID})
+        }
+        query["$and"] = []bson.M{{"$or": visible}}
+    }
     
     // Set default sort options
     sortField := "created_at"
@@ -167,4 +208,220 @@ func (b *BlogRepo) List(page, limit int, filter domain.BlogFilter) ([]*domain.Bl
     }
     
     return blogs, total, nil
-}
\ No newline at end of file
+}
+
+// TransitionStatus moves a blog from one lifecycle status to another. The
+// update only applies while the blog is still in the expected status so
+// concurrent transitions cannot both succeed.
+func (b *BlogRepo) TransitionStatus(id primitive.ObjectID, from, to string, at time.Time) error {
+	filter := bson.M{"_id": id, "status": statusQuery(from)}
+	set := bson.M{"status": to, "updated_at": at}
+	if to == domain.BlogStatusPublished {
+		set["published_at"] = at
+	}
+	update := bson.M{"$set": set, "$unset": bson.M{"publish_at": ""}}
+
+	res, err := b.collection.UpdateOne(b.context, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// SchedulePublish marks a blog as scheduled for publishing at publishAt,
+// provided it is still in the expected status
+func (b *BlogRepo) SchedulePublish(id primitive.ObjectID, from string, publishAt, at time.Time) error {
+	filter := bson.M{"_id": id, "status": statusQuery(from)}
+	update := bson.M{"$set": bson.M{
+		"status":     domain.BlogStatusScheduled,
+		"publish_at": publishAt,
+		"updated_at": at,
+	}}
+
+	res, err := b.collection.UpdateOne(b.context, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// PublishNextDue publishes one scheduled blog whose publish time has passed
+// and returns it, or returns nil when nothing is due. The status check and
+// update happen in a single document operation, so when several API
+// instances run the publisher each blog is published by exactly one of them.
+func (b *BlogRepo) PublishNextDue(now time.Time) (*domain.Blog, error) {
+	filter := bson.M{
+		"status":     domain.BlogStatusScheduled,
+		"publish_at": bson.M{"$lte": now},
+	}
+	update := bson.M{
+		"$set":   bson.M{"status": domain.BlogStatusPublished, "published_at": now, "updated_at": now},
+		"$unset": bson.M{"publish_at": ""},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "publish_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var blog domain.Blog
+	err := b.collection.FindOneAndUpdate(b.context, filter, update, opts).Decode(&blog)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &blog, nil
+}
+
+// FindBySlug finds a blog by its current slug or by one of its old slugs
+func (b *BlogRepo) FindBySlug(slug string) (*domain.Blog, error) {
+	filter := bson.M{"$or": []bson.M{{"slug": slug}, {"old_slugs": slug}}}
+
+	var blog domain.Blog
+	if err := b.collection.FindOne(b.context, filter).Decode(&blog); err != nil {
+		return nil, err
+	}
+	return &blog, nil
+}
+
+// SlugTaken reports whether any blog other than exclude uses the slug,
+// either currently or as an old slug
+func (b *BlogRepo) SlugTaken(slug string, exclude primitive.ObjectID) (bool, error) {
+	filter := bson.M{
+		"$or": []bson.M{{"slug": slug}, {"old_slugs": slug}},
+		"_id": bson.M{"$ne": exclude},
+	}
+	count, err := b.collection.CountDocuments(b.context, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// SaveRenderedContent caches the rendered HTML of a blog's content
+func (b *BlogRepo) SaveRenderedContent(id primitive.ObjectID, contentHTML string, version int) error {
+	update := bson.M{"$set": bson.M{"content_html": contentHTML, "render_version": version}}
+	_, err := b.collection.UpdateOne(b.context, bson.M{"_id": id}, update)
+	return err
+}
+
+// SetCommentPolicy changes whether comments on the blog are open, moderated or closed
+func (b *BlogRepo) SetCommentPolicy(id primitive.ObjectID, policy string) error {
+	update := bson.M{"$set": bson.M{"comment_policy": policy, "updated_at": time.Now()}}
+	_, err := b.collection.UpdateOne(b.context, bson.M{"_id": id}, update)
+	return err
+}
+
+// SaveSummary stores the blog's summary, or removes it when summary is nil
+func (b *BlogRepo) SaveSummary(id primitive.ObjectID, summary *domain.BlogSummary) error {
+	update := bson.M{"$unset": bson.M{"summary": ""}}
+	if summary != nil {
+		update = bson.M{"$set": bson.M{"summary": summary}}
+	}
+	_, err := b.collection.UpdateOne(b.context, bson.M{"_id": id}, update)
+	return err
+}
+
+// PopularTags returns the tags used by published blogs, most used first
+func (b *BlogRepo) PopularTags(limit int) ([]string, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"status": statusQuery(domain.BlogStatusPublished)}}},
+		{{Key: "$unwind", Value: "$tags"}},
+		{{Key: "$group", Value: bson.M{"_id": "$tags", "count": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+
+	cursor, err := b.collection.Aggregate(b.context, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var results []struct {
+		Tag string `bson:"_id"`
+	}
+	if err := cursor.All(b.context, &results); err != nil {
+		return nil, err
+	}
+
+	tags := make([]string, 0, len(results))
+	for _, result := range results {
+		if result.Tag != "" {
+			tags = append(tags, result.Tag)
+		}
+	}
+	return tags, nil
+}
+
+// ConfirmAIReview marks the AI draft as reviewed by its author
+func (b *BlogRepo) ConfirmAIReview(id primitive.ObjectID, at time.Time) error {
+	update := bson.M{"$set": bson.M{"ai.reviewed": true, "ai.reviewed_at": at, "updated_at": at}}
+	_, err := b.collection.UpdateOne(b.context, bson.M{"_id": id, "ai": bson.M{"$exists": true}}, update)
+	return err
+}
+
+// Feed returns published blogs by the given authors, most recently
+// published first, starting after the cursor when one is given. Blogs
+// published before publish times were recorded count from their creation.
+func (b *BlogRepo) Feed(authorIDs []primitive.ObjectID, after *domain.FeedCursor, limit int) ([]*domain.Blog, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"author_id": bson.M{"$in": authorIDs},
+			"status":    statusQuery(domain.BlogStatusPublished),
+		}}},
+		{{Key: "$addFields", Value: bson.M{"feed_at": bson.M{"$ifNull": bson.A{"$published_at", "$created_at"}}}}},
+	}
+	if after != nil {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.M{"$or": []bson.M{
+			{"feed_at": bson.M{"$lt": after.PublishedAt}},
+			{"feed_at": after.PublishedAt, "_id": bson.M{"$lt": after.ID}},
+		}}}})
+	}
+	pipeline = append(pipeline,
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "feed_at", Value: -1}, {Key: "_id", Value: -1}}}},
+		bson.D{{Key: "$limit", Value: limit}},
+		bson.D{{Key: "$project", Value: bson.M{"feed_at": 0}}},
+	)
+
+	cursor, err := b.collection.Aggregate(b.context, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var blogs []*domain.Blog
+	if err := cursor.All(b.context, &blogs); err != nil {
+		return nil, err
+	}
+	return blogs, nil
+}
+
+// FindPublishedByIDs returns the published blogs among ids, in no
+// particular order
+func (b *BlogRepo) FindPublishedByIDs(ids []primitive.ObjectID) ([]*domain.Blog, error) {
+	query := bson.M{
+		"_id":    bson.M{"$in": ids},
+		"status": statusQuery(domain.BlogStatusPublished),
+	}
+	cursor, err := b.collection.Find(b.context, query)
+	if err != nil {
+		return nil, err
+	}
+	var blogs []*domain.Blog
+	if err := cursor.All(b.context, &blogs); err != nil {
+		return nil, err
+	}
+	return blogs, nil
+}
+
+// statusQuery matches a blog status. Blogs stored before the lifecycle was
+// introduced have no status and count as published.
+func statusQuery(status string) interface{} {
+	if status == domain.BlogStatusPublished {
+		return bson.M{"$in": bson.A{domain.BlogStatusPublished, nil}}
+	}
+	return status
+}
@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type bookmarkRepository struct {
+	blogCollection     *mongo.Collection
+	bookmarkCollection *mongo.Collection
+	listCollection     *mongo.Collection
+	positionCollection *mongo.Collection
+}
+
+// positionCounter hands out bookmark positions for one list of one user
+type positionCounter struct {
+	Next int `bson:"next"`
+}
+
+func NewBookmarkRepository(blogColl, bookmarkColl, listColl, positionColl *mongo.Collection) domain.BookmarkRepository {
+	ctx := context.Background()
+	_, err := bookmarkColl.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "list_id", Value: 1}, {Key: "blog_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Println("creating bookmark index failed:", err)
+	}
+	_, err = positionColl.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "list_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Println("creating bookmark position index failed:", err)
+	}
+	return &bookmarkRepository{
+		blogCollection:     blogColl,
+		bookmarkCollection: bookmarkColl,
+		listCollection:     listColl,
+		positionCollection: positionColl,
+	}
+}
+
+// Add saves the bookmark at the end of its list. Adding a blog that is
+// already in the list leaves the existing bookmark untouched.
+func (r *bookmarkRepository) Add(bookmark *domain.Bookmark) error {
+	ctx := context.Background()
+	key := bson.M{"user_id": bookmark.UserID, "blog_id": bookmark.BlogID, "list_id": bookmark.ListID}
+
+	err := r.bookmarkCollection.FindOne(ctx, key).Decode(bookmark)
+	if err != mongo.ErrNoDocuments {
+		return err
+	}
+	position, err := r.nextPosition(ctx, bookmark.UserID, bookmark.ListID)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{"$setOnInsert": bson.M{"position": position, "created_at": bookmark.CreatedAt}}
+	_, err = r.bookmarkCollection.UpdateOne(ctx, key, update, options.Update().SetUpsert(true))
+	// A duplicate key means a concurrent request saved the same bookmark
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+	return r.bookmarkCollection.FindOne(ctx, key).Decode(bookmark)
+}
+
+// nextPosition takes the next free position at the end of the list. The
+// counter of a list is created on first use, after the bookmarks it
+// already has.
+func (r *bookmarkRepository) nextPosition(ctx context.Context, userID, listID primitive.ObjectID) (int, error) {
+	key := bson.M{"user_id": userID, "list_id": listID}
+	for {
+		var counter positionCounter
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.Before)
+		err := r.positionCollection.FindOneAndUpdate(ctx, key, bson.M{"$inc": bson.M{"next": 1}}, opts).Decode(&counter)
+		if err == nil {
+			return counter.Next, nil
+		}
+		if err != mongo.ErrNoDocuments {
+			return 0, err
+		}
+
+		var last domain.Bookmark
+		findOpts := options.FindOne().SetSort(bson.D{{Key: "position", Value: -1}})
+		err = r.bookmarkCollection.FindOne(ctx, key, findOpts).Decode(&last)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return 0, err
+		}
+		next := 0
+		if err == nil {
+			next = last.Position + 1
+		}
+		// Losing the race to create the counter is fine; the loop uses
+		// the winner's
+		_, err = r.positionCollection.InsertOne(ctx, bson.M{"user_id": userID, "list_id": listID, "next": next})
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return 0, err
+		}
+	}
+}
+
+func (r *bookmarkRepository) Remove(userID, blogID, listID primitive.ObjectID) error {
+	res, err := r.bookmarkCollection.DeleteOne(
+		context.Background(),
+		bson.M{"user_id": userID, "blog_id": blogID, "list_id": listID},
+	)
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// List returns a page of bookmarks in list order with their blogs attached.
+// Bookmarks whose blog no longer exists or is no longer visible to the user
+// are skipped.
+func (r *bookmarkRepository) List(userID, listID primitive.ObjectID, page, limit int) ([]*domain.Bookmark, int64, error) {
+	ctx := context.Background()
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userID, "list_id": listID}}},
+		{{Key: "$sort", Value: bson.D{{Key: "position", Value: 1}, {Key: "created_at", Value: 1}}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         r.blogCollection.Name(),
+			"localField":   "blog_id",
+			"foreignField": "_id",
+			"as":           "blog",
+		}}},
+		{{Key: "$unwind", Value: "$blog"}},
+		{{Key: "$match", Value: bson.M{"$or": []bson.M{
+			{"blog.status": bson.M{"$in": bson.A{domain.BlogStatusPublished, nil}}},
+			{"blog.author_id": userID},
+		}}}},
+		{{Key: "$facet", Value: bson.M{
+			"data":  bson.A{bson.M{"$skip": int64((page - 1) * limit)}, bson.M{"$limit": int64(limit)}},
+			"total": bson.A{bson.M{"$count": "count"}},
+		}}},
+	}
+
+	cursor, err := r.bookmarkCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var result []struct {
+		Data  []*domain.Bookmark `bson:"data"`
+		Total []struct {
+			Count int64 `bson:"count"`
+		} `bson:"total"`
+	}
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, 0, err
+	}
+	if len(result) == 0 {
+		return []*domain.Bookmark{}, 0, nil
+	}
+
+	var total int64
+	if len(result[0].Total) > 0 {
+		total = result[0].Total[0].Count
+	}
+	return result[0].Data, total, nil
+}
+
+// Reorder puts the given blogs first, in the given order, followed by the
+// rest of the list in its current order
+func (r *bookmarkRepository) Reorder(userID, listID primitive.ObjectID, blogIDs []primitive.ObjectID) error {
+	ctx := context.Background()
+	filter := bson.M{"user_id": userID, "list_id": listID}
+	opts := options.Find().SetSort(bson.D{{Key: "position", Value: 1}, {Key: "created_at", Value: 1}})
+
+	cursor, err := r.bookmarkCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return err
+	}
+	var current []domain.Bookmark
+	if err := cursor.All(ctx, &current); err != nil {
+		return err
+	}
+
+	currentIDs := make([]primitive.ObjectID, len(current))
+	for i, bookmark := range current {
+		currentIDs[i] = bookmark.BlogID
+	}
+	order := domain.BookmarkOrder(currentIDs, blogIDs)
+	if len(order) == 0 {
+		return nil
+	}
+
+	models := make([]mongo.WriteModel, 0, len(order))
+	for position, blogID := range order {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"user_id": userID, "list_id": listID, "blog_id": blogID}).
+			SetUpdate(bson.M{"$set": bson.M{"position": position}}))
+	}
+	_, err = r.bookmarkCollection.BulkWrite(ctx, models)
+	return err
+}
+
+// DeleteByBlog removes the blog from every user's bookmarks
+func (r *bookmarkRepository) DeleteByBlog(blogID primitive.ObjectID) error {
+	_, err := r.bookmarkCollection.DeleteMany(context.Background(), bson.M{"blog_id": blogID})
+	return err
+}
+
+func (r *bookmarkRepository) CreateList(list *domain.ReadingList) error {
+	result, err := r.listCollection.InsertOne(context.Background(), list)
+	if err != nil {
+		return err
+	}
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		list.ID = oid
+	}
+	return nil
+}
+
+func (r *bookmarkRepository) GetList(id primitive.ObjectID) (*domain.ReadingList, error) {
+	var list domain.ReadingList
+	if err := r.listCollection.FindOne(context.Background(), bson.M{"_id": id}).Decode(&list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+func (r *bookmarkRepository) ListLists(userID primitive.ObjectID) ([]*domain.ReadingList, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.listCollection.Find(context.Background(), bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var lists []*domain.ReadingList
+	if err := cursor.All(context.Background(), &lists); err != nil {
+		return nil, err
+	}
+	return lists, nil
+}
+
+func (r *bookmarkRepository) RenameList(id primitive.ObjectID, name string) error {
+	_, err := r.listCollection.UpdateOne(
+		context.Background(),
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"name": name, "updated_at": time.Now()}},
+	)
+	return err
+}
+
+// DeleteList removes the reading list together with its bookmarks
+func (r *bookmarkRepository) DeleteList(id primitive.ObjectID) error {
+	if _, err := r.bookmarkCollection.DeleteMany(context.Background(), bson.M{"list_id": id}); err != nil {
+		return err
+	}
+	if _, err := r.positionCollection.DeleteMany(context.Background(), bson.M{"list_id": id}); err != nil {
+		return err
+	}
+	_, err := r.listCollection.DeleteOne(context.Background(), bson.M{"_id": id})
+	return err
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/sol-tad/Blog-post-Api/domain"
 	"go.mongodb.org/mongo-driver/bson"
@@ -12,18 +13,30 @@ import (
 )
 
 type commentRepository struct {
-	collection *mongo.Collection
+	blogCollection *mongo.Collection
+	collection     *mongo.Collection
 }
 
-func NewCommentRepository(coll *mongo.Collection) domain.CommentRepository {
+func NewCommentRepository(blogColl, coll *mongo.Collection) domain.CommentRepository {
 	return &commentRepository{
-		collection: coll,
+		blogCollection: blogColl,
+		collection:     coll,
 	}
 }
 
+// approvedQuery matches approved comments, including those stored before
+// moderation existed
+var approvedQuery = bson.M{"$in": bson.A{domain.CommentStatusApproved, nil}}
+
 func (r *commentRepository) Create(comment *domain.Comment) error {
-	_, err := r.collection.InsertOne(context.Background(), comment)
-	return err
+	result, err := r.collection.InsertOne(context.Background(), comment)
+	if err != nil {
+		return err
+	}
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		comment.ID = oid
+	}
+	return nil
 }
 
 func (r *commentRepository) GetByID(id string) (*domain.Comment, error) {
@@ -50,7 +63,7 @@ func (r *commentRepository) GetByBlog(blogID string, page, limit int) ([]*domain
 	
 	cursor, err := r.collection.Find(
 		context.Background(),
-		bson.M{"blog_id": objID},
+		bson.M{"blog_id": objID, "status": approvedQuery},
 		opts,
 	)
 	if err != nil {
@@ -86,6 +99,85 @@ func (r *commentRepository) Delete(id string) error {
 }
 
 
+// GetThread returns every comment of a blog up to maxDepth, oldest first
+func (r *commentRepository) GetThread(blogID string, maxDepth int) ([]*domain.Comment, error) {
+	objID, err := primitive.ObjectIDFromHex(blogID)
+	if err != nil {
+		return nil, err
+	}
+
+	// comments stored before threading have no depth and are top-level
+	filter := bson.M{
+		"blog_id": objID,
+		"depth":   bson.M{"$not": bson.M{"$gt": maxDepth}},
+		"status":  approvedQuery,
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	cursor, err := r.collection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var comments []*domain.Comment
+	if err = cursor.All(context.Background(), &comments); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// CountReplies counts the direct replies to a comment
+func (r *commentRepository) CountReplies(id string) (int64, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return 0, err
+	}
+	filter := bson.M{"parent_id": objID, "status": bson.M{"$ne": domain.CommentStatusRejected}}
+	return r.collection.CountDocuments(context.Background(), filter)
+}
+
+// GetByStatus lists a blog's comments in the given moderation status, oldest first
+func (r *commentRepository) GetByStatus(blogID string, status string, page, limit int) ([]*domain.Comment, error) {
+	objID, err := primitive.ObjectIDFromHex(blogID)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(context.Background(), bson.M{"blog_id": objID, "status": status}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var comments []*domain.Comment
+	if err = cursor.All(context.Background(), &comments); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+func (r *commentRepository) SetStatus(id string, from, to string) (bool, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, err
+	}
+
+	res, err := r.collection.UpdateOne(
+		context.Background(),
+		bson.M{"_id": objID, "status": from},
+		bson.M{"$set": bson.M{"status": to, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
 
 // IncrementCommentCount increases the comment count for a blog
 func (r *commentRepository) IncrementCommentCount(blogID string) error {
@@ -94,7 +186,7 @@ func (r *commentRepository) IncrementCommentCount(blogID string) error {
 		return errors.New("invalid blog ID")
 	}
 	
-	_, err = r.collection.UpdateOne(
+	_, err = r.blogCollection.UpdateOne(
 		context.Background(),
 		bson.M{"_id": objID},
 		bson.M{"$inc": bson.M{"stats.comments": 1}},
@@ -109,7 +201,7 @@ func (r *commentRepository) DecrementCommentCount(blogID string) error {
 		return errors.New("invalid blog ID")
 	}
 	
-	_, err = r.collection.UpdateOne(
+	_, err = r.blogCollection.UpdateOne(
 		context.Background(),
 		bson.M{"_id": objID},
 		bson.M{"$inc": bson.M{"stats.comments": -1}},
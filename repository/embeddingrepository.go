@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type embeddingRepository struct {
+	collection *mongo.Collection
+}
+
+func NewEmbeddingRepository(coll *mongo.Collection) domain.BlogEmbeddingRepository {
+	return &embeddingRepository{
+		collection: coll,
+	}
+}
+
+func (r *embeddingRepository) Save(embedding *domain.BlogEmbedding) error {
+	_, err := r.collection.ReplaceOne(
+		context.Background(),
+		bson.M{"_id": embedding.BlogID},
+		embedding,
+		options.Replace().SetUpsert(true),
+	)
+	return err
+}
+
+func (r *embeddingRepository) Get(blogID primitive.ObjectID) (*domain.BlogEmbedding, error) {
+	var embedding domain.BlogEmbedding
+	if err := r.collection.FindOne(context.Background(), bson.M{"_id": blogID}).Decode(&embedding); err != nil {
+		return nil, err
+	}
+	return &embedding, nil
+}
+
+// ListByModel returns a page of the embeddings made by the model. Ranking
+// goes through them page by page, so a query never holds every vector.
+func (r *embeddingRepository) ListByModel(model string, after primitive.ObjectID, limit int) ([]*domain.BlogEmbedding, error) {
+	ctx := context.Background()
+	filter := bson.M{"model": model, "_id": bson.M{"$gt": after}}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	embeddings := []*domain.BlogEmbedding{}
+	if err := cursor.All(ctx, &embeddings); err != nil {
+		return nil, err
+	}
+	return embeddings, nil
+}
+
+func (r *embeddingRepository) Delete(blogID primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(context.Background(), bson.M{"_id": blogID})
+	return err
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type followRepository struct {
+	collection *mongo.Collection
+}
+
+func NewFollowRepository(coll *mongo.Collection) domain.FollowRepository {
+	_, err := coll.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "follower_id", Value: 1}, {Key: "followee_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Println("creating follow index failed:", err)
+	}
+	return &followRepository{
+		collection: coll,
+	}
+}
+
+// Follow records the follow and reports whether it is new
+func (r *followRepository) Follow(followerID, followeeID primitive.ObjectID) (bool, error) {
+	res, err := r.collection.UpdateOne(
+		context.Background(),
+		bson.M{"follower_id": followerID, "followee_id": followeeID},
+		bson.M{"$setOnInsert": bson.M{"created_at": time.Now()}},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		// a concurrent request recorded the same follow first
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return res.UpsertedCount > 0, nil
+}
+
+// Unfollow removes the follow and reports whether there was one
+func (r *followRepository) Unfollow(followerID, followeeID primitive.ObjectID) (bool, error) {
+	res, err := r.collection.DeleteOne(
+		context.Background(),
+		bson.M{"follower_id": followerID, "followee_id": followeeID},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
+}
+
+func (r *followRepository) IsFollowing(followerID, followeeID primitive.ObjectID) (bool, error) {
+	count, err := r.collection.CountDocuments(
+		context.Background(),
+		bson.M{"follower_id": followerID, "followee_id": followeeID},
+		options.Count().SetLimit(1),
+	)
+	return count > 0, err
+}
+
+// FollowingIDs returns every user the follower follows
+func (r *followRepository) FollowingIDs(followerID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	return r.ids(bson.M{"follower_id": followerID}, "followee_id", options.Find())
+}
+
+func (r *followRepository) Followers(followeeID primitive.ObjectID, page, limit int) ([]primitive.ObjectID, error) {
+	return r.ids(bson.M{"followee_id": followeeID}, "follower_id", pageOptions(page, limit))
+}
+
+func (r *followRepository) Following(followerID primitive.ObjectID, page, limit int) ([]primitive.ObjectID, error) {
+	return r.ids(bson.M{"follower_id": followerID}, "followee_id", pageOptions(page, limit))
+}
+
+func (r *followRepository) CountFollowers(userID primitive.ObjectID) (int64, error) {
+	return r.collection.CountDocuments(context.Background(), bson.M{"followee_id": userID})
+}
+
+func (r *followRepository) CountFollowing(userID primitive.ObjectID) (int64, error) {
+	return r.collection.CountDocuments(context.Background(), bson.M{"follower_id": userID})
+}
+
+// ids collects one user ID field from the matching follows
+func (r *followRepository) ids(filter bson.M, field string, opts *options.FindOptions) ([]primitive.ObjectID, error) {
+	opts.SetProjection(bson.M{field: 1})
+	cursor, err := r.collection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var follows []domain.Follow
+	if err := cursor.All(context.Background(), &follows); err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(follows))
+	for _, follow := range follows {
+		if field == "follower_id" {
+			ids = append(ids, follow.FollowerID)
+		} else {
+			ids = append(ids, follow.FolloweeID)
+		}
+	}
+	return ids, nil
+}
+
+// pageOptions lists newest follows first
+func pageOptions(page, limit int) *options.FindOptions {
+	return options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+}
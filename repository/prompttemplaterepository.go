@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type promptTemplateRepository struct {
+	collection *mongo.Collection
+}
+
+func NewPromptTemplateRepository(coll *mongo.Collection) domain.PromptTemplateRepository {
+	return &promptTemplateRepository{
+		collection: coll,
+	}
+}
+
+func (r *promptTemplateRepository) Create(template *domain.PromptTemplate) error {
+	result, err := r.collection.InsertOne(context.Background(), template)
+	if err != nil {
+		return err
+	}
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		template.ID = oid
+	}
+	return nil
+}
+
+func (r *promptTemplateRepository) Latest(name string) (*domain.PromptTemplate, error) {
+	var template domain.PromptTemplate
+	opts := options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}})
+	if err := r.collection.FindOne(context.Background(), bson.M{"name": name}, opts).Decode(&template); err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (r *promptTemplateRepository) GetVersion(name string, version int) (*domain.PromptTemplate, error) {
+	var template domain.PromptTemplate
+	err := r.collection.FindOne(context.Background(), bson.M{"name": name, "version": version}).Decode(&template)
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// ListLatest returns the latest version of every template, by name
+func (r *promptTemplateRepository) ListLatest() ([]*domain.PromptTemplate, error) {
+	ctx := context.Background()
+	pipeline := mongo.Pipeline{
+		{{Key: "$sort", Value: bson.D{{Key: "name", Value: 1}, {Key: "version", Value: -1}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$name", "latest": bson.M{"$first": "$$ROOT"}}}},
+		{{Key: "$replaceRoot", Value: bson.M{"newRoot": "$latest"}}},
+		{{Key: "$sort", Value: bson.D{{Key: "name", Value: 1}}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	templates := []*domain.PromptTemplate{}
+	if err := cursor.All(ctx, &templates); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// Versions returns every version of the template, newest first
+func (r *promptTemplateRepository) Versions(name string) ([]*domain.PromptTemplate, error) {
+	ctx := context.Background()
+	opts := options.Find().SetSort(bson.D{{Key: "version", Value: -1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"name": name}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	templates := []*domain.PromptTemplate{}
+	if err := cursor.All(ctx, &templates); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
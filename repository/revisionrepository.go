@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"log"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type revisionRepository struct {
+	collection *mongo.Collection
+}
+
+// maxRevisionAttempts bounds how often Create picks a new revision number
+// after another save of the same blog took it
+const maxRevisionAttempts = 5
+
+func NewRevisionRepository(coll *mongo.Collection) domain.BlogRevisionRepository {
+	// Revision numbers are read before they are written; the index turns
+	// away the second of two saves that picked the same number
+	_, err := coll.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "blog_id", Value: 1}, {Key: "revision", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Println("creating revision number index failed:", err)
+	}
+	return &revisionRepository{
+		collection: coll,
+	}
+}
+
+// Create stores the revision with the next revision number for its blog
+func (r *revisionRepository) Create(revision *domain.BlogRevision) error {
+	for attempt := 1; ; attempt++ {
+		var latest domain.BlogRevision
+		opts := options.FindOne().SetSort(bson.D{{Key: "revision", Value: -1}})
+		err := r.collection.FindOne(context.Background(), bson.M{"blog_id": revision.BlogID}, opts).Decode(&latest)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return err
+		}
+		revision.Revision = latest.Revision + 1
+
+		result, err := r.collection.InsertOne(context.Background(), revision)
+		if mongo.IsDuplicateKeyError(err) && attempt < maxRevisionAttempts {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+			revision.ID = oid
+		}
+		return nil
+	}
+}
+
+func (r *revisionRepository) ListByBlog(blogID primitive.ObjectID, page, limit int) ([]*domain.BlogRevision, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "revision", Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(context.Background(), bson.M{"blog_id": blogID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var revisions []*domain.BlogRevision
+	if err = cursor.All(context.Background(), &revisions); err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (r *revisionRepository) GetByNumber(blogID primitive.ObjectID, revision int) (*domain.BlogRevision, error) {
+	var result domain.BlogRevision
+	err := r.collection.FindOne(
+		context.Background(),
+		bson.M{"blog_id": blogID, "revision": revision},
+	).Decode(&result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type roleChangeRepository struct {
+	collection *mongo.Collection
+}
+
+// NewRoleChangeRepository stores the role change audit trail. Entries are
+// only removed when the change they record failed.
+func NewRoleChangeRepository(coll *mongo.Collection) domain.RoleChangeRepository {
+	return &roleChangeRepository{
+		collection: coll,
+	}
+}
+
+func (r *roleChangeRepository) Create(ctx context.Context, change *domain.RoleChange) error {
+	if change.ID.IsZero() {
+		change.ID = primitive.NewObjectID()
+	}
+	_, err := r.collection.InsertOne(ctx, change)
+	return err
+}
+
+func (r *roleChangeRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (r *roleChangeRepository) List(ctx context.Context, userID primitive.ObjectID, page, limit int) ([]*domain.RoleChange, int64, error) {
+	filter := bson.M{}
+	if !userID.IsZero() {
+		filter["user_id"] = userID
+	}
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	changes := []*domain.RoleChange{}
+	if err := cursor.All(ctx, &changes); err != nil {
+		return nil, 0, err
+	}
+	return changes, total, nil
+}
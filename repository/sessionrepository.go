@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type sessionRepository struct {
+	collection *mongo.Collection
+}
+
+// NewSessionRepository stores sessions in Mongo. A TTL index on expires_at
+// lets Mongo drop sessions once their refresh tokens expired.
+func NewSessionRepository(coll *mongo.Collection) domain.SessionRepository {
+	_, err := coll.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		log.Println("creating session TTL index failed:", err)
+	}
+	return &sessionRepository{
+		collection: coll,
+	}
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *domain.Session) error {
+	if session.ID.IsZero() {
+		session.ID = primitive.NewObjectID()
+	}
+	_, err := r.collection.InsertOne(ctx, session)
+	return err
+}
+
+func (r *sessionRepository) Get(ctx context.Context, id primitive.ObjectID) (*domain.Session, error) {
+	var session domain.Session
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Rotate compares and swaps the token hash in one update, so two refreshes
+// racing with the same token cannot both succeed
+func (r *sessionRepository) Rotate(ctx context.Context, id primitive.ObjectID, oldHash, newHash string, client domain.SessionClient, usedAt, expiresAt time.Time) (bool, error) {
+	filter := bson.M{
+		"_id":        id,
+		"token_hash": oldHash,
+		"revoked_at": bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": usedAt},
+	}
+	update := bson.M{"$set": bson.M{
+		"token_hash":   newHash,
+		"user_agent":   client.UserAgent,
+		"ip":           client.IP,
+		"last_used_at": usedAt,
+		"expires_at":   expiresAt,
+	}}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func (r *sessionRepository) ListActive(ctx context.Context, userID primitive.ObjectID, now time.Time) ([]*domain.Session, error) {
+	filter := bson.M{
+		"user_id":    userID,
+		"revoked_at": bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": now},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "last_used_at", Value: -1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	sessions := []*domain.Session{}
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// Revoke keeps the first revocation of a session and its reason
+func (r *sessionRepository) Revoke(ctx context.Context, id primitive.ObjectID, reason string, at time.Time) error {
+	filter := bson.M{"_id": id, "revoked_at": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"revoked_at": at, "revoked_reason": reason}}
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+func (r *sessionRepository) RevokeAllForUser(ctx context.Context, userID primitive.ObjectID, reason string, at time.Time) error {
+	filter := bson.M{"user_id": userID, "revoked_at": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"revoked_at": at, "revoked_reason": reason}}
+	_, err := r.collection.UpdateMany(ctx, filter, update)
+	return err
+}
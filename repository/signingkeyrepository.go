@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type signingKeyRepository struct {
+	collection *mongo.Collection
+}
+
+// NewSigningKeyRepository stores signing keys in Mongo. A TTL index on
+// expires_at deletes keys, private halves included, once they expired.
+func NewSigningKeyRepository(coll *mongo.Collection) domain.SigningKeyRepository {
+	_, err := coll.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		log.Println("creating signing key TTL index failed:", err)
+	}
+	// Instances starting at the same time on an empty collection race to
+	// create the first key; only one may win
+	_, err = coll.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.D{{Key: "bootstrap", Value: 1}},
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"bootstrap": true}),
+	})
+	if err != nil {
+		log.Println("creating signing key bootstrap index failed:", err)
+	}
+	return &signingKeyRepository{
+		collection: coll,
+	}
+}
+
+func (r *signingKeyRepository) Create(ctx context.Context, key *domain.SigningKey) error {
+	_, err := r.collection.InsertOne(ctx, key)
+	return err
+}
+
+func (r *signingKeyRepository) CreateBootstrap(ctx context.Context, key *domain.SigningKey) (bool, error) {
+	key.Bootstrap = true
+	_, err := r.collection.InsertOne(ctx, key)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *signingKeyRepository) List(ctx context.Context, now time.Time) ([]*domain.SigningKey, error) {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"expires_at": bson.M{"$exists": false}},
+		bson.M{"expires_at": bson.M{"$gt": now}},
+	}}
+	opts := options.Find().SetSort(bson.D{{Key: "not_before", Value: -1}, {Key: "created_at", Value: -1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	keys := []*domain.SigningKey{}
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (r *signingKeyRepository) Retire(ctx context.Context, id string, retireAt, expiresAt time.Time) error {
+	filter := bson.M{"_id": id, "retired_at": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"retired_at": retireAt, "expires_at": expiresAt}}
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+func (r *signingKeyRepository) ReplacePrivateKey(ctx context.Context, id, old, replacement string) error {
+	filter := bson.M{"_id": id, "private_key": old}
+	update := bson.M{"$set": bson.M{"private_key": replacement}}
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	return err
+}
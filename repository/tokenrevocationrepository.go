@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type tokenRevocationRepository struct {
+	collection *mongo.Collection
+}
+
+// NewTokenRevocationRepository stores revocations in Mongo. A TTL index on
+// expires_at lets Mongo drop entries once the tokens they cover expired.
+func NewTokenRevocationRepository(coll *mongo.Collection) domain.TokenRevocationRepository {
+	_, err := coll.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		log.Println("creating revoked token TTL index failed:", err)
+	}
+	return &tokenRevocationRepository{
+		collection: coll,
+	}
+}
+
+// Revoke only ever moves an entry later, so a second revocation cannot
+// shorten the first
+func (r *tokenRevocationRepository) Revoke(ctx context.Context, key string, at, expiresAt time.Time) error {
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": key},
+		bson.M{"$max": bson.M{"revoked_at": at, "expires_at": expiresAt}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (r *tokenRevocationRepository) IsRevoked(ctx context.Context, issuedAt time.Time, keys ...string) (bool, error) {
+	filter := bson.M{
+		"_id":        bson.M{"$in": keys},
+		"revoked_at": bson.M{"$gte": issuedAt},
+	}
+	count, err := r.collection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+type revocation struct {
+	revokedAt time.Time
+	expiresAt time.Time
+}
+
+type memoryTokenRevocationRepository struct {
+	mu          sync.Mutex
+	revocations map[string]revocation
+}
+
+// NewMemoryTokenRevocationRepository keeps revocations in memory, for tests
+// and single instance setups. Revocations are lost on restart.
+func NewMemoryTokenRevocationRepository() domain.TokenRevocationRepository {
+	return &memoryTokenRevocationRepository{
+		revocations: map[string]revocation{},
+	}
+}
+
+func (r *memoryTokenRevocationRepository) Revoke(ctx context.Context, key string, at, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.prune(time.Now())
+	existing := r.revocations[key]
+	if at.After(existing.revokedAt) {
+		existing.revokedAt = at
+	}
+	if expiresAt.After(existing.expiresAt) {
+		existing.expiresAt = expiresAt
+	}
+	r.revocations[key] = existing
+	return nil
+}
+
+func (r *memoryTokenRevocationRepository) IsRevoked(ctx context.Context, issuedAt time.Time, keys ...string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, key := range keys {
+		entry, ok := r.revocations[key]
+		if ok && now.Before(entry.expiresAt) && !issuedAt.After(entry.revokedAt) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// prune drops expired entries, the way the TTL index does in Mongo
+func (r *memoryTokenRevocationRepository) prune(now time.Time) {
+	for key, entry := range r.revocations {
+		if !now.Before(entry.expiresAt) {
+			delete(r.revocations, key)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+)
+
+func TestMemoryTokenRevocationRepository(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	user := domain.UserRevocationKey("user-1")
+	session := domain.SessionRevocationKey("session-1")
+
+	tests := []struct {
+		name     string
+		revoke   func(r domain.TokenRevocationRepository)
+		issuedAt time.Time
+		keys     []string
+		want     bool
+	}{
+		{
+			name:     "nothing revoked",
+			revoke:   func(r domain.TokenRevocationRepository) {},
+			issuedAt: now,
+			keys:     []string{user},
+			want:     false,
+		},
+		{
+			name:     "token issued before the revocation",
+			revoke:   func(r domain.TokenRevocationRepository) { r.Revoke(ctx, user, now, now.Add(time.Hour)) },
+			issuedAt: now.Add(-time.Minute),
+			keys:     []string{user},
+			want:     true,
+		},
+		{
+			name:     "token issued at the revocation",
+			revoke:   func(r domain.TokenRevocationRepository) { r.Revoke(ctx, user, now, now.Add(time.Hour)) },
+			issuedAt: now,
+			keys:     []string{user},
+			want:     true,
+		},
+		{
+			name:     "token issued after the revocation",
+			revoke:   func(r domain.TokenRevocationRepository) { r.Revoke(ctx, user, now, now.Add(time.Hour)) },
+			issuedAt: now.Add(time.Minute),
+			keys:     []string{user},
+			want:     false,
+		},
+		{
+			name:     "revoked under another of its keys",
+			revoke:   func(r domain.TokenRevocationRepository) { r.Revoke(ctx, session, now, now.Add(time.Hour)) },
+			issuedAt: now.Add(-time.Minute),
+			keys:     []string{user, session},
+			want:     true,
+		},
+		{
+			name:     "revoked under an unrelated key",
+			revoke:   func(r domain.TokenRevocationRepository) { r.Revoke(ctx, session, now, now.Add(time.Hour)) },
+			issuedAt: now.Add(-time.Minute),
+			keys:     []string{user},
+			want:     false,
+		},
+		{
+			name:     "revocation expired",
+			revoke:   func(r domain.TokenRevocationRepository) { r.Revoke(ctx, user, now, now.Add(-time.Second)) },
+			issuedAt: now.Add(-time.Minute),
+			keys:     []string{user},
+			want:     false,
+		},
+		{
+			name: "an earlier revocation does not move the cut off back",
+			revoke: func(r domain.TokenRevocationRepository) {
+				r.Revoke(ctx, user, now, now.Add(time.Hour))
+				r.Revoke(ctx, user, now.Add(-time.Hour), now.Add(time.Minute))
+			},
+			issuedAt: now.Add(-time.Minute),
+			keys:     []string{user},
+			want:     true,
+		},
+		{
+			name: "an earlier expiry does not shorten the revocation",
+			revoke: func(r domain.TokenRevocationRepository) {
+				r.Revoke(ctx, user, now, now.Add(time.Hour))
+				r.Revoke(ctx, user, now, now.Add(-time.Second))
+			},
+			issuedAt: now.Add(-time.Minute),
+			keys:     []string{user},
+			want:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewMemoryTokenRevocationRepository()
+			tt.revoke(repo)
+
+			got, err := repo.IsRevoked(ctx, tt.issuedAt, tt.keys...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("IsRevoked = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryTokenRevocationRepositoryPrunesExpiredEntries(t *testing.T) {
+	repo := NewMemoryTokenRevocationRepository().(*memoryTokenRevocationRepository)
+	now := time.Now()
+	repo.Revoke(context.Background(), "expired", now, now.Add(-time.Second))
+	repo.Revoke(context.Background(), "live", now, now.Add(time.Hour))
+
+	if _, ok := repo.revocations["expired"]; ok {
+		t.Error("expired revocation was kept")
+	}
+	if _, ok := repo.revocations["live"]; !ok {
+		t.Error("live revocation was dropped")
+	}
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type translationRepository struct {
+	collection *mongo.Collection
+}
+
+func NewTranslationRepository(coll *mongo.Collection) domain.BlogTranslationRepository {
+	return &translationRepository{
+		collection: coll,
+	}
+}
+
+// Save replaces the translation of the blog into the same language, if
+// any, keeping its ID and creation time
+func (r *translationRepository) Save(translation *domain.BlogTranslation) error {
+	ctx := context.Background()
+	filter := bson.M{"blog_id": translation.BlogID, "language": translation.Language}
+
+	var existing domain.BlogTranslation
+	err := r.collection.FindOne(ctx, filter).Decode(&existing)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+	if err == nil {
+		translation.ID = existing.ID
+		translation.CreatedAt = existing.CreatedAt
+	}
+
+	result, err := r.collection.ReplaceOne(ctx, filter, translation, options.Replace().SetUpsert(true))
+	if err != nil {
+		return err
+	}
+	if oid, ok := result.UpsertedID.(primitive.ObjectID); ok {
+		translation.ID = oid
+	}
+	return nil
+}
+
+func (r *translationRepository) Get(blogID primitive.ObjectID, language string) (*domain.BlogTranslation, error) {
+	var translation domain.BlogTranslation
+	err := r.collection.FindOne(context.Background(), bson.M{"blog_id": blogID, "language": language}).Decode(&translation)
+	if err != nil {
+		return nil, err
+	}
+	return &translation, nil
+}
+
+func (r *translationRepository) ListByBlog(blogID primitive.ObjectID) ([]*domain.BlogTranslation, error) {
+	ctx := context.Background()
+	opts := options.Find().SetSort(bson.D{{Key: "language", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"blog_id": blogID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	translations := []*domain.BlogTranslation{}
+	if err := cursor.All(ctx, &translations); err != nil {
+		return nil, err
+	}
+	return translations, nil
+}
+
+func (r *translationRepository) DeleteByBlog(blogID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(context.Background(), bson.M{"blog_id": blogID})
+	return err
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type twoFactorRepository struct {
+	collection *mongo.Collection
+}
+
+// NewTwoFactorRepository stores one document per enrolled user, keyed by
+// the user's ID
+func NewTwoFactorRepository(coll *mongo.Collection) domain.TwoFactorRepository {
+	return &twoFactorRepository{
+		collection: coll,
+	}
+}
+
+func (r *twoFactorRepository) Get(ctx context.Context, userID primitive.ObjectID) (*domain.TwoFactor, error) {
+	var twoFactor domain.TwoFactor
+	if err := r.collection.FindOne(ctx, bson.M{"_id": userID}).Decode(&twoFactor); err != nil {
+		return nil, err
+	}
+	return &twoFactor, nil
+}
+
+// StartEnrollment upserts over a pending enrollment only; an enabled one
+// does not match the filter, so the upsert collides with it on _id
+func (r *twoFactorRepository) StartEnrollment(ctx context.Context, enrollment *domain.TwoFactor) (bool, error) {
+	filter := bson.M{"_id": enrollment.UserID, "enabled": bson.M{"$ne": true}}
+	_, err := r.collection.ReplaceOne(ctx, filter, enrollment, options.Replace().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *twoFactorRepository) Enable(ctx context.Context, userID primitive.ObjectID, recoveryCodes []string, at time.Time) error {
+	update := bson.M{"$set": bson.M{
+		"enabled":         true,
+		"enabled_at":      at,
+		"recovery_codes":  recoveryCodes,
+		"failed_attempts": 0,
+	}}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": userID, "enabled": false}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *twoFactorRepository) Delete(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": userID})
+	return err
+}
+
+func (r *twoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userID primitive.ObjectID, recoveryCodes []string) error {
+	update := bson.M{"$set": bson.M{"recovery_codes": recoveryCodes}}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": userID, "enabled": true}, update)
+	return err
+}
+
+// UseStep is a compare and set on last_used_step, so the same code sent
+// twice at once is only accepted once
+func (r *twoFactorRepository) UseStep(ctx context.Context, userID primitive.ObjectID, step int64) (bool, error) {
+	filter := bson.M{"_id": userID, "last_used_step": bson.M{"$lt": step}}
+	update := bson.M{"$set": bson.M{"last_used_step": step}}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func (r *twoFactorRepository) UseRecoveryCode(ctx context.Context, userID primitive.ObjectID, hash string) (bool, error) {
+	filter := bson.M{"_id": userID, "recovery_codes": hash}
+	update := bson.M{"$pull": bson.M{"recovery_codes": hash}}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func (r *twoFactorRepository) RecordFailure(ctx context.Context, userID primitive.ObjectID) (int, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var twoFactor domain.TwoFactor
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": userID}, bson.M{"$inc": bson.M{"failed_attempts": 1}}, opts).Decode(&twoFactor)
+	if err != nil {
+		return 0, err
+	}
+	return twoFactor.FailedAttempts, nil
+}
+
+func (r *twoFactorRepository) Lock(ctx context.Context, userID primitive.ObjectID, until time.Time) error {
+	update := bson.M{"$set": bson.M{"locked_until": until, "failed_attempts": 0}}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": userID}, update)
+	return err
+}
+
+func (r *twoFactorRepository) ResetFailures(ctx context.Context, userID primitive.ObjectID) error {
+	update := bson.M{
+		"$set":   bson.M{"failed_attempts": 0},
+		"$unset": bson.M{"locked_until": ""},
+	}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": userID, "failed_attempts": bson.M{"$gt": 0}}, update)
+	return err
+}
+
+const twoFactorPolicyID = "two_factor_policy"
+
+type twoFactorPolicyRepository struct {
+	collection *mongo.Collection
+}
+
+// NewTwoFactorPolicyRepository keeps the policy as a single document in the
+// settings collection
+func NewTwoFactorPolicyRepository(coll *mongo.Collection) domain.TwoFactorPolicyRepository {
+	return &twoFactorPolicyRepository{
+		collection: coll,
+	}
+}
+
+func (r *twoFactorPolicyRepository) Get(ctx context.Context) (*domain.TwoFactorPolicy, error) {
+	var policy domain.TwoFactorPolicy
+	err := r.collection.FindOne(ctx, bson.M{"_id": twoFactorPolicyID}).Decode(&policy)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return &domain.TwoFactorPolicy{RequiredRoles: []string{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (r *twoFactorPolicyRepository) Save(ctx context.Context, policy *domain.TwoFactorPolicy) error {
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": twoFactorPolicyID},
+		bson.M{"$set": policy},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
@@ -3,7 +3,6 @@ package repository
 import (
 	"context"
 	"errors"
-	"log"
 
 	"github.com/sol-tad/Blog-post-Api/domain"
 	"go.mongodb.org/mongo-driver/bson"
@@ -56,57 +55,6 @@ func (ur *UserRepositoryImpl) Login(ctx context.Context,username string)(domain.
     return user, nil
 }
 
-func (ur *UserRepositoryImpl) SaveRefreshToken(ctx context.Context, userID string, refreshToken string) error {
-	objID, err := primitive.ObjectIDFromHex(userID)
-	if err != nil {
-		return errors.New("invalid user ID format")
-	}
-
-	filter := bson.M{"_id": objID}
-	update := bson.M{"$set": bson.M{"refresh_token": refreshToken}}
-
-	_, err = ur.collection.UpdateOne(ctx, filter, update)
-	if err != nil {
-		return errors.New("failed to save refresh token")
-	}
-	return nil
-}
-
-func (ur *UserRepositoryImpl) VerifyRefreshToken(ctx context.Context, userID string, refreshToken string) (bool, error) {
-	log.Println("idddd----------->:", userID)
-    objID, err := primitive.ObjectIDFromHex(userID)
-    if err != nil {
-        return false, errors.New("invalid user ID format")
-    }
-
-    var user domain.User
-    err = ur.collection.FindOne(ctx, bson.M{
-        "_id":           objID,
-        "refresh_token":  refreshToken, // match the field name you used in SaveRefreshToken
-    }).Decode(&user)
-
-    if err != nil {
-        return false, err
-    }
-
-    return true, nil
-}
-
-
-func (ur *UserRepositoryImpl) DeleteRefreshToken(ctx context.Context, userID string) error {
-    objID, err := primitive.ObjectIDFromHex(userID)
-    if err != nil {
-        return errors.New("invalid user ID")
-    }
-
-    filter := bson.M{"_id": objID}
-    update := bson.M{"$unset": bson.M{"refresh_token": ""}} 
-
-    _, err = ur.collection.UpdateOne(ctx, filter, update)
-    return err
-}
-
-
 func (ur *UserRepositoryImpl) FindByID(ctx context.Context, userID string) (domain.User, error) {
 	var user domain.User
 
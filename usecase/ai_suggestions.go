@@ -0,0 +1,121 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"github.com/sol-tad/Blog-post-Api/infrastructure"
+)
+
+const (
+	maxTagSuggestions   = 5
+	maxTitleSuggestions = 5
+	// knownTagLimit caps how many existing tags are offered to the provider
+	knownTagLimit = 100
+)
+
+// SuggestTags proposes tags for a stored blog. Suggestions that match an
+// existing tag take its spelling. With apply they are added to the blog.
+func (ac *AIUseCase) SuggestTags(ctx context.Context, blogID, userID, role string, apply bool) ([]string, *domain.Blog, error) {
+	_, blog, err := ac.blogUC.ownedBlog(blogID, userID, role)
+	if err != nil {
+		return nil, nil, err
+	}
+	known, err := ac.blogUC.Repo.PopularTags(knownTagLimit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var tags []string
+	prompt := infrastructure.BuildTagPrompt(blog.Content, known, maxTagSuggestions)
+	_, err = ac.call(userID, role, domain.AIOperationSuggestTags, prompt, func() (string, error) {
+		var err error
+		tags, err = ac.aiService.SuggestTags(ctx, blog.Content, known, maxTagSuggestions)
+		return strings.Join(tags, "\n"), err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	tags = canonicalTags(tags, known)
+
+	if apply && len(tags) > 0 {
+		blog.Tags = mergeTags(blog.Tags, tags)
+		if err := ac.blogUC.UpdateBlog(blogID, userID, blog); err != nil {
+			return nil, nil, err
+		}
+	}
+	return tags, blog, nil
+}
+
+// SuggestTitles proposes alternative titles for a stored blog. With apply
+// the first suggestion becomes the blog's title.
+func (ac *AIUseCase) SuggestTitles(ctx context.Context, blogID, userID, role string, apply bool) ([]string, *domain.Blog, error) {
+	_, blog, err := ac.blogUC.ownedBlog(blogID, userID, role)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var titles []string
+	prompt := infrastructure.BuildTitlePrompt(blog.Title, blog.Content, maxTitleSuggestions)
+	_, err = ac.call(userID, role, domain.AIOperationSuggestTitles, prompt, func() (string, error) {
+		var err error
+		titles, err = ac.aiService.SuggestTitles(ctx, blog.Title, blog.Content, maxTitleSuggestions)
+		return strings.Join(titles, "\n"), err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if apply {
+		if len(titles) == 0 {
+			return nil, nil, ErrEmptyAIOutput
+		}
+		blog.Title = titles[0]
+		if err := ac.blogUC.UpdateBlog(blogID, userID, blog); err != nil {
+			return nil, nil, err
+		}
+	}
+	return titles, blog, nil
+}
+
+// canonicalTags normalizes suggested tags, reusing the spelling of known
+// tags, and lists the known ones first
+func canonicalTags(suggested, known []string) []string {
+	spelling := make(map[string]string, len(known))
+	for _, tag := range known {
+		spelling[strings.ToLower(tag)] = tag
+	}
+
+	existing, fresh := []string{}, []string{}
+	seen := map[string]bool{}
+	for _, tag := range suggested {
+		key := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(tag), "#")))
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		if known, ok := spelling[key]; ok {
+			existing = append(existing, known)
+		} else {
+			fresh = append(fresh, key)
+		}
+	}
+	return append(existing, fresh...)
+}
+
+// mergeTags appends the new tags the blog does not have yet
+func mergeTags(current, added []string) []string {
+	has := make(map[string]bool, len(current))
+	for _, tag := range current {
+		has[strings.ToLower(tag)] = true
+	}
+	merged := append([]string{}, current...)
+	for _, tag := range added {
+		if !has[strings.ToLower(tag)] {
+			merged = append(merged, tag)
+			has[strings.ToLower(tag)] = true
+		}
+	}
+	return merged
+}
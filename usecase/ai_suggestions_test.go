@@ -0,0 +1,166 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"github.com/sol-tad/Blog-post-Api/infrastructure"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PopularTags returns the tags of the stored blogs, most used first
+func (r *fakeBlogRepo) PopularTags(limit int) ([]string, error) {
+	counts := map[string]int{}
+	for _, blog := range r.blogs {
+		for _, tag := range blog.Tags {
+			counts[tag]++
+		}
+	}
+	tags := make([]string, 0, len(counts))
+	for tag := range counts {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if counts[tags[i]] != counts[tags[j]] {
+			return counts[tags[i]] > counts[tags[j]]
+		}
+		return tags[i] < tags[j]
+	})
+	return tags[:min(limit, len(tags))], nil
+}
+
+func TestSuggestTags(t *testing.T) {
+	author := primitive.NewObjectID()
+	fake, _ := infrastructure.NewFakeAIProvider()
+	other := &domain.Blog{ID: primitive.NewObjectID(), Tags: []string{"Goroutines", "Testing"}, Status: domain.BlogStatusPublished}
+	newBlog := func() *domain.Blog {
+		return &domain.Blog{
+			ID:       primitive.NewObjectID(),
+			AuthorID: author,
+			Title:    "Channels",
+			Slug:     "channels",
+			Content:  "Golang channels and golang goroutines. Channels everywhere.",
+			Tags:     []string{"golang"},
+			Status:   domain.BlogStatusPublished,
+		}
+	}
+	suggested := []string{"Goroutines", "golang", "channels"}
+
+	tests := []struct {
+		name     string
+		userID   string
+		apply    bool
+		daily    int64
+		wantErr  error
+		wantTags []string
+	}{
+		{"suggest only", author.Hex(), false, 0, nil, []string{"golang"}},
+		{"apply adds the new tags", author.Hex(), true, 0, nil, []string{"golang", "Goroutines", "channels"}},
+		{"someone else's blog", primitive.NewObjectID().Hex(), false, 0, ErrBlogForbidden, []string{"golang"}},
+		{"quota used up", author.Hex(), true, 1, ErrAIQuotaExceeded, []string{"golang"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blog := newBlog()
+			uc, usage, repo := newTestAIUseCase(fake, tt.daily, blog, other)
+			if tt.daily > 0 {
+				usage.Record(&domain.AIUsage{UserID: author, CreatedAt: time.Now()})
+			}
+
+			tags, _, err := uc.SuggestTags(context.Background(), blog.ID.Hex(), tt.userID, domain.RoleAuthor, tt.apply)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(tags, suggested) {
+				t.Errorf("suggested %v, want %v", tags, suggested)
+			}
+			if got := repo.blogs[blog.ID].Tags; !reflect.DeepEqual(got, tt.wantTags) {
+				t.Errorf("stored tags = %v, want %v", got, tt.wantTags)
+			}
+		})
+	}
+}
+
+func TestSuggestTitles(t *testing.T) {
+	author := primitive.NewObjectID()
+	fake, _ := infrastructure.NewFakeAIProvider()
+
+	tests := []struct {
+		name      string
+		service   domain.AIService
+		apply     bool
+		wantErr   error
+		wantTitle string
+		wantSlug  string
+	}{
+		{"suggest only", fake, false, nil, "Caching", "caching"},
+		{"apply takes the first suggestion", fake, true, nil, "A Practical Guide to Caching", "a-practical-guide-to-caching"},
+		{"apply without suggestions", silentAI{fake}, true, ErrEmptyAIOutput, "Caching", "caching"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blog := &domain.Blog{ID: primitive.NewObjectID(), AuthorID: author, Title: "Caching", Slug: "caching", Content: "Keep it warm.", Status: domain.BlogStatusDraft}
+			uc, usage, repo := newTestAIUseCase(tt.service, 0, blog)
+
+			titles, _, err := uc.SuggestTitles(context.Background(), blog.ID.Hex(), author.Hex(), domain.RoleAuthor, tt.apply)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (len(titles) == 0 || len(titles) > maxTitleSuggestions) {
+				t.Errorf("got %d titles, want 1 to %d", len(titles), maxTitleSuggestions)
+			}
+			stored := repo.blogs[blog.ID]
+			if stored.Title != tt.wantTitle || stored.Slug != tt.wantSlug {
+				t.Errorf("stored title %q, slug %q; want %q, %q", stored.Title, stored.Slug, tt.wantTitle, tt.wantSlug)
+			}
+			if len(usage.records) != 1 || usage.records[0].Operation != domain.AIOperationSuggestTitles {
+				t.Errorf("usage = %v, want one %s call", usage.records, domain.AIOperationSuggestTitles)
+			}
+		})
+	}
+}
+
+// silentAI is a provider that suggests nothing
+type silentAI struct {
+	domain.AIService
+}
+
+func (silentAI) SuggestTitles(ctx context.Context, title, content string, count int) ([]string, error) {
+	return nil, nil
+}
+
+func TestCanonicalTags(t *testing.T) {
+	tests := []struct {
+		name      string
+		suggested []string
+		known     []string
+		want      []string
+	}{
+		{"known spelling wins", []string{"golang", "DOCKER"}, []string{"Docker"}, []string{"Docker", "golang"}},
+		{"known tags first", []string{"new", "Go"}, []string{"go"}, []string{"go", "new"}},
+		{"hashes, spaces and repeats dropped", []string{" #Rust ", "rust", "", "#"}, nil, []string{"rust"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canonicalTags(tt.suggested, tt.known); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("canonicalTags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeTags(t *testing.T) {
+	current := []string{"Go", "testing"}
+	got := mergeTags(current, []string{"go", "Docker", "docker"})
+	if want := []string{"Go", "testing", "Docker"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeTags() = %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(current, []string{"Go", "testing"}) {
+		t.Errorf("mergeTags changed its input to %v", current)
+	}
+}
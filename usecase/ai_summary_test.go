@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"github.com/sol-tad/Blog-post-Api/infrastructure"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (silentAI) SummarizeBlog(ctx context.Context, content string) (string, error) {
+	return " \n", nil
+}
+
+func TestSummarizeStoredBlog(t *testing.T) {
+	author := primitive.NewObjectID()
+	fake, _ := infrastructure.NewFakeAIProvider()
+	content := "Caches keep hot data close. They need a clear eviction policy. Measure before tuning."
+	stored := &domain.BlogSummary{Text: "- old", ContentHash: domain.HashContent(content), Provider: "fake", GeneratedAt: time.Now().Add(-time.Hour)}
+	stale := &domain.BlogSummary{Text: "- stale", ContentHash: domain.HashContent("an earlier version"), Provider: "fake"}
+
+	tests := []struct {
+		name      string
+		service   domain.AIService
+		summary   *domain.BlogSummary
+		userID    string
+		refresh   bool
+		wantErr   error
+		wantText  string
+		wantCalls int
+	}{
+		{"first summary", fake, nil, author.Hex(), false, nil, "- Caches keep hot data close\n- They need a clear eviction policy\n- Measure before tuning", 1},
+		{"current summary is reused", fake, stored, author.Hex(), false, nil, "- old", 0},
+		{"refresh", fake, stored, author.Hex(), true, nil, "- Caches keep hot data close\n- They need a clear eviction policy\n- Measure before tuning", 1},
+		{"summary of old content is replaced", fake, stale, author.Hex(), false, nil, "- Caches keep hot data close\n- They need a clear eviction policy\n- Measure before tuning", 1},
+		{"empty output", silentAI{fake}, nil, author.Hex(), false, ErrEmptyAIOutput, "", 1},
+		{"someone else's blog", fake, nil, primitive.NewObjectID().Hex(), false, ErrBlogForbidden, "", 0},
+		{"no provider", nil, nil, author.Hex(), false, ErrAIUnavailable, "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blog := &domain.Blog{ID: primitive.NewObjectID(), AuthorID: author, Title: "Caching", Content: content, Status: domain.BlogStatusPublished, Summary: tt.summary}
+			uc, usage, repo := newTestAIUseCase(tt.service, 0, blog)
+
+			summary, err := uc.SummarizeStoredBlog(context.Background(), blog.ID.Hex(), tt.userID, domain.RoleAuthor, "", tt.refresh)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if len(usage.records) != tt.wantCalls {
+				t.Errorf("made %d AI calls, want %d", len(usage.records), tt.wantCalls)
+			}
+			if err != nil {
+				if repo.blogs[blog.ID].Summary != tt.summary {
+					t.Error("a failed summary changed the stored one")
+				}
+				return
+			}
+			if summary.Text != tt.wantText {
+				t.Errorf("summary = %q, want %q", summary.Text, tt.wantText)
+			}
+			saved := repo.blogs[blog.ID].Summary
+			if saved == nil || saved.Text != tt.wantText || saved.ContentHash != domain.HashContent(content) {
+				t.Errorf("saved summary = %+v, want %q for the current content", saved, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestEditDropsOutdatedSummary(t *testing.T) {
+	author := primitive.NewObjectID()
+	summary := &domain.BlogSummary{Text: "- old", ContentHash: domain.HashContent("first")}
+	blog := &domain.Blog{ID: primitive.NewObjectID(), AuthorID: author, Title: "Title", Slug: "title", Content: "first", Status: domain.BlogStatusDraft, Summary: summary}
+	repo := newFakeBlogRepo(blog)
+	uc := &BlogUseCase{Repo: repo, RevisionRepo: &fakeRevisionRepo{}}
+
+	if _, err := uc.EditBlog(blog.ID.Hex(), author.Hex(), domain.RoleAuthor, &domain.Blog{Title: "Title", Content: "first"}); err != nil {
+		t.Fatal(err)
+	}
+	if repo.blogs[blog.ID].Summary == nil {
+		t.Fatal("an edit that kept the content dropped the summary")
+	}
+	if _, err := uc.EditBlog(blog.ID.Hex(), author.Hex(), domain.RoleAuthor, &domain.Blog{Title: "Title", Content: "second"}); err != nil {
+		t.Fatal(err)
+	}
+	if repo.blogs[blog.ID].Summary != nil {
+		t.Error("the summary of the old content was kept")
+	}
+}
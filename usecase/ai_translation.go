@@ -0,0 +1,64 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"github.com/sol-tad/Blog-post-Api/infrastructure"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// TranslateBlog machine translates a stored blog and saves the translation.
+// A translation that is still current is reused, and one edited by hand is
+// never replaced, unless refresh is set.
+func (ac *AIUseCase) TranslateBlog(ctx context.Context, blogID, language, userID, role string, refresh bool) (*domain.BlogTranslation, error) {
+	_, blog, err := ac.blogUC.ownedBlog(blogID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	language, err = translationLanguage(blog, language)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := ac.blogUC.TranslationRepo.Get(blog.ID, language)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+	if existing != nil && !refresh {
+		existing.Stale = blog.UpdatedAt.After(existing.SourceUpdatedAt)
+		if existing.Origin == domain.TranslationHuman && existing.Stale {
+			return nil, ErrHumanTranslation
+		}
+		if !existing.Stale {
+			return existing, nil
+		}
+	}
+
+	prompt := infrastructure.BuildTranslatePrompt(blog.Title, blog.Content, language)
+	text, err := ac.call(userID, role, domain.AIOperationTranslate, prompt, func() (string, error) {
+		return ac.aiService.Translate(ctx, blog.Title, blog.Content, language)
+	})
+	if err != nil {
+		return nil, err
+	}
+	title, content := parseGeneratedPost(text, blog.Title)
+	if strings.TrimSpace(content) == "" {
+		return nil, ErrEmptyAIOutput
+	}
+
+	translation := &domain.BlogTranslation{
+		BlogID:   blog.ID,
+		Language: language,
+		Title:    title,
+		Content:  content,
+		Origin:   domain.TranslationMachine,
+		Provider: ac.aiService.Name(),
+	}
+	if err := ac.blogUC.saveTranslation(blog, translation); err != nil {
+		return nil, err
+	}
+	return translation, nil
+}
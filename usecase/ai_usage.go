@@ -0,0 +1,130 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrAIQuotaExceeded = errors.New("AI usage quota exceeded")
+	ErrInvalidAIUser   = errors.New("invalid user")
+)
+
+// call runs an AI operation for the user once their quota allows it, and
+// records the call in the usage log whether or not it succeeded
+func (ac *AIUseCase) call(userID, role, operation, prompt string, run func() (string, error)) (string, error) {
+	if ac.aiService == nil {
+		return "", ErrAIUnavailable
+	}
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return "", ErrInvalidAIUser
+	}
+	if err := ac.checkQuota(id, role); err != nil {
+		return "", err
+	}
+
+	started := time.Now()
+	output, err := run()
+
+	usage := &domain.AIUsage{
+		UserID:        id,
+		Role:          role,
+		Operation:     operation,
+		Provider:      ac.aiService.Name(),
+		PromptChars:   utf8.RuneCountInString(prompt),
+		ResponseChars: utf8.RuneCountInString(output),
+		LatencyMs:     time.Since(started).Milliseconds(),
+		Success:       err == nil,
+		CreatedAt:     started,
+	}
+	if recordErr := ac.usageRepo.Record(usage); recordErr != nil {
+		fmt.Println("recording AI usage failed:", recordErr)
+	}
+	return output, err
+}
+
+// checkQuota fails once the user has used up the daily or monthly quota of
+// their role. Concurrent calls may overshoot the quota by a few calls.
+func (ac *AIUseCase) checkQuota(userID primitive.ObjectID, role string) error {
+	quota := ac.quotaFor(role)
+	day, month := usagePeriods(time.Now())
+
+	if quota.Daily > 0 {
+		used, err := ac.usageRepo.CountSince(userID, day)
+		if err != nil {
+			return err
+		}
+		if used >= quota.Daily {
+			return fmt.Errorf("%w: daily limit of %d calls reached", ErrAIQuotaExceeded, quota.Daily)
+		}
+	}
+	if quota.Monthly > 0 {
+		used, err := ac.usageRepo.CountSince(userID, month)
+		if err != nil {
+			return err
+		}
+		if used >= quota.Monthly {
+			return fmt.Errorf("%w: monthly limit of %d calls reached", ErrAIQuotaExceeded, quota.Monthly)
+		}
+	}
+	return nil
+}
+
+// quotaFor returns the quota of the role, falling back to the quota of
+// the default role for roles without one
+func (ac *AIUseCase) quotaFor(role string) domain.AIQuota {
+	if quota, ok := ac.quotas[role]; ok {
+		return quota
+	}
+	return ac.quotas[domain.DefaultRole]
+}
+
+// GetMyUsage reports the user's usage this month against their quota
+func (ac *AIUseCase) GetMyUsage(userID, role string) (*domain.AIQuotaStatus, error) {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidAIUser
+	}
+	day, month := usagePeriods(time.Now())
+
+	usedToday, err := ac.usageRepo.CountSince(id, day)
+	if err != nil {
+		return nil, err
+	}
+	usedThisMonth, err := ac.usageRepo.CountSince(id, month)
+	if err != nil {
+		return nil, err
+	}
+	operations, err := ac.usageRepo.Summarize(domain.AIUsageFilter{UserID: id, From: month})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.AIQuotaStatus{
+		Role:          role,
+		Quota:         ac.quotaFor(role),
+		UsedToday:     usedToday,
+		UsedThisMonth: usedThisMonth,
+		Operations:    operations,
+	}, nil
+}
+
+// GetUsageReport totals usage per user and operation for admins
+func (ac *AIUseCase) GetUsageReport(filter domain.AIUsageFilter) ([]*domain.AIUsageSummary, error) {
+	return ac.usageRepo.Summarize(filter)
+}
+
+// usagePeriods returns the start of the current day and month in UTC,
+// which is when quotas reset
+func usagePeriods(now time.Time) (day, month time.Time) {
+	now = now.UTC()
+	day = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	month = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return day, month
+}
@@ -1,27 +1,242 @@
 package usecase
 
-import "github.com/sol-tad/Blog-post-Api/domain"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"github.com/sol-tad/Blog-post-Api/infrastructure"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrAIUnavailable = errors.New("AI features are not available: no AI provider is configured")
+	ErrEmptyAIOutput = errors.New("AI provider returned no content")
+	ErrInvalidLength = fmt.Errorf("length must be between 1 and %d words", maxGenerationLength)
+)
+
+const (
+	defaultGenerationLength = 800
+	// maxGenerationLength bounds the words asked of the provider per post
+	maxGenerationLength = 5000
+)
 
 type AIUseCase struct {
 	aiService domain.AIService
+	blogUC    *BlogUseCase
+	usageRepo domain.AIUsageRepository
+	quotas    map[string]domain.AIQuota
+	templates *PromptTemplateUsecase
 }
 
-func NewAIUseCases(aiService domain.AIService) *AIUseCase {
+// NewAIUseCases accepts a nil service, in which case every AI operation
+// fails with ErrAIUnavailable. Quotas are keyed by role.
+func NewAIUseCases(aiService domain.AIService, blogUC *BlogUseCase, usageRepo domain.AIUsageRepository, quotas map[string]domain.AIQuota, templates *PromptTemplateUsecase) *AIUseCase {
 	return &AIUseCase{
 		aiService: aiService,
+		blogUC:    blogUC,
+		usageRepo: usageRepo,
+		quotas:    quotas,
+		templates: templates,
 	}}
 
-func (ac *AIUseCase) GenerateBlog(params domain.GenerationParams) ( string, error){
+func (ac *AIUseCase) GenerateBlog(ctx context.Context, params domain.GenerationParams, userID, role string) ( string, error){
+	params, _, err := ac.generationParams(params)
+	if err != nil {
+		return "", err
+	}
+	return ac.call(userID, role, domain.AIOperationGenerate, infrastructure.BuildPrompt(params), func() (string, error) {
+		return ac.aiService.GenerateContent(ctx, params)
+	})
+}
+
+// GenerateBlogStream passes generated text to onChunk as it arrives and
+// returns the assembled content. Providers that cannot stream deliver the
+// whole post as a single chunk.
+func (ac *AIUseCase) GenerateBlogStream(ctx context.Context, params domain.GenerationParams, userID, role string, onChunk func(string) error) (string, error) {
+	params, _, err := ac.generationParams(params)
+	if err != nil {
+		return "", err
+	}
+	return ac.call(userID, role, domain.AIOperationGenerateStream, infrastructure.BuildPrompt(params), func() (string, error) {
+		if streamer, ok := ac.aiService.(domain.AIStreamService); ok {
+			return streamer.GenerateContentStream(ctx, params, onChunk)
+		}
+
+		content, err := ac.aiService.GenerateContent(ctx, params)
+		if err != nil {
+			return "", err
+		}
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		return content, onChunk(content)
+	})
+}
+
+// generationParams fills in defaults and renders the prompt of the chosen
+// template. It also returns the reference of the template version used.
+func (ac *AIUseCase) generationParams(params domain.GenerationParams) (domain.GenerationParams, string, error) {
 	if params.Tone == ""{
 		params.Tone = "professional"
 	}
 	if params.Length == 0{
-		params.Length = 800
+		params.Length = defaultGenerationLength
+	}
+	if params.Length < 0 || params.Length > maxGenerationLength {
+		return params, "", ErrInvalidLength
+	}
+	params.Prompt = ""
+	if params.Template == "" {
+		return params, "", nil
+	}
+
+	prompt, template, err := ac.templates.Render(params.Template, domain.PromptKindGenerate, domain.PromptData{GenerationParams: params})
+	if err != nil {
+		return params, "", err
+	}
+	params.Prompt = prompt
+	return params, template.Ref(), nil
+}
+
+func(ac *AIUseCase) SummarizeBLog(ctx context.Context, content, template, userID, role string) (string, error){
+	summary, _, err := ac.summarize(ctx, content, template, userID, role)
+	return summary, err
+}
+
+// summarize summarizes the content with the default prompt or the chosen
+// template, returning the reference of the template version used
+func (ac *AIUseCase) summarize(ctx context.Context, content, template, userID, role string) (string, string, error) {
+	if template == "" {
+		summary, err := ac.call(userID, role, domain.AIOperationSummarize, infrastructure.BuildSummaryPrompt(content), func() (string, error) {
+			return ac.aiService.SummarizeBlog(ctx, content)
+		})
+		return summary, "", err
+	}
+
+	prompt, tmpl, err := ac.templates.Render(template, domain.PromptKindSummarize, domain.PromptData{Content: content})
+	if err != nil {
+		return "", "", err
+	}
+	summary, err := ac.call(userID, role, domain.AIOperationSummarize, prompt, func() (string, error) {
+		return ac.aiService.Complete(ctx, prompt)
+	})
+	return summary, tmpl.Ref(), err
+}
+
+// SummarizeStoredBlog summarizes the blog's stored content and saves the
+// summary on the blog. A summary that still matches the content and was
+// made with the same template is reused unless refresh is set.
+func (ac *AIUseCase) SummarizeStoredBlog(ctx context.Context, blogID, userID, role, template string, refresh bool) (*domain.BlogSummary, error) {
+	id, blog, err := ac.blogUC.ownedBlog(blogID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	summary := blog.CurrentSummary()
+	if summary != nil && !refresh && (summary.Template == template || summary.TemplateName() == template) {
+		return summary, nil
 	}
-	return ac.aiService.GenerateContent(params)
+
+	hash := domain.HashContent(blog.Content)
+	text, ref, err := ac.summarize(ctx, blog.Content, template, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, ErrEmptyAIOutput
+	}
+
+	summary = &domain.BlogSummary{
+		Text:        text,
+		ContentHash: hash,
+		Provider:    ac.aiService.Name(),
+		Template:    ref,
+		GeneratedAt: time.Now(),
+	}
+	if err := ac.blogUC.Repo.SaveSummary(id, summary); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// GenerateDraft generates a post and saves it as a draft owned by the user.
+// The draft records how it was produced and cannot be published until the
+// author confirms it.
+func (ac *AIUseCase) GenerateDraft(ctx context.Context, params domain.GenerationParams, userID, role string) (*domain.Blog, error) {
+	authorID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrBlogForbidden
+	}
+
+	params, templateRef, err := ac.generationParams(params)
+	if err != nil {
+		return nil, err
+	}
+	prompt := infrastructure.BuildPrompt(params)
+	generatedAt := time.Now()
+	content, err := ac.call(userID, role, domain.AIOperationDraft, prompt, func() (string, error) {
+		return ac.aiService.GenerateContent(ctx, params)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fallback := params.Title
+	if fallback == "" {
+		fallback = params.Topic
+	}
+	title, body := parseGeneratedPost(content, fallback)
+	if body == "" {
+		return nil, ErrEmptyAIOutput
+	}
+
+	tags := params.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	blog := &domain.Blog{
+		Title:    title,
+		Content:  body,
+		AuthorID: authorID,
+		Tags:     tags,
+		Status:   domain.BlogStatusDraft,
+		AI: &domain.AIProvenance{
+			Provider:    ac.aiService.Name(),
+			Prompt:      prompt,
+			Template:    templateRef,
+			Params:      params,
+			GeneratedAt: generatedAt,
+		},
+	}
+	if err := ac.blogUC.StoreBlog(blog); err != nil {
+		return nil, err
+	}
+	return blog, nil
 }
 
-func(ac *AIUseCase) SummarizeBLog(content string) (string, error){
-	return ac.SummarizeBLog(content)
-}
\ No newline at end of file
+// parseGeneratedPost splits generated Markdown into a title and a body. The
+// title is taken from a leading heading or "Title:" line; without one the
+// fallback is used and the whole text becomes the body.
+func parseGeneratedPost(content, fallback string) (string, string) {
+	content = strings.TrimSpace(strings.ReplaceAll(content, "\r\n", "\n"))
+	first, rest, _ := strings.Cut(content, "\n")
+
+	line := strings.TrimSpace(first)
+	title := ""
+	switch {
+	case strings.HasPrefix(line, "#"):
+		title = strings.TrimSpace(strings.TrimLeft(line, "#"))
+	case strings.HasPrefix(strings.ToLower(strings.Trim(line, "*")), "title:"):
+		title = strings.TrimSpace(strings.Trim(line, "*")[len("title:"):])
+	}
+	title = strings.TrimSpace(strings.Trim(title, "*_\"'"))
+
+	if title == "" {
+		return fallback, content
+	}
+	return title, strings.TrimSpace(rest)
+}
@@ -0,0 +1,157 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"github.com/sol-tad/Blog-post-Api/infrastructure"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeUsageRepo keeps the usage log in memory
+type fakeUsageRepo struct {
+	domain.AIUsageRepository
+	records []*domain.AIUsage
+}
+
+func (r *fakeUsageRepo) Record(usage *domain.AIUsage) error {
+	r.records = append(r.records, usage)
+	return nil
+}
+
+func (r *fakeUsageRepo) CountSince(userID primitive.ObjectID, since time.Time) (int64, error) {
+	var count int64
+	for _, usage := range r.records {
+		if usage.UserID == userID && !usage.CreatedAt.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// plainAI hides the streaming of the provider it wraps
+type plainAI struct {
+	domain.AIService
+}
+
+// newTestAIUseCase runs AI calls on service with the given daily quota for
+// authors, on top of a blog use case holding blogs
+func newTestAIUseCase(service domain.AIService, daily int64, blogs ...*domain.Blog) (*AIUseCase, *fakeUsageRepo, *fakeBlogRepo) {
+	usage := &fakeUsageRepo{}
+	repo := newFakeBlogRepo(blogs...)
+	blogUC := &BlogUseCase{Repo: repo, RevisionRepo: &fakeRevisionRepo{}}
+	quotas := map[string]domain.AIQuota{domain.RoleAuthor: {Daily: daily}}
+	return NewAIUseCases(service, blogUC, usage, quotas, nil), usage, repo
+}
+
+func TestGenerationParamsLength(t *testing.T) {
+	tests := []struct {
+		name       string
+		length     int
+		wantLength int
+		wantErr    error
+	}{
+		{"default", 0, defaultGenerationLength, nil},
+		{"short", 1, 1, nil},
+		{"longest", maxGenerationLength, maxGenerationLength, nil},
+		{"too long", maxGenerationLength + 1, 0, ErrInvalidLength},
+		{"negative", -5, 0, ErrInvalidLength},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := NewAIUseCases(nil, nil, nil, nil, nil)
+			params, _, err := uc.generationParams(domain.GenerationParams{Topic: "go", Length: tt.length})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && params.Length != tt.wantLength {
+				t.Errorf("length = %d, want %d", params.Length, tt.wantLength)
+			}
+		})
+	}
+}
+
+func TestGenerateBlogStream(t *testing.T) {
+	fake, _ := infrastructure.NewFakeAIProvider()
+	clientGone := errors.New("client went away")
+
+	tests := []struct {
+		name      string
+		service   domain.AIService
+		daily     int64
+		onChunk   error
+		wantErr   error
+		minChunks int
+		maxChunks int
+		wantUsage bool
+	}{
+		{"streaming provider", fake, 0, nil, nil, 2, 1000, true},
+		{"provider without streaming sends one chunk", plainAI{fake}, 0, nil, nil, 1, 1, true},
+		{"client disconnects", fake, 0, clientGone, clientGone, 1, 1, true},
+		{"quota used up", fake, 1, nil, ErrAIQuotaExceeded, 0, 0, false},
+		{"no provider", nil, 0, nil, ErrAIUnavailable, 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, usage, _ := newTestAIUseCase(tt.service, tt.daily)
+			user := primitive.NewObjectID()
+			if tt.daily > 0 {
+				usage.Record(&domain.AIUsage{UserID: user, CreatedAt: time.Now()})
+			}
+			before := len(usage.records)
+
+			var chunks []string
+			content, err := uc.GenerateBlogStream(context.Background(), domain.GenerationParams{Topic: "caching", Length: 60}, user.Hex(), domain.RoleAuthor, func(chunk string) error {
+				chunks = append(chunks, chunk)
+				return tt.onChunk
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if len(chunks) < tt.minChunks || len(chunks) > tt.maxChunks {
+				t.Errorf("got %d chunks, want %d to %d", len(chunks), tt.minChunks, tt.maxChunks)
+			}
+			if err == nil && strings.Join(chunks, "") != content {
+				t.Error("chunks do not add up to the returned content")
+			}
+
+			recorded := usage.records[before:]
+			if (len(recorded) == 1) != tt.wantUsage {
+				t.Fatalf("recorded %d usage entries, want recorded %v", len(recorded), tt.wantUsage)
+			}
+			if tt.wantUsage {
+				if recorded[0].Operation != domain.AIOperationGenerateStream {
+					t.Errorf("operation = %q, want %q", recorded[0].Operation, domain.AIOperationGenerateStream)
+				}
+				if recorded[0].Success != (tt.wantErr == nil) {
+					t.Errorf("success = %v, want %v", recorded[0].Success, tt.wantErr == nil)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateBlogStreamStopsWhenCancelled(t *testing.T) {
+	fake, _ := infrastructure.NewFakeAIProvider()
+	uc, _, _ := newTestAIUseCase(fake, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	chunks := 0
+	_, err := uc.GenerateBlogStream(ctx, domain.GenerationParams{Topic: "caching"}, primitive.NewObjectID().Hex(), domain.RoleAuthor, func(string) error {
+		chunks++
+		if chunks == 3 {
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want %v", err, context.Canceled)
+	}
+	if chunks != 3 {
+		t.Errorf("sent %d chunks after cancelling, want 3", chunks)
+	}
+}
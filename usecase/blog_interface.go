@@ -1,6 +1,8 @@
 package usecase
 
 import (
+	"time"
+
 	"github.com/sol-tad/Blog-post-Api/domain"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -13,6 +15,18 @@ type IBlogRepo interface {
 	DeleteBlog(id primitive.ObjectID) error
 	GetByAuthor(author string, skip, limit int) ([]*domain.Blog, error)
 	List(page, limit int, filter domain.BlogFilter) ([]*domain.Blog, int64, error)	
+	TransitionStatus(id primitive.ObjectID, from, to string, at time.Time) error
+	SchedulePublish(id primitive.ObjectID, from string, publishAt, at time.Time) error
+	PublishNextDue(now time.Time) (*domain.Blog, error)
+	FindBySlug(slug string) (*domain.Blog, error)
+	SlugTaken(slug string, exclude primitive.ObjectID) (bool, error)
+	SaveRenderedContent(id primitive.ObjectID, contentHTML string, version int) error
+	SetCommentPolicy(id primitive.ObjectID, policy string) error
+	SaveSummary(id primitive.ObjectID, summary *domain.BlogSummary) error
+	PopularTags(limit int) ([]string, error)
+	ConfirmAIReview(id primitive.ObjectID, at time.Time) error
+	Feed(authorIDs []primitive.ObjectID, after *domain.FeedCursor, limit int) ([]*domain.Blog, error)
+	FindPublishedByIDs(ids []primitive.ObjectID) ([]*domain.Blog, error)
 
 }
 
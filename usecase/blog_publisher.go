@@ -0,0 +1,59 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// BlogPublisher periodically publishes scheduled blogs whose publish time
+// has passed. Schedules live on the blog documents, so nothing is lost when
+// the process restarts.
+type BlogPublisher struct {
+	Repo     IBlogRepo
+	Interval time.Duration
+}
+
+func NewBlogPublisher(repo IBlogRepo, interval time.Duration) *BlogPublisher {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &BlogPublisher{
+		Repo:     repo,
+		Interval: interval,
+	}
+}
+
+// Run publishes due blogs until the context is cancelled
+func (p *BlogPublisher) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		p.PublishDue()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PublishDue publishes every scheduled blog that is due and returns how
+// many were published
+func (p *BlogPublisher) PublishDue() int {
+	published := 0
+	for {
+		blog, err := p.Repo.PublishNextDue(time.Now())
+		if err != nil {
+			log.Println("scheduled publishing failed:", err)
+			return published
+		}
+		if blog == nil {
+			return published
+		}
+		log.Println("published scheduled blog:", blog.ID.Hex())
+		published++
+	}
+}
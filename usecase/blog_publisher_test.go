@@ -0,0 +1,107 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PublishNextDue publishes the earliest due scheduled blog, failing with
+// failPublish if it is set
+func (r *fakeBlogRepo) PublishNextDue(now time.Time) (*domain.Blog, error) {
+	if r.failPublish != nil {
+		return nil, r.failPublish
+	}
+	var due *domain.Blog
+	for _, blog := range r.blogs {
+		if blog.Status != domain.BlogStatusScheduled || blog.PublishAt == nil || blog.PublishAt.After(now) {
+			continue
+		}
+		if due == nil || blog.PublishAt.Before(*due.PublishAt) {
+			due = blog
+		}
+	}
+	if due == nil {
+		return nil, nil
+	}
+	due.Status = domain.BlogStatusPublished
+	due.PublishedAt = &now
+	due.PublishAt = nil
+	copied := *due
+	return &copied, nil
+}
+
+func scheduledBlog(publishAt time.Time) *domain.Blog {
+	return &domain.Blog{ID: primitive.NewObjectID(), Status: domain.BlogStatusScheduled, PublishAt: &publishAt}
+}
+
+func TestPublishDue(t *testing.T) {
+	now := time.Now()
+	due := scheduledBlog(now.Add(-time.Minute))
+	alsoDue := scheduledBlog(now.Add(-time.Hour))
+	later := scheduledBlog(now.Add(time.Hour))
+	draft := &domain.Blog{ID: primitive.NewObjectID(), Status: domain.BlogStatusDraft}
+	repo := newFakeBlogRepo(due, alsoDue, later, draft)
+	publisher := NewBlogPublisher(repo, time.Minute)
+
+	if got := publisher.PublishDue(); got != 2 {
+		t.Fatalf("PublishDue() = %d, want 2", got)
+	}
+	// A second run finds nothing left to publish
+	if got := publisher.PublishDue(); got != 0 {
+		t.Errorf("second PublishDue() = %d, want 0", got)
+	}
+
+	tests := []struct {
+		name          string
+		blog          *domain.Blog
+		wantStatus    string
+		wantPublished bool
+	}{
+		{"due", due, domain.BlogStatusPublished, true},
+		{"overdue", alsoDue, domain.BlogStatusPublished, true},
+		{"not yet due", later, domain.BlogStatusScheduled, false},
+		{"draft", draft, domain.BlogStatusDraft, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blog := repo.blogs[tt.blog.ID]
+			if blog.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", blog.Status, tt.wantStatus)
+			}
+			if (blog.PublishedAt != nil) != tt.wantPublished {
+				t.Errorf("published at = %v, want set %v", blog.PublishedAt, tt.wantPublished)
+			}
+		})
+	}
+	if repo.blogs[later.ID].PublishAt == nil {
+		t.Error("the schedule of a blog that is not due was cleared")
+	}
+}
+
+func TestPublishDueStopsOnError(t *testing.T) {
+	repo := newFakeBlogRepo(scheduledBlog(time.Now().Add(-time.Minute)))
+	repo.failPublish = errors.New("database down")
+
+	if got := NewBlogPublisher(repo, time.Minute).PublishDue(); got != 0 {
+		t.Errorf("PublishDue() = %d, want 0", got)
+	}
+}
+
+func TestPublisherRunPublishesOnStart(t *testing.T) {
+	blog := scheduledBlog(time.Now().Add(-time.Minute))
+	repo := newFakeBlogRepo(blog)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Run publishes once before it checks the context, so a cancelled
+	// context returns right after the first pass
+	NewBlogPublisher(repo, time.Hour).Run(ctx)
+	if status := repo.blogs[blog.ID].Status; status != domain.BlogStatusPublished {
+		t.Errorf("status = %q, want %q", status, domain.BlogStatusPublished)
+	}
+}
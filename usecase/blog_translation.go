@@ -0,0 +1,187 @@
+package usecase
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"github.com/sol-tad/Blog-post-Api/infrastructure"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrInvalidLanguage    = errors.New("language must be a language code such as en or pt-br")
+	ErrSameLanguage       = errors.New("blog is already written in this language")
+	ErrInvalidTranslation = errors.New("translation needs a title and content")
+	ErrHumanTranslation   = errors.New("translation was edited by hand; refresh to replace it")
+)
+
+// ListTranslations returns the translations of a blog the user can read
+func (b *BlogUseCase) ListTranslations(blogID, userID, role string) ([]*domain.BlogTranslation, error) {
+	blog := b.findVisibleBlog(blogID, userID, role)
+	if blog == nil {
+		return nil, ErrBlogNotFound
+	}
+	translations, err := b.TranslationRepo.ListByBlog(blog.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, translation := range translations {
+		translation.Stale = blog.UpdatedAt.After(translation.SourceUpdatedAt)
+	}
+	return translations, nil
+}
+
+// SaveTranslation writes or corrects a translation by hand. The
+// translation counts as human made from then on.
+func (b *BlogUseCase) SaveTranslation(blogID, language, userID, role, title, content string) (*domain.BlogTranslation, error) {
+	_, blog, err := b.ownedBlog(blogID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	language, err = translationLanguage(blog, language)
+	if err != nil {
+		return nil, err
+	}
+	title, content = strings.TrimSpace(title), strings.TrimSpace(content)
+	if title == "" || content == "" {
+		return nil, ErrInvalidTranslation
+	}
+
+	editorID, _ := primitive.ObjectIDFromHex(userID)
+	translation := &domain.BlogTranslation{
+		BlogID:   blog.ID,
+		Language: language,
+		Title:    title,
+		Content:  content,
+		Origin:   domain.TranslationHuman,
+		EditedBy: editorID,
+	}
+	// Keep track of the provider a corrected machine translation came from
+	existing, err := b.TranslationRepo.Get(blog.ID, language)
+	if err == nil {
+		translation.Provider = existing.Provider
+	} else if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+	return translation, b.saveTranslation(blog, translation)
+}
+
+// saveTranslation renders and stores a translation of blog
+func (b *BlogUseCase) saveTranslation(blog *domain.Blog, translation *domain.BlogTranslation) error {
+	translation.ContentHTML = infrastructure.RenderMarkdown(translation.Content)
+	translation.SourceUpdatedAt = blog.UpdatedAt
+	translation.CreatedAt = time.Now()
+	translation.UpdatedAt = translation.CreatedAt
+	return b.TranslationRepo.Save(translation)
+}
+
+// ViewBlogInLanguage looks a blog up like ViewBlogForUser and serves it in
+// the requested language: lang if given, otherwise the best match for an
+// Accept-Language header. Without a matching translation the blog is
+// returned in its own language.
+func (b *BlogUseCase) ViewBlogInLanguage(idOrSlug, userID, role, lang, acceptLanguage string) *domain.Blog {
+	blog := b.ViewBlogForUser(idOrSlug, userID, role)
+	if blog == nil {
+		return nil
+	}
+
+	preferences := []string{lang}
+	if lang == "" {
+		preferences = parseAcceptLanguage(acceptLanguage)
+	}
+	if len(preferences) == 0 {
+		return blog
+	}
+
+	translations, err := b.TranslationRepo.ListByBlog(blog.ID)
+	if err != nil || len(translations) == 0 {
+		return blog
+	}
+	if translation := matchTranslation(blog, preferences, translations); translation != nil {
+		blog.Translate(translation)
+	}
+	return blog
+}
+
+// translationLanguage validates the language a blog is translated into
+func translationLanguage(blog *domain.Blog, language string) (string, error) {
+	language, ok := domain.NormalizeLanguage(language)
+	if !ok {
+		return "", ErrInvalidLanguage
+	}
+	if language == blog.CurrentLanguage() {
+		return "", ErrSameLanguage
+	}
+	return language, nil
+}
+
+// matchTranslation picks the translation for the first preference that
+// either the blog or one of its translations can serve. A preference for
+// the blog's own language, or for any language, selects no translation.
+func matchTranslation(blog *domain.Blog, preferences []string, translations []*domain.BlogTranslation) *domain.BlogTranslation {
+	source := blog.CurrentLanguage()
+	for _, preference := range preferences {
+		if preference == "*" || preference == source {
+			return nil
+		}
+		for _, translation := range translations {
+			if translation.Language == preference {
+				return translation
+			}
+		}
+		// "pt-br" is happy with "pt" and the other way round
+		if domain.BaseLanguage(preference) == domain.BaseLanguage(source) {
+			return nil
+		}
+		for _, translation := range translations {
+			if domain.BaseLanguage(translation.Language) == domain.BaseLanguage(preference) {
+				return translation
+			}
+		}
+	}
+	return nil
+}
+
+// parseAcceptLanguage returns the languages of an Accept-Language header,
+// most preferred first
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		language string
+		q        float64
+	}
+	var entries []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			entries = append(entries, weighted{tag, q})
+		} else if language, ok := domain.NormalizeLanguage(tag); ok {
+			entries = append(entries, weighted{language, q})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].q > entries[j].q
+	})
+
+	languages := make([]string, len(entries))
+	for i, entry := range entries {
+		languages[i] = entry.language
+	}
+	return languages
+}
@@ -0,0 +1,280 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"github.com/sol-tad/Blog-post-Api/infrastructure"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type fakeTranslationRepo struct {
+	domain.BlogTranslationRepository
+	translations map[string]*domain.BlogTranslation
+}
+
+func newFakeTranslationRepo(translations ...*domain.BlogTranslation) *fakeTranslationRepo {
+	r := &fakeTranslationRepo{translations: map[string]*domain.BlogTranslation{}}
+	for _, translation := range translations {
+		r.Save(translation)
+	}
+	return r
+}
+
+func (r *fakeTranslationRepo) Save(translation *domain.BlogTranslation) error {
+	r.translations[translation.BlogID.Hex()+"/"+translation.Language] = translation
+	return nil
+}
+
+func (r *fakeTranslationRepo) Get(blogID primitive.ObjectID, language string) (*domain.BlogTranslation, error) {
+	translation, ok := r.translations[blogID.Hex()+"/"+language]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	copied := *translation
+	return &copied, nil
+}
+
+func (r *fakeTranslationRepo) ListByBlog(blogID primitive.ObjectID) ([]*domain.BlogTranslation, error) {
+	var translations []*domain.BlogTranslation
+	for _, translation := range r.translations {
+		if translation.BlogID == blogID {
+			copied := *translation
+			translations = append(translations, &copied)
+		}
+	}
+	sort.Slice(translations, func(i, j int) bool {
+		return translations[i].Language < translations[j].Language
+	})
+	return translations, nil
+}
+
+// ignoreViews drops the view counts of blogs looked up in tests
+type ignoreViews struct {
+	domain.InteractionRepository
+}
+
+func (ignoreViews) IncrementViewCount(blogID string) error {
+	return nil
+}
+
+func (silentAI) Translate(ctx context.Context, title, content, language string) (string, error) {
+	return " \n", nil
+}
+
+func TestTranslateBlog(t *testing.T) {
+	author := primitive.NewObjectID()
+	fake, _ := infrastructure.NewFakeAIProvider()
+	updated := time.Now().Add(-time.Hour)
+	translated := func(origin string, source time.Time) *domain.BlogTranslation {
+		return &domain.BlogTranslation{Language: "fr", Title: "Mise en cache", Content: "Gardez-le au chaud.", Origin: origin, Provider: "fake", SourceUpdatedAt: source}
+	}
+
+	tests := []struct {
+		name       string
+		service    domain.AIService
+		existing   *domain.BlogTranslation
+		language   string
+		refresh    bool
+		wantErr    error
+		wantTitle  string
+		wantOrigin string
+		wantCalls  int
+	}{
+		{"new translation", fake, nil, "FR", false, nil, "[fr] Caching", domain.TranslationMachine, 1},
+		{"current translation is reused", fake, translated(domain.TranslationMachine, updated), "fr", false, nil, "Mise en cache", domain.TranslationMachine, 0},
+		{"stale translation is replaced", fake, translated(domain.TranslationMachine, updated.Add(-time.Hour)), "fr", false, nil, "[fr] Caching", domain.TranslationMachine, 1},
+		{"current human translation is reused", fake, translated(domain.TranslationHuman, updated), "fr", false, nil, "Mise en cache", domain.TranslationHuman, 0},
+		{"stale human translation is kept", fake, translated(domain.TranslationHuman, updated.Add(-time.Hour)), "fr", false, ErrHumanTranslation, "Mise en cache", domain.TranslationHuman, 0},
+		{"refresh replaces a human translation", fake, translated(domain.TranslationHuman, updated), "fr", true, nil, "[fr] Caching", domain.TranslationMachine, 1},
+		{"blog language", fake, nil, "en", false, ErrSameLanguage, "", "", 0},
+		{"invalid language", fake, nil, "french!", false, ErrInvalidLanguage, "", "", 0},
+		{"empty output", silentAI{fake}, nil, "fr", false, ErrEmptyAIOutput, "", "", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blog := &domain.Blog{ID: primitive.NewObjectID(), AuthorID: author, Title: "Caching", Content: "Keep it warm.", Status: domain.BlogStatusPublished, UpdatedAt: updated}
+			uc, usage, _ := newTestAIUseCase(tt.service, 0, blog)
+			translations := newFakeTranslationRepo()
+			if tt.existing != nil {
+				tt.existing.BlogID = blog.ID
+				translations.Save(tt.existing)
+			}
+			uc.blogUC.TranslationRepo = translations
+
+			translation, err := uc.TranslateBlog(context.Background(), blog.ID.Hex(), tt.language, author.Hex(), domain.RoleAuthor, tt.refresh)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if len(usage.records) != tt.wantCalls {
+				t.Errorf("made %d AI calls, want %d", len(usage.records), tt.wantCalls)
+			}
+			if err == nil && (translation.Title != tt.wantTitle || translation.Origin != tt.wantOrigin) {
+				t.Errorf("translation %q by %s, want %q by %s", translation.Title, translation.Origin, tt.wantTitle, tt.wantOrigin)
+			}
+			stored, _ := translations.Get(blog.ID, "fr")
+			if tt.wantTitle == "" {
+				if stored != nil {
+					t.Errorf("stored %+v, want no translation", stored)
+				}
+				return
+			}
+			if stored.Title != tt.wantTitle {
+				t.Errorf("stored %q, want %q", stored.Title, tt.wantTitle)
+			}
+			if tt.wantCalls > 0 && !stored.SourceUpdatedAt.Equal(updated) {
+				t.Errorf("stored translation of the blog at %v, want %v", stored.SourceUpdatedAt, updated)
+			}
+		})
+	}
+}
+
+func TestSaveTranslation(t *testing.T) {
+	author := primitive.NewObjectID()
+	machine := &domain.BlogTranslation{Language: "de", Title: "Zwischenspeicher", Content: "Warm halten.", Origin: domain.TranslationMachine, Provider: "openai"}
+
+	tests := []struct {
+		name         string
+		userID       string
+		existing     *domain.BlogTranslation
+		language     string
+		title        string
+		wantErr      error
+		wantProvider string
+	}{
+		{"new translation", author.Hex(), nil, "de", "Caching", nil, ""},
+		{"correction keeps the provider", author.Hex(), machine, "DE", "Caching", nil, "openai"},
+		{"no title", author.Hex(), nil, "de", "  ", ErrInvalidTranslation, ""},
+		{"blog language", author.Hex(), nil, "en", "Caching", ErrSameLanguage, ""},
+		{"someone else's blog", primitive.NewObjectID().Hex(), nil, "de", "Caching", ErrBlogForbidden, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blog := &domain.Blog{ID: primitive.NewObjectID(), AuthorID: author, Title: "Caching", Content: "Keep it warm.", Status: domain.BlogStatusPublished, UpdatedAt: time.Now()}
+			translations := newFakeTranslationRepo()
+			if tt.existing != nil {
+				existing := *tt.existing
+				existing.BlogID = blog.ID
+				translations.Save(&existing)
+			}
+			uc := &BlogUseCase{Repo: newFakeBlogRepo(blog), TranslationRepo: translations}
+
+			_, err := uc.SaveTranslation(blog.ID.Hex(), tt.language, tt.userID, domain.RoleAuthor, tt.title, "**Warm** halten.")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			stored, _ := translations.Get(blog.ID, "de")
+			if err != nil {
+				if stored != nil && stored.Origin != domain.TranslationMachine {
+					t.Errorf("a failed save stored %+v", stored)
+				}
+				return
+			}
+			if stored.Origin != domain.TranslationHuman || stored.EditedBy != author || stored.Provider != tt.wantProvider {
+				t.Errorf("stored %+v, want a human translation by %s from %q", stored, author.Hex(), tt.wantProvider)
+			}
+			if stored.ContentHTML != "<p><strong>Warm</strong> halten.</p>\n" {
+				t.Errorf("rendered %q", stored.ContentHTML)
+			}
+		})
+	}
+}
+
+func TestViewBlogInLanguage(t *testing.T) {
+	now := time.Now()
+	blog := &domain.Blog{ID: primitive.NewObjectID(), Title: "Caching", Content: "Keep it warm.", Status: domain.BlogStatusPublished, RenderVersion: infrastructure.MarkdownRendererVersion, UpdatedAt: now}
+	translations := newFakeTranslationRepo(
+		&domain.BlogTranslation{BlogID: blog.ID, Language: "fr", Title: "Mise en cache", SourceUpdatedAt: now},
+		&domain.BlogTranslation{BlogID: blog.ID, Language: "pt-br", Title: "Cache", SourceUpdatedAt: now.Add(-time.Hour)},
+	)
+
+	tests := []struct {
+		name           string
+		lang           string
+		acceptLanguage string
+		wantTitle      string
+		wantStale      bool
+	}{
+		{"no preference", "", "", "Caching", false},
+		{"lang parameter", "fr", "pt-BR", "Mise en cache", false},
+		{"lang parameter without translation", "de", "fr", "Caching", false},
+		{"accept language", "", "de, pt-BR;q=0.9, fr;q=0.5", "Cache", true},
+		{"base language match", "", "pt", "Cache", true},
+		{"own language first", "", "en-GB, fr;q=0.8", "Caching", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored := *blog
+			uc := &BlogUseCase{Repo: newFakeBlogRepo(&stored), InteractionRepo: ignoreViews{}, TranslationRepo: translations}
+
+			got := uc.ViewBlogInLanguage(blog.ID.Hex(), "", domain.RoleUser, tt.lang, tt.acceptLanguage)
+			if got == nil {
+				t.Fatal("blog not found")
+			}
+			if got.Title != tt.wantTitle {
+				t.Errorf("title = %q, want %q", got.Title, tt.wantTitle)
+			}
+			wantTranslated := tt.wantTitle != blog.Title
+			if translated := got.Translation != nil; translated != wantTranslated {
+				t.Errorf("translation = %+v, want set %v", got.Translation, wantTranslated)
+			} else if translated && got.Translation.Stale != tt.wantStale {
+				t.Errorf("stale = %v, want %v", got.Translation.Stale, tt.wantStale)
+			}
+		})
+	}
+}
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{"", []string{}},
+		{"fr", []string{"fr"}},
+		{"de;q=0.5, pt_BR, *;q=0.1", []string{"pt-br", "de", "*"}},
+		{"en;q=0, es;q=bad, it;q=0.3", []string{"it"}},
+		{"not a language!, fr;q=0.9", []string{"fr"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := parseAcceptLanguage(tt.header); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseAcceptLanguage(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchTranslation(t *testing.T) {
+	blog := &domain.Blog{Language: "pt"}
+	translations := []*domain.BlogTranslation{{Language: "en-us"}, {Language: "fr"}}
+
+	tests := []struct {
+		name        string
+		preferences []string
+		want        string
+	}{
+		{"exact", []string{"fr"}, "fr"},
+		{"base language", []string{"en-gb"}, "en-us"},
+		{"first preference wins", []string{"de", "fr", "en"}, "fr"},
+		{"own language", []string{"pt-br", "fr"}, ""},
+		{"any language", []string{"*", "fr"}, ""},
+		{"no match", []string{"de"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			if translation := matchTranslation(blog, tt.preferences, translations); translation != nil {
+				got = translation.Language
+			}
+			if got != tt.want {
+				t.Errorf("matchTranslation(%v) = %q, want %q", tt.preferences, got, tt.want)
+			}
+		})
+	}
+}
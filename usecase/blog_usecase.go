@@ -1,24 +1,51 @@
 package usecase
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/sol-tad/Blog-post-Api/domain"
+	"github.com/sol-tad/Blog-post-Api/infrastructure"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrBlogNotFound         = errors.New("blog not found")
+	ErrBlogForbidden        = errors.New("you are not allowed to change this blog")
+	ErrInvalidBlogStatus    = errors.New("invalid blog status")
+	ErrInvalidTransition    = errors.New("blog cannot move to the requested status")
+	ErrInvalidPublishTime   = errors.New("publish time must be in the future")
+	ErrNotScheduled         = errors.New("blog is not scheduled for publishing")
+	ErrInvalidCommentPolicy = errors.New("comment policy must be open, moderated or closed")
+	ErrAIReviewPending      = errors.New("AI generated blog must be confirmed by its author first")
+	ErrNotAIDraft           = errors.New("blog was not generated by AI")
+	ErrModerationHold       = errors.New("blog was flagged by moderation and must be published by a moderator")
+	ErrSlugUnavailable      = errors.New("too many blogs share this title; choose another title")
 )
 
 type BlogUseCase struct {
 	Repo IBlogRepo
 	InteractionRepo domain.InteractionRepository
 	UserRepo domain.UserRepository
+	RevisionRepo domain.BlogRevisionRepository
+	BookmarkRepo domain.BookmarkRepository
+	TranslationRepo domain.BlogTranslationRepository
+	Moderator domain.ContentModerator
+	Search *SemanticSearchUsecase
 }
 
-func NewBlogUseCase(repo IBlogRepo, interactionRepo domain.InteractionRepository,urepo domain.UserRepository) *BlogUseCase {
+func NewBlogUseCase(repo IBlogRepo, interactionRepo domain.InteractionRepository,urepo domain.UserRepository, revisionRepo domain.BlogRevisionRepository, bookmarkRepo domain.BookmarkRepository, translationRepo domain.BlogTranslationRepository, moderator domain.ContentModerator, search *SemanticSearchUsecase) *BlogUseCase {
 	return &BlogUseCase{
 		Repo: repo,
 		InteractionRepo: interactionRepo,
 		UserRepo: urepo,
+		RevisionRepo: revisionRepo,
+		BookmarkRepo: bookmarkRepo,
+		TranslationRepo: translationRepo,
+		Moderator: moderator,
+		Search: search,
 	}
 }
 
@@ -32,14 +59,64 @@ func (b *BlogUseCase) StoreBlog(blog *domain.Blog) error {
 		Dislikes: 0,
 		Comments: 0,
 	}
+	if blog.Status == "" || blog.NeedsAIReview() {
+		blog.Status = domain.BlogStatusDraft
+	}
+	// Flagged blogs wait in review instead of going live
+	blog.Moderation = moderate(b.Moderator, blogText(blog))
+	if blog.Moderation.Flagged() && (blog.Status == domain.BlogStatusPublished || blog.Status == domain.BlogStatusScheduled) {
+		blog.Status = domain.BlogStatusInReview
+	}
+	switch blog.Status {
+	case domain.BlogStatusDraft, domain.BlogStatusInReview:
+		blog.PublishedAt = nil
+		blog.PublishAt = nil
+	case domain.BlogStatusScheduled:
+		if blog.PublishAt == nil || !blog.PublishAt.After(time.Now()) {
+			return ErrInvalidPublishTime
+		}
+		blog.PublishedAt = nil
+	case domain.BlogStatusPublished:
+		now := time.Now()
+		blog.PublishedAt = &now
+		blog.PublishAt = nil
+	default:
+		return ErrInvalidBlogStatus
+	}
+	if blog.CommentPolicy != "" && !domain.IsValidCommentPolicy(blog.CommentPolicy) {
+		return ErrInvalidCommentPolicy
+	}
+	if blog.Language != "" {
+		language, ok := domain.NormalizeLanguage(blog.Language)
+		if !ok {
+			return ErrInvalidLanguage
+		}
+		blog.Language = language
+	}
+	blog.OldSlugs = nil
+	renderContent(blog)
 	author := b.UserRepo.GetByID(blog.AuthorID)
 	blog.AuthorName = author.Username
-	err := b.Repo.StoreBlog(blog)
-	if err != nil {
-		fmt.Println("blog insertion failed")
-		return err
+	// The unique index on slugs turns away a blog that took the same slug
+	// in the meantime; it gets the next free one
+	for attempt := 1; ; attempt++ {
+		slug, err := b.uniqueSlug(blog.Title, primitive.NilObjectID)
+		if err != nil {
+			return err
+		}
+		blog.Slug = slug
+		err = b.Repo.StoreBlog(blog)
+		if mongo.IsDuplicateKeyError(err) && attempt < maxSlugAttempts {
+			continue
+		}
+		if err != nil {
+			fmt.Println("blog insertion failed")
+			return err
+		}
+		break
 	}
 	fmt.Println("Inserted a blog")
+	b.Search.indexInBackground(blog)
 	return nil
 
 }
@@ -55,6 +132,52 @@ func (b *BlogUseCase) ViewBlogByID(blogID string)*domain.Blog{
 
 	return result
 }
+// ViewBlogForUser looks a blog up by ID or slug and returns it only if the
+// viewer is allowed to read it. Old slugs resolve to the renamed blog.
+func (b *BlogUseCase) ViewBlogForUser(idOrSlug, userID, role string) *domain.Blog {
+	result := b.findVisibleBlog(idOrSlug, userID, role)
+	if result == nil {
+		return nil
+	}
+	b.ensureRendered(result)
+	go b.TrackView(result.ID.Hex())
+	return result
+}
+
+// findVisibleBlog looks a blog up by ID or slug without counting a view
+func (b *BlogUseCase) findVisibleBlog(idOrSlug, userID, role string) *domain.Blog {
+	var result *domain.Blog
+	if id, err := primitive.ObjectIDFromHex(idOrSlug); err == nil {
+		result = b.Repo.ViewBlogByID(id)
+	}
+	if result == nil {
+		result, _ = b.Repo.FindBySlug(idOrSlug)
+	}
+
+	if result == nil || !result.VisibleTo(userID, role) {
+		return nil
+	}
+	return result
+}
+
+// ensureRendered refreshes the cached HTML of blogs stored before rendering
+// existed or rendered by an older version of the renderer
+func (b *BlogUseCase) ensureRendered(blog *domain.Blog) {
+	if blog.RenderVersion == infrastructure.MarkdownRendererVersion {
+		return
+	}
+	renderContent(blog)
+	if err := b.Repo.SaveRenderedContent(blog.ID, blog.ContentHTML, blog.RenderVersion); err != nil {
+		fmt.Println("saving rendered content failed:", err)
+	}
+}
+
+// renderContent renders the blog's Markdown content into sanitized HTML
+func renderContent(blog *domain.Blog) {
+	blog.ContentHTML = infrastructure.RenderMarkdown(blog.Content)
+	blog.RenderVersion = infrastructure.MarkdownRendererVersion
+}
+
 func (b *BlogUseCase) GetBlogByAuthor(author string, page, limit int) ([]*domain.Blog, error){
 	 if page < 1 {page = 1}
 	 if limit <1 || limit > 50 {limit = 10}
@@ -69,23 +192,113 @@ func (b *BlogUseCase) ViewBlogs() []domain.Blog{
 }
 
 // there will be the updater and the blog's author
-func (b *BlogUseCase) UpdateBlog(blogID string ,updatedBlog *domain.Blog) error{
+func (b *BlogUseCase) UpdateBlog(blogID, editorID string ,updatedBlog *domain.Blog) error{
 	// b.Repo.CheckUserAuthority(blog , blog.User)
 	id , err := primitive.ObjectIDFromHex(blogID)
 	if err != nil{
 		return err
 	}
+
+	previous := b.Repo.ViewBlogByID(id)
+	if previous == nil {
+		return ErrBlogNotFound
+	}
+
+	// Keep the previous version so the edit can be undone
+	if err := b.saveRevision(previous, editorID); err != nil {
+		return err
+	}
+
+	// A new title gets a new slug; the old one keeps working as a redirect
+	newSlug := previous.Slug == "" || updatedBlog.Title != previous.Title
+	if !newSlug {
+		updatedBlog.Slug = previous.Slug
+		updatedBlog.OldSlugs = previous.OldSlugs
+	}
+
+	// Edits are checked like new blogs, so a live blog cannot be changed
+	// into one moderation would have held
+	if text := blogText(updatedBlog); text != blogText(previous) {
+		updatedBlog.Moderation = moderate(b.Moderator, text)
+	} else {
+		updatedBlog.Moderation = previous.Moderation
+	}
+
+	renderContent(updatedBlog)
 	updatedBlog.UpdatedAt = time.Now()
-	result := b.Repo.UpdateBlog(id, updatedBlog)
-	return result
+	for attempt := 1; ; attempt++ {
+		if newSlug {
+			slug, err := b.uniqueSlug(updatedBlog.Title, id)
+			if err != nil {
+				return err
+			}
+			updatedBlog.Slug = slug
+			updatedBlog.OldSlugs = retireSlug(previous.OldSlugs, previous.Slug, slug)
+		}
+		err := b.Repo.UpdateBlog(id, updatedBlog)
+		if newSlug && mongo.IsDuplicateKeyError(err) && attempt < maxSlugAttempts {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		break
+	}
+	if err := b.holdFlaggedEdit(id, previous, updatedBlog); err != nil {
+		return err
+	}
+	indexed := *updatedBlog
+	indexed.ID = id
+	b.Search.indexInBackground(&indexed)
+
+	// A summary of the old content no longer describes the blog
+	if previous.Summary != nil && updatedBlog.Content != previous.Content {
+		updatedBlog.Summary = nil
+		return b.Repo.SaveSummary(id, nil)
+	}
+	return nil
+}
+
+// EditBlog changes the title, content and tags of a blog the user may edit
+func (b *BlogUseCase) EditBlog(blogID, userID, role string, changes *domain.Blog) (*domain.Blog, error) {
+	_, blog, err := b.ownedBlog(blogID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	blog.Title = changes.Title
+	blog.Content = changes.Content
+	blog.Tags = changes.Tags
+	if err := b.UpdateBlog(blogID, userID, blog); err != nil {
+		return nil, err
+	}
+	return blog, nil
 }
 
-func(b *BlogUseCase) DeleteBlog(blogID string) error {
+// DeleteBlog deletes a blog. Only its author or a role that may delete any
+// blog may delete it.
+func(b *BlogUseCase) DeleteBlog(blogID, userID, role string) error {
 	id, err := primitive.ObjectIDFromHex(blogID)
 	if err != nil{
-		return err 
+		return ErrBlogNotFound
+	}
+	blog := b.Repo.ViewBlogByID(id)
+	if blog == nil || !blog.VisibleTo(userID, role) {
+		return ErrBlogNotFound
 	}
-	return b.Repo.DeleteBlog(id)
+	if !domain.CanActOn(userID, role, blog.AuthorID, domain.PermBlogsDeleteAny) {
+		return ErrBlogForbidden
+	}
+	if err := b.Repo.DeleteBlog(id); err != nil {
+		return err
+	}
+	// Drop the blog from everyone's bookmarks and reading lists
+	if err := b.BookmarkRepo.DeleteByBlog(id); err != nil {
+		return err
+	}
+	if err := b.TranslationRepo.DeleteByBlog(id); err != nil {
+		return err
+	}
+	return b.Search.RemoveBlog(id)
 
 }
 func (b *BlogUseCase) ListBlogs(page, limit int, filter domain.BlogFilter) ([]*domain.Blog, int64, error) {
@@ -97,5 +310,290 @@ func (b *BlogUseCase) ListBlogs(page, limit int, filter domain.BlogFilter) ([]*d
 		filter.SortOrder = "desc"
 	}
 	
-	return b.Repo.List(page, limit, filter)
-}
\ No newline at end of file
+	blogs, total, err := b.Repo.List(page, limit, filter)
+	return withExcerpts(blogs), total, err
+}
+
+// withExcerpts fills in the excerpt of listed blogs from their summaries
+func withExcerpts(blogs []*domain.Blog) []*domain.Blog {
+	for _, blog := range blogs {
+		if summary := blog.CurrentSummary(); summary != nil {
+			blog.Excerpt = summary.Text
+		}
+	}
+	return blogs
+}
+
+// ChangeStatus moves a blog through its lifecycle. Only the author or a
+// role that may edit any blog may change the status of a blog; a role that
+// may publish flagged blogs may also publish one held by moderation.
+func (b *BlogUseCase) ChangeStatus(blogID, userID, role, status string) (*domain.Blog, error) {
+	if !domain.IsValidBlogStatus(status) {
+		return nil, ErrInvalidBlogStatus
+	}
+	// Scheduling needs a publish time and goes through SchedulePublish
+	if status == domain.BlogStatusScheduled {
+		return nil, ErrInvalidTransition
+	}
+
+	load := b.ownedBlog
+	if status == domain.BlogStatusPublished {
+		load = b.releasableBlog
+	}
+	id, blog, err := load(blogID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	current := blog.CurrentStatus()
+	if !domain.CanTransitionBlog(current, status) {
+		return nil, ErrInvalidTransition
+	}
+	if blog.NeedsAIReview() && status != domain.BlogStatusDraft && status != domain.BlogStatusArchived {
+		return nil, ErrAIReviewPending
+	}
+	if blog.Moderation.Flagged() && !domain.RoleHas(role, domain.PermBlogsPublishFlagged) && status == domain.BlogStatusPublished {
+		return nil, ErrModerationHold
+	}
+
+	now := time.Now()
+	if err := b.Repo.TransitionStatus(id, current, status, now); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrInvalidTransition
+		}
+		return nil, err
+	}
+
+	blog.Status = status
+	blog.UpdatedAt = now
+	blog.PublishAt = nil
+	if status == domain.BlogStatusPublished {
+		blog.PublishedAt = &now
+	}
+	return blog, nil
+}
+
+// SchedulePublish schedules a blog to be published at publishAt. Calling it
+// again on a scheduled blog reschedules it.
+func (b *BlogUseCase) SchedulePublish(blogID, userID, role string, publishAt time.Time) (*domain.Blog, error) {
+	if !publishAt.After(time.Now()) {
+		return nil, ErrInvalidPublishTime
+	}
+
+	id, blog, err := b.releasableBlog(blogID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	current := blog.CurrentStatus()
+	if !domain.CanTransitionBlog(current, domain.BlogStatusScheduled) {
+		return nil, ErrInvalidTransition
+	}
+	if blog.NeedsAIReview() {
+		return nil, ErrAIReviewPending
+	}
+	if blog.Moderation.Flagged() && !domain.RoleHas(role, domain.PermBlogsPublishFlagged) {
+		return nil, ErrModerationHold
+	}
+
+	now := time.Now()
+	if err := b.Repo.SchedulePublish(id, current, publishAt, now); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrInvalidTransition
+		}
+		return nil, err
+	}
+
+	blog.Status = domain.BlogStatusScheduled
+	blog.PublishAt = &publishAt
+	blog.UpdatedAt = now
+	return blog, nil
+}
+
+// ReschedulePublish changes the publish time of an already scheduled blog
+func (b *BlogUseCase) ReschedulePublish(blogID, userID, role string, publishAt time.Time) (*domain.Blog, error) {
+	if err := b.requireScheduled(blogID, userID, role); err != nil {
+		return nil, err
+	}
+	return b.SchedulePublish(blogID, userID, role, publishAt)
+}
+
+// CancelSchedule returns a scheduled blog to draft
+func (b *BlogUseCase) CancelSchedule(blogID, userID, role string) (*domain.Blog, error) {
+	if err := b.requireScheduled(blogID, userID, role); err != nil {
+		return nil, err
+	}
+	return b.ChangeStatus(blogID, userID, role, domain.BlogStatusDraft)
+}
+
+func (b *BlogUseCase) requireScheduled(blogID, userID, role string) error {
+	_, blog, err := b.ownedBlog(blogID, userID, role)
+	if err != nil {
+		return err
+	}
+	if blog.CurrentStatus() != domain.BlogStatusScheduled {
+		return ErrNotScheduled
+	}
+	return nil
+}
+
+// holdFlaggedEdit takes a published or scheduled blog whose edit was
+// flagged back into review, where it waits for a moderator
+func (b *BlogUseCase) holdFlaggedEdit(id primitive.ObjectID, previous, updated *domain.Blog) error {
+	current := previous.CurrentStatus()
+	if !updated.Moderation.Flagged() || (current != domain.BlogStatusPublished && current != domain.BlogStatusScheduled) {
+		return nil
+	}
+	now := time.Now()
+	if err := b.Repo.TransitionStatus(id, current, domain.BlogStatusInReview, now); err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+	updated.Status = domain.BlogStatusInReview
+	updated.PublishAt = nil
+	return nil
+}
+
+// ownedBlog loads a blog that the user is allowed to manage: their own, or
+// any blog if their role may edit others' blogs
+func (b *BlogUseCase) ownedBlog(blogID, userID, role string) (primitive.ObjectID, *domain.Blog, error) {
+	id, blog, err := b.visibleBlog(blogID, userID, role)
+	if err != nil {
+		return id, nil, err
+	}
+	if !domain.CanActOn(userID, role, blog.AuthorID, domain.PermBlogsEditAny) {
+		return id, nil, ErrBlogForbidden
+	}
+	return id, blog, nil
+}
+
+// releasableBlog loads a blog that the user is allowed to publish: one they
+// may manage, or one held by moderation if their role may release it
+func (b *BlogUseCase) releasableBlog(blogID, userID, role string) (primitive.ObjectID, *domain.Blog, error) {
+	id, blog, err := b.visibleBlog(blogID, userID, role)
+	if err != nil {
+		return id, nil, err
+	}
+	if !domain.CanActOn(userID, role, blog.AuthorID, domain.PermBlogsEditAny) &&
+		!(blog.Moderation.Flagged() && domain.RoleHas(role, domain.PermBlogsPublishFlagged)) {
+		return id, nil, ErrBlogForbidden
+	}
+	return id, blog, nil
+}
+
+func (b *BlogUseCase) visibleBlog(blogID, userID, role string) (primitive.ObjectID, *domain.Blog, error) {
+	id, err := primitive.ObjectIDFromHex(blogID)
+	if err != nil {
+		return id, nil, ErrBlogNotFound
+	}
+
+	blog := b.Repo.ViewBlogByID(id)
+	if blog == nil || !blog.VisibleTo(userID, role) {
+		return id, nil, ErrBlogNotFound
+	}
+	return id, blog, nil
+}
+
+// SetCommentPolicy changes how new comments on the blog are handled
+func (b *BlogUseCase) SetCommentPolicy(blogID, userID, role, policy string) (*domain.Blog, error) {
+	if !domain.IsValidCommentPolicy(policy) {
+		return nil, ErrInvalidCommentPolicy
+	}
+	id, blog, err := b.ownedBlog(blogID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.Repo.SetCommentPolicy(id, policy); err != nil {
+		return nil, err
+	}
+	blog.CommentPolicy = policy
+	return blog, nil
+}
+
+// ConfirmAIDraft records that the author reviewed an AI generated blog,
+// which allows it to be submitted and published like any other blog
+func (b *BlogUseCase) ConfirmAIDraft(blogID, userID, role string) (*domain.Blog, error) {
+	id, blog, err := b.ownedBlog(blogID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	if blog.AI == nil {
+		return nil, ErrNotAIDraft
+	}
+	if blog.AI.Reviewed {
+		return blog, nil
+	}
+
+	now := time.Now()
+	if err := b.Repo.ConfirmAIReview(id, now); err != nil {
+		return nil, err
+	}
+	blog.AI.Reviewed = true
+	blog.AI.ReviewedAt = &now
+	blog.UpdatedAt = now
+	return blog, nil
+}
+
+// ListMyBlogs lists the blogs written by the user, including unpublished ones
+func (b *BlogUseCase) ListMyBlogs(userID string, page, limit int, status string) ([]*domain.Blog, int64, error) {
+	if status != "" && !domain.IsValidBlogStatus(status) {
+		return nil, 0, ErrInvalidBlogStatus
+	}
+	authorID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	filter := domain.BlogFilter{
+		SortBy:     "created_at",
+		SortOrder:  "desc",
+		Status:     status,
+		ViewerID:   authorID,
+		OnlyViewer: true,
+	}
+	blogs, total, err := b.Repo.List(page, limit, filter)
+	return withExcerpts(blogs), total, err
+}
+
+// maxSlugAttempts bounds how often a write is retried when another blog
+// took the chosen slug between the check and the write
+const maxSlugAttempts = 5
+
+// maxSlugCandidates bounds how many numbered slugs are tried for a title
+// before giving up
+const maxSlugCandidates = 20
+
+// uniqueSlug builds a slug from the title, adding a numeric suffix when
+// another blog uses it, currently or as an old slug, or the slug is
+// reserved for a route
+func (b *BlogUseCase) uniqueSlug(title string, exclude primitive.ObjectID) (string, error) {
+	base := infrastructure.Slugify(title)
+	slug := base
+	for n := 2; n <= maxSlugCandidates+1; n++ {
+		if !domain.IsReservedBlogSlug(slug) {
+			taken, err := b.Repo.SlugTaken(slug, exclude)
+			if err != nil {
+				return "", err
+			}
+			if !taken {
+				return slug, nil
+			}
+		}
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+	return "", ErrSlugUnavailable
+}
+
+// retireSlug adds the replaced slug to the old slugs, dropping the new slug
+// in case the blog went back to an earlier title
+func retireSlug(oldSlugs []string, replaced, current string) []string {
+	result := make([]string, 0, len(oldSlugs)+1)
+	for _, s := range oldSlugs {
+		if s != current && s != replaced {
+			result = append(result, s)
+		}
+	}
+	if replaced != "" && replaced != current {
+		result = append(result, replaced)
+	}
+	return result
+}
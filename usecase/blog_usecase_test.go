@@ -0,0 +1,224 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// fakeBlogRepo keeps blogs in memory. Methods a test does not need are left
+// to the embedded interface and panic when called.
+type fakeBlogRepo struct {
+	IBlogRepo
+	blogs map[primitive.ObjectID]*domain.Blog
+	// racedSlugs are taken by another writer right after they were checked
+	racedSlugs map[string]bool
+	// failPublish is returned by PublishNextDue
+	failPublish error
+}
+
+func newFakeBlogRepo(blogs ...*domain.Blog) *fakeBlogRepo {
+	repo := &fakeBlogRepo{blogs: make(map[primitive.ObjectID]*domain.Blog)}
+	for _, blog := range blogs {
+		repo.blogs[blog.ID] = blog
+	}
+	return repo
+}
+
+func (r *fakeBlogRepo) ViewBlogByID(id primitive.ObjectID) *domain.Blog {
+	blog, ok := r.blogs[id]
+	if !ok {
+		return nil
+	}
+	copied := *blog
+	return &copied
+}
+
+func (r *fakeBlogRepo) TransitionStatus(id primitive.ObjectID, from, to string, at time.Time) error {
+	blog := r.blogs[id]
+	blog.Status = to
+	blog.UpdatedAt = at
+	return nil
+}
+
+func (r *fakeBlogRepo) UpdateBlog(id primitive.ObjectID, updated *domain.Blog) error {
+	blog := r.blogs[id]
+	blog.Title = updated.Title
+	blog.Content = updated.Content
+	blog.Tags = updated.Tags
+	blog.Slug = updated.Slug
+	blog.Moderation = updated.Moderation
+	return nil
+}
+
+// StoreBlog rejects a slug that is already taken, like the unique index
+func (r *fakeBlogRepo) StoreBlog(blog *domain.Blog) error {
+	if r.racedSlugs[blog.Slug] {
+		delete(r.racedSlugs, blog.Slug)
+		other := primitive.NewObjectID()
+		r.blogs[other] = &domain.Blog{ID: other, Slug: blog.Slug}
+	}
+	for _, existing := range r.blogs {
+		if existing.Slug == blog.Slug {
+			return mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000, Message: "duplicate key"}}}
+		}
+	}
+	blog.ID = primitive.NewObjectID()
+	copied := *blog
+	r.blogs[blog.ID] = &copied
+	return nil
+}
+
+func (r *fakeBlogRepo) SlugTaken(slug string, exclude primitive.ObjectID) (bool, error) {
+	for id, blog := range r.blogs {
+		if id != exclude && (blog.Slug == slug || slices.Contains(blog.OldSlugs, slug)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeBlogRepo) SaveSummary(id primitive.ObjectID, summary *domain.BlogSummary) error {
+	r.blogs[id].Summary = summary
+	return nil
+}
+
+func (r *fakeBlogRepo) SchedulePublish(id primitive.ObjectID, from string, publishAt, at time.Time) error {
+	blog := r.blogs[id]
+	blog.Status = domain.BlogStatusScheduled
+	blog.PublishAt = &publishAt
+	return nil
+}
+
+func TestChangeStatusPermissions(t *testing.T) {
+	author := primitive.NewObjectID()
+	other := primitive.NewObjectID().Hex()
+	flagged := &domain.ModerationVerdict{Verdict: domain.ModerationFlag}
+
+	tests := []struct {
+		name    string
+		blog    domain.Blog
+		userID  string
+		role    string
+		status  string
+		wantErr error
+	}{
+		{"author publishes own draft", domain.Blog{Status: domain.BlogStatusDraft}, author.Hex(), domain.RoleAuthor, domain.BlogStatusPublished, nil},
+		{"author cannot publish a flagged blog", domain.Blog{Status: domain.BlogStatusInReview, Moderation: flagged}, author.Hex(), domain.RoleAuthor, domain.BlogStatusPublished, ErrModerationHold},
+		{"other author cannot see a draft", domain.Blog{Status: domain.BlogStatusDraft}, other, domain.RoleAuthor, domain.BlogStatusPublished, ErrBlogNotFound},
+		{"editor publishes any draft", domain.Blog{Status: domain.BlogStatusDraft}, other, domain.RoleEditor, domain.BlogStatusPublished, nil},
+		{"editor cannot release a flagged blog", domain.Blog{Status: domain.BlogStatusInReview, Moderation: flagged}, other, domain.RoleEditor, domain.BlogStatusPublished, ErrModerationHold},
+		{"moderator releases a flagged blog", domain.Blog{Status: domain.BlogStatusInReview, Moderation: flagged}, other, domain.RoleModerator, domain.BlogStatusPublished, nil},
+		{"moderator cannot publish an unflagged draft", domain.Blog{Status: domain.BlogStatusDraft}, other, domain.RoleModerator, domain.BlogStatusPublished, ErrBlogForbidden},
+		{"moderator cannot archive a flagged blog", domain.Blog{Status: domain.BlogStatusInReview, Moderation: flagged}, other, domain.RoleModerator, domain.BlogStatusDraft, ErrBlogForbidden},
+		{"admin releases a flagged blog", domain.Blog{Status: domain.BlogStatusInReview, Moderation: flagged}, other, domain.RoleAdmin, domain.BlogStatusPublished, nil},
+		{"invalid transition", domain.Blog{Status: domain.BlogStatusPublished}, author.Hex(), domain.RoleAuthor, domain.BlogStatusInReview, ErrInvalidTransition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blog := tt.blog
+			blog.ID = primitive.NewObjectID()
+			blog.AuthorID = author
+			uc := &BlogUseCase{Repo: newFakeBlogRepo(&blog)}
+
+			got, err := uc.ChangeStatus(blog.ID.Hex(), tt.userID, tt.role, tt.status)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got.Status != tt.status {
+				t.Errorf("status = %q, want %q", got.Status, tt.status)
+			}
+		})
+	}
+}
+
+func TestSchedulePublishLetsModeratorsReleaseFlaggedBlogs(t *testing.T) {
+	blog := &domain.Blog{
+		ID:         primitive.NewObjectID(),
+		AuthorID:   primitive.NewObjectID(),
+		Status:     domain.BlogStatusInReview,
+		Moderation: &domain.ModerationVerdict{Verdict: domain.ModerationFlag},
+	}
+	uc := &BlogUseCase{Repo: newFakeBlogRepo(blog)}
+
+	got, err := uc.SchedulePublish(blog.ID.Hex(), primitive.NewObjectID().Hex(), domain.RoleModerator, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != domain.BlogStatusScheduled {
+		t.Errorf("status = %q, want %q", got.Status, domain.BlogStatusScheduled)
+	}
+}
+
+func TestUniqueSlug(t *testing.T) {
+	existing := &domain.Blog{ID: primitive.NewObjectID(), Slug: "hello-world"}
+	renamed := &domain.Blog{ID: primitive.NewObjectID(), Slug: "go-in-practice", OldSlugs: []string{"go-basics"}}
+
+	tests := []struct {
+		name    string
+		title   string
+		exclude primitive.ObjectID
+		want    string
+	}{
+		{"free slug", "Go Tips", primitive.NilObjectID, "go-tips"},
+		{"taken slug", "Hello, World!", primitive.NilObjectID, "hello-world-2"},
+		{"own slug", "Hello World", existing.ID, "hello-world"},
+		{"old slug of another blog", "Go Basics", primitive.NilObjectID, "go-basics-2"},
+		{"own old slug", "Go Basics", renamed.ID, "go-basics"},
+		{"reserved for my blogs", "Mine", primitive.NilObjectID, "mine-2"},
+		{"reserved for semantic search", "Semantic Search", primitive.NilObjectID, "semantic-search-2"},
+		{"reserved for creating blogs", "Create", primitive.NilObjectID, "create-2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &BlogUseCase{Repo: newFakeBlogRepo(existing, renamed)}
+
+			got, err := uc.uniqueSlug(tt.title, tt.exclude)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("slug = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUniqueSlugGivesUp(t *testing.T) {
+	blogs := []*domain.Blog{{ID: primitive.NewObjectID(), Slug: "intro"}}
+	for n := 2; n <= maxSlugCandidates; n++ {
+		blogs = append(blogs, &domain.Blog{ID: primitive.NewObjectID(), Slug: fmt.Sprintf("intro-%d", n)})
+	}
+	uc := &BlogUseCase{Repo: newFakeBlogRepo(blogs...)}
+
+	if got, err := uc.uniqueSlug("Intro", primitive.NilObjectID); !errors.Is(err, ErrSlugUnavailable) {
+		t.Errorf("uniqueSlug() = %q, %v; want %v", got, err, ErrSlugUnavailable)
+	}
+	// The last candidate is still tried
+	last := fmt.Sprintf("intro-%d", maxSlugCandidates)
+	uc.Repo = newFakeBlogRepo(blogs[:len(blogs)-1]...)
+	if got, err := uc.uniqueSlug("Intro", primitive.NilObjectID); err != nil || got != last {
+		t.Errorf("uniqueSlug() = %q, %v; want %q", got, err, last)
+	}
+}
+
+func TestStoreBlogRetriesSlugTakenConcurrently(t *testing.T) {
+	author := &domain.User{ID: primitive.NewObjectID(), Username: "writer"}
+	repo := newFakeBlogRepo()
+	repo.racedSlugs = map[string]bool{"go-tips": true}
+	uc := &BlogUseCase{Repo: repo, UserRepo: newFakeUserRepo(author)}
+
+	blog := &domain.Blog{Title: "Go Tips", Content: "content", AuthorID: author.ID}
+	if err := uc.StoreBlog(blog); err != nil {
+		t.Fatal(err)
+	}
+	if blog.Slug != "go-tips-2" {
+		t.Errorf("slug = %q, want %q", blog.Slug, "go-tips-2")
+	}
+}
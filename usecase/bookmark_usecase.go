@@ -0,0 +1,209 @@
+package usecase
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrBookmarkNotFound    = errors.New("bookmark not found")
+	ErrReadingListNotFound = errors.New("reading list not found")
+	ErrInvalidListName     = errors.New("reading list name is required")
+)
+
+type BookmarkUsecase struct {
+	bookmarkRepo domain.BookmarkRepository
+	blogRepo     IBlogRepo
+}
+
+func NewBookmarkUsecase(bookmarkRepo domain.BookmarkRepository, blogRepo IBlogRepo) *BookmarkUsecase {
+	return &BookmarkUsecase{
+		bookmarkRepo: bookmarkRepo,
+		blogRepo:     blogRepo,
+	}
+}
+
+// AddBookmark saves a blog to the user's default list or to one of their
+// reading lists when listID is set
+func (uc *BookmarkUsecase) AddBookmark(userID, blogID, listID, role string) (*domain.Bookmark, error) {
+	user, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+	list, err := uc.ownedList(user, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	blogObjID, err := primitive.ObjectIDFromHex(blogID)
+	if err != nil {
+		return nil, ErrBlogNotFound
+	}
+	blog := uc.blogRepo.ViewBlogByID(blogObjID)
+	if blog == nil || !blog.VisibleTo(userID, role) {
+		return nil, ErrBlogNotFound
+	}
+
+	bookmark := &domain.Bookmark{
+		UserID:    user,
+		BlogID:    blogObjID,
+		ListID:    list,
+		CreatedAt: time.Now(),
+	}
+	if err := uc.bookmarkRepo.Add(bookmark); err != nil {
+		return nil, err
+	}
+	bookmark.Blog = blog
+	return bookmark, nil
+}
+
+func (uc *BookmarkUsecase) RemoveBookmark(userID, blogID, listID string) error {
+	user, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+	list, err := uc.ownedList(user, listID)
+	if err != nil {
+		return err
+	}
+	blogObjID, err := primitive.ObjectIDFromHex(blogID)
+	if err != nil {
+		return ErrBookmarkNotFound
+	}
+
+	if err := uc.bookmarkRepo.Remove(user, blogObjID, list); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrBookmarkNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// ListBookmarks returns a page of the list's bookmarks in the user's order
+func (uc *BookmarkUsecase) ListBookmarks(userID, listID string, page, limit int) ([]*domain.Bookmark, int64, error) {
+	user, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	list, err := uc.ownedList(user, listID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 50 {
+		limit = 10
+	}
+	return uc.bookmarkRepo.List(user, list, page, limit)
+}
+
+// ReorderBookmarks moves the given blogs to the top of the list in order
+func (uc *BookmarkUsecase) ReorderBookmarks(userID, listID string, blogIDs []string) error {
+	user, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+	list, err := uc.ownedList(user, listID)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(blogIDs))
+	for _, blogID := range blogIDs {
+		id, err := primitive.ObjectIDFromHex(blogID)
+		if err != nil {
+			return ErrBookmarkNotFound
+		}
+		ids = append(ids, id)
+	}
+	return uc.bookmarkRepo.Reorder(user, list, ids)
+}
+
+func (uc *BookmarkUsecase) CreateReadingList(userID, name string) (*domain.ReadingList, error) {
+	user, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrInvalidListName
+	}
+
+	list := &domain.ReadingList{
+		UserID:    user,
+		Name:      name,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := uc.bookmarkRepo.CreateList(list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (uc *BookmarkUsecase) GetReadingLists(userID string) ([]*domain.ReadingList, error) {
+	user, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+	return uc.bookmarkRepo.ListLists(user)
+}
+
+func (uc *BookmarkUsecase) RenameReadingList(userID, listID, name string) error {
+	user, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return ErrInvalidListName
+	}
+	list, err := uc.ownedList(user, listID)
+	if err != nil {
+		return err
+	}
+	if list.IsZero() {
+		return ErrReadingListNotFound
+	}
+	return uc.bookmarkRepo.RenameList(list, name)
+}
+
+// DeleteReadingList removes the list and the bookmarks saved in it
+func (uc *BookmarkUsecase) DeleteReadingList(userID, listID string) error {
+	user, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+	list, err := uc.ownedList(user, listID)
+	if err != nil {
+		return err
+	}
+	if list.IsZero() {
+		return ErrReadingListNotFound
+	}
+	return uc.bookmarkRepo.DeleteList(list)
+}
+
+// ownedList resolves a reading list ID belonging to the user. An empty ID
+// is the default list, represented by the zero ObjectID.
+func (uc *BookmarkUsecase) ownedList(user primitive.ObjectID, listID string) (primitive.ObjectID, error) {
+	if listID == "" {
+		return primitive.NilObjectID, nil
+	}
+	id, err := primitive.ObjectIDFromHex(listID)
+	if err != nil {
+		return id, ErrReadingListNotFound
+	}
+	list, err := uc.bookmarkRepo.GetList(id)
+	if err != nil || list.UserID != user {
+		return id, ErrReadingListNotFound
+	}
+	return id, nil
+}
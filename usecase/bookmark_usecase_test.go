@@ -0,0 +1,292 @@
+package usecase
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// fakeBookmarkRepo keeps bookmarks and reading lists in memory
+type fakeBookmarkRepo struct {
+	domain.BookmarkRepository
+	bookmarks []*domain.Bookmark
+	lists     map[primitive.ObjectID]*domain.ReadingList
+}
+
+func newFakeBookmarkRepo() *fakeBookmarkRepo {
+	return &fakeBookmarkRepo{lists: make(map[primitive.ObjectID]*domain.ReadingList)}
+}
+
+// Add appends the bookmark to the end of its list, once
+func (r *fakeBookmarkRepo) Add(bookmark *domain.Bookmark) error {
+	position := 0
+	for _, existing := range r.bookmarks {
+		if existing.UserID != bookmark.UserID || existing.ListID != bookmark.ListID {
+			continue
+		}
+		if existing.BlogID == bookmark.BlogID {
+			*bookmark = *existing
+			return nil
+		}
+		position = max(position, existing.Position+1)
+	}
+	bookmark.ID = primitive.NewObjectID()
+	bookmark.Position = position
+	copied := *bookmark
+	r.bookmarks = append(r.bookmarks, &copied)
+	return nil
+}
+
+func (r *fakeBookmarkRepo) Remove(userID, blogID, listID primitive.ObjectID) error {
+	for i, bookmark := range r.bookmarks {
+		if bookmark.UserID == userID && bookmark.BlogID == blogID && bookmark.ListID == listID {
+			r.bookmarks = append(r.bookmarks[:i], r.bookmarks[i+1:]...)
+			return nil
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+func (r *fakeBookmarkRepo) List(userID, listID primitive.ObjectID, page, limit int) ([]*domain.Bookmark, int64, error) {
+	var list []*domain.Bookmark
+	for _, bookmark := range r.bookmarks {
+		if bookmark.UserID == userID && bookmark.ListID == listID {
+			list = append(list, bookmark)
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Position < list[j].Position })
+	start := min((page-1)*limit, len(list))
+	return list[start:min(start+limit, len(list))], int64(len(list)), nil
+}
+
+func (r *fakeBookmarkRepo) Reorder(userID, listID primitive.ObjectID, blogIDs []primitive.ObjectID) error {
+	list, _, _ := r.List(userID, listID, 1, len(r.bookmarks)+1)
+	current := make([]primitive.ObjectID, len(list))
+	byBlog := make(map[primitive.ObjectID]*domain.Bookmark, len(list))
+	for i, bookmark := range list {
+		current[i] = bookmark.BlogID
+		byBlog[bookmark.BlogID] = bookmark
+	}
+	for position, blogID := range domain.BookmarkOrder(current, blogIDs) {
+		byBlog[blogID].Position = position
+	}
+	return nil
+}
+
+func (r *fakeBookmarkRepo) CreateList(list *domain.ReadingList) error {
+	list.ID = primitive.NewObjectID()
+	r.lists[list.ID] = list
+	return nil
+}
+
+func (r *fakeBookmarkRepo) GetList(id primitive.ObjectID) (*domain.ReadingList, error) {
+	list, ok := r.lists[id]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	return list, nil
+}
+
+func (r *fakeBookmarkRepo) RenameList(id primitive.ObjectID, name string) error {
+	r.lists[id].Name = name
+	return nil
+}
+
+// DeleteList removes the list along with its bookmarks
+func (r *fakeBookmarkRepo) DeleteList(id primitive.ObjectID) error {
+	delete(r.lists, id)
+	kept := r.bookmarks[:0]
+	for _, bookmark := range r.bookmarks {
+		if bookmark.ListID != id {
+			kept = append(kept, bookmark)
+		}
+	}
+	r.bookmarks = kept
+	return nil
+}
+
+// listedBlogs returns the blog IDs of a list in order
+func listedBlogs(t *testing.T, uc *BookmarkUsecase, userID, listID string) []primitive.ObjectID {
+	t.Helper()
+	bookmarks, _, err := uc.ListBookmarks(userID, listID, 1, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]primitive.ObjectID, len(bookmarks))
+	for i, bookmark := range bookmarks {
+		ids[i] = bookmark.BlogID
+	}
+	return ids
+}
+
+func sameIDs(a, b []primitive.ObjectID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestAddBookmark(t *testing.T) {
+	user := primitive.NewObjectID()
+	other := primitive.NewObjectID()
+	published := &domain.Blog{ID: primitive.NewObjectID(), AuthorID: other, Status: domain.BlogStatusPublished}
+	othersDraft := &domain.Blog{ID: primitive.NewObjectID(), AuthorID: other, Status: domain.BlogStatusDraft}
+	ownDraft := &domain.Blog{ID: primitive.NewObjectID(), AuthorID: user, Status: domain.BlogStatusDraft}
+	repo := newFakeBookmarkRepo()
+	uc := NewBookmarkUsecase(repo, newFakeBlogRepo(published, othersDraft, ownDraft))
+	othersList, _ := uc.CreateReadingList(other.Hex(), "theirs")
+
+	tests := []struct {
+		name    string
+		blogID  string
+		listID  string
+		wantErr error
+	}{
+		{"published blog", published.ID.Hex(), "", nil},
+		{"saved twice", published.ID.Hex(), "", nil},
+		{"own draft", ownDraft.ID.Hex(), "", nil},
+		{"someone else's draft", othersDraft.ID.Hex(), "", ErrBlogNotFound},
+		{"missing blog", primitive.NewObjectID().Hex(), "", ErrBlogNotFound},
+		{"invalid blog ID", "nope", "", ErrBlogNotFound},
+		{"someone else's list", published.ID.Hex(), othersList.ID.Hex(), ErrReadingListNotFound},
+		{"invalid list ID", published.ID.Hex(), "nope", ErrReadingListNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := uc.AddBookmark(user.Hex(), tt.blogID, tt.listID, domain.RoleAuthor); !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if got, want := listedBlogs(t, uc, user.Hex(), ""), []primitive.ObjectID{published.ID, ownDraft.ID}; !sameIDs(got, want) {
+		t.Errorf("saved blogs = %v, want %v", got, want)
+	}
+	if err := uc.RemoveBookmark(user.Hex(), othersDraft.ID.Hex(), ""); !errors.Is(err, ErrBookmarkNotFound) {
+		t.Errorf("removing a missing bookmark: err = %v, want %v", err, ErrBookmarkNotFound)
+	}
+}
+
+func TestReorderBookmarks(t *testing.T) {
+	user := primitive.NewObjectID()
+	blogs := make([]*domain.Blog, 4)
+	for i := range blogs {
+		blogs[i] = &domain.Blog{ID: primitive.NewObjectID(), Status: domain.BlogStatusPublished}
+	}
+	a, b, c, d := blogs[0].ID, blogs[1].ID, blogs[2].ID, blogs[3].ID
+	notSaved := primitive.NewObjectID()
+
+	tests := []struct {
+		name    string
+		order   []primitive.ObjectID
+		want    []primitive.ObjectID
+		wantErr error
+	}{
+		{"moves blogs to the top", []primitive.ObjectID{c, a}, []primitive.ObjectID{c, a, b, d}, nil},
+		{"full order", []primitive.ObjectID{d, c, b, a}, []primitive.ObjectID{d, c, b, a}, nil},
+		{"ignores blogs not in the list", []primitive.ObjectID{notSaved, b}, []primitive.ObjectID{b, a, c, d}, nil},
+		{"ignores repeats", []primitive.ObjectID{b, b, a}, []primitive.ObjectID{b, a, c, d}, nil},
+		{"empty order keeps the list", nil, []primitive.ObjectID{a, b, c, d}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := NewBookmarkUsecase(newFakeBookmarkRepo(), newFakeBlogRepo(blogs...))
+			for _, blog := range blogs {
+				if _, err := uc.AddBookmark(user.Hex(), blog.ID.Hex(), "", domain.RoleAuthor); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ids := make([]string, len(tt.order))
+			for i, id := range tt.order {
+				ids[i] = id.Hex()
+			}
+			if err := uc.ReorderBookmarks(user.Hex(), "", ids); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got := listedBlogs(t, uc, user.Hex(), ""); !sameIDs(got, tt.want) {
+				t.Errorf("order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	uc := NewBookmarkUsecase(newFakeBookmarkRepo(), newFakeBlogRepo(blogs...))
+	if err := uc.ReorderBookmarks(user.Hex(), "", []string{"nope"}); !errors.Is(err, ErrBookmarkNotFound) {
+		t.Errorf("invalid blog ID: err = %v, want %v", err, ErrBookmarkNotFound)
+	}
+	if err := uc.ReorderBookmarks(user.Hex(), primitive.NewObjectID().Hex(), []string{a.Hex()}); !errors.Is(err, ErrReadingListNotFound) {
+		t.Errorf("missing list: err = %v, want %v", err, ErrReadingListNotFound)
+	}
+}
+
+func TestReadingLists(t *testing.T) {
+	user := primitive.NewObjectID()
+	other := primitive.NewObjectID()
+	blog := &domain.Blog{ID: primitive.NewObjectID(), Status: domain.BlogStatusPublished}
+	repo := newFakeBookmarkRepo()
+	uc := NewBookmarkUsecase(repo, newFakeBlogRepo(blog))
+
+	if _, err := uc.CreateReadingList(user.Hex(), "   "); !errors.Is(err, ErrInvalidListName) {
+		t.Errorf("blank name: err = %v, want %v", err, ErrInvalidListName)
+	}
+	list, err := uc.CreateReadingList(user.Hex(), "  Later  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.Name != "Later" {
+		t.Errorf("name = %q, want %q", list.Name, "Later")
+	}
+	listID := list.ID.Hex()
+	if _, err := uc.AddBookmark(user.Hex(), blog.ID.Hex(), listID, domain.RoleAuthor); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.AddBookmark(user.Hex(), blog.ID.Hex(), "", domain.RoleAuthor); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		userID  string
+		listID  string
+		newName string
+		wantErr error
+	}{
+		{"default list cannot be renamed", user.Hex(), "", "Saved", ErrReadingListNotFound},
+		{"someone else's list", other.Hex(), listID, "Mine", ErrReadingListNotFound},
+		{"blank name", user.Hex(), listID, " ", ErrInvalidListName},
+		{"rename", user.Hex(), listID, "Weekend", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := uc.RenameReadingList(tt.userID, tt.listID, tt.newName); !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+	if got := repo.lists[list.ID].Name; got != "Weekend" {
+		t.Errorf("name = %q, want %q", got, "Weekend")
+	}
+
+	if err := uc.DeleteReadingList(other.Hex(), listID); !errors.Is(err, ErrReadingListNotFound) {
+		t.Errorf("deleting someone else's list: err = %v, want %v", err, ErrReadingListNotFound)
+	}
+	if err := uc.DeleteReadingList(user.Hex(), listID); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := uc.ListBookmarks(user.Hex(), listID, 1, 10); !errors.Is(err, ErrReadingListNotFound) {
+		t.Errorf("listing a deleted list: err = %v, want %v", err, ErrReadingListNotFound)
+	}
+	// Bookmarks in the default list stay
+	if got := listedBlogs(t, uc, user.Hex(), ""); !sameIDs(got, []primitive.ObjectID{blog.ID}) {
+		t.Errorf("saved blogs = %v, want %v", got, []primitive.ObjectID{blog.ID})
+	}
+}
@@ -1,36 +1,154 @@
 package usecase
 
 import (
+	"errors"
 	"time"
 
 	"github.com/sol-tad/Blog-post-Api/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrCommentNotFound  = errors.New("comment not found")
+	ErrCommentDeleted   = errors.New("comment has been deleted")
+	ErrThreadTooDeep    = errors.New("reply thread is too deep")
+	ErrCommentsClosed   = errors.New("comments are closed on this blog")
+	ErrNotPending       = errors.New("comment is not awaiting moderation")
+	ErrInvalidAction    = errors.New("moderation action must be approve or reject")
+	ErrCommentForbidden = errors.New("you are not allowed to change this comment")
 )
 
 type CommentUsecase struct {
     commentRepo domain.CommentRepository
     blogRepo    IBlogRepo
+    userRepo    domain.UserRepository
+    moderator   domain.ContentModerator
 }
 
 func NewCommentUsecase(
     commentRepo domain.CommentRepository,
     blogRepo IBlogRepo,
+    userRepo domain.UserRepository,
+    moderator domain.ContentModerator,
 ) *CommentUsecase {
     return &CommentUsecase{
         commentRepo: commentRepo,
         blogRepo:    blogRepo,
+        userRepo:    userRepo,
+        moderator:   moderator,
     }
 }
 
 func (uc *CommentUsecase) CreateComment(comment *domain.Comment) error {
     comment.CreatedAt = time.Now()
     comment.UpdatedAt = time.Now()
-    
-    // Increment blog comment count
-    if err := uc.commentRepo.IncrementCommentCount(comment.BlogID.Hex()); err != nil {
+
+    blog := uc.blogRepo.ViewBlogByID(comment.BlogID)
+    if blog == nil || blog.CurrentStatus() != domain.BlogStatusPublished {
+        return ErrBlogNotFound
+    }
+
+    policy := blog.CurrentCommentPolicy()
+    if policy == domain.CommentPolicyClosed {
+        return ErrCommentsClosed
+    }
+
+    // Comments show the commenter's current username, not one from the client
+    author := uc.userRepo.GetByID(comment.UserID)
+    if author == nil {
+        return ErrUserNotFound
+    }
+    comment.Username = author.Username
+
+    comment.Moderation = moderate(uc.moderator, comment.Content)
+    if policy == domain.CommentPolicyModerated || comment.Moderation.Flagged() {
+        // Held for the author or a moderator; not counted until approved
+        comment.Status = domain.CommentStatusPending
+        return uc.commentRepo.Create(comment)
+    }
+
+    comment.Status = domain.CommentStatusApproved
+    if err := uc.commentRepo.Create(comment); err != nil {
         return err
     }
-    
-    return uc.commentRepo.Create(comment)
+    // Increment blog comment count once the comment is stored
+    return uc.commentRepo.IncrementCommentCount(comment.BlogID.Hex())
+}
+
+// CreateReply stores a comment as a reply to the comment with parentID
+func (uc *CommentUsecase) CreateReply(parentID string, reply *domain.Comment) error {
+    parent, err := uc.commentRepo.GetByID(parentID)
+    if err != nil {
+        return ErrCommentNotFound
+    }
+    if parent.BlogID != reply.BlogID {
+        return ErrCommentNotFound
+    }
+    if parent.CurrentStatus() != domain.CommentStatusApproved {
+        return ErrCommentNotFound
+    }
+    if parent.Deleted {
+        return ErrCommentDeleted
+    }
+    if parent.Depth >= domain.MaxCommentDepth {
+        return ErrThreadTooDeep
+    }
+
+    reply.ParentID = parent.ID
+    reply.Depth = parent.Depth + 1
+    return uc.CreateComment(reply)
+}
+
+// GetCommentThread returns the comments of a blog down to maxDepth, either
+// nested under their parents or as a flat list in thread order where each
+// comment points at its parent. Replies to a comment that is not shown,
+// such as one whose edit awaits moderation, are hidden along with it.
+func (uc *CommentUsecase) GetCommentThread(blogID string, maxDepth int, flat bool) ([]*domain.Comment, error) {
+    if maxDepth < 0 || maxDepth > domain.MaxCommentDepth {
+        maxDepth = domain.MaxCommentDepth
+    }
+
+    comments, err := uc.commentRepo.GetThread(blogID, maxDepth)
+    if err != nil {
+        return nil, err
+    }
+
+    byID := make(map[primitive.ObjectID]*domain.Comment, len(comments))
+    for _, comment := range comments {
+        byID[comment.ID] = comment
+    }
+
+    // Comments come oldest first, so parents are placed before their replies
+    var roots []*domain.Comment
+    for _, comment := range comments {
+        if comment.ParentID.IsZero() {
+            roots = append(roots, comment)
+            continue
+        }
+        parent, ok := byID[comment.ParentID]
+        if !ok {
+            delete(byID, comment.ID)
+            continue
+        }
+        parent.Replies = append(parent.Replies, comment)
+    }
+
+    if !flat {
+        return roots, nil
+    }
+
+    ordered := make([]*domain.Comment, 0, len(comments))
+    var walk func(nodes []*domain.Comment)
+    walk = func(nodes []*domain.Comment) {
+        for _, node := range nodes {
+            replies := node.Replies
+            node.Replies = nil
+            ordered = append(ordered, node)
+            walk(replies)
+        }
+    }
+    walk(roots)
+    return ordered, nil
 }
 
 func (uc *CommentUsecase) GetCommentByID(id string) (*domain.Comment, error) {
@@ -41,20 +159,197 @@ func (uc *CommentUsecase) GetCommentsByBlog(blogID string, page, limit int) ([]*
     return uc.commentRepo.GetByBlog(blogID, page, limit)
 }
 
-func (uc *CommentUsecase) UpdateComment(comment *domain.Comment) error {
+// UpdateComment changes the content of a comment. Only its author may
+// edit it. A flagged edit of an approved comment waits for moderation, and
+// the replies to it are hidden until then.
+func (uc *CommentUsecase) UpdateComment(id, userID, content string) (*domain.Comment, error) {
+    comment, err := uc.commentRepo.GetByID(id)
+    if err != nil {
+        return nil, ErrCommentNotFound
+    }
+    if comment.Deleted {
+        return nil, ErrCommentDeleted
+    }
+    if comment.UserID.Hex() != userID {
+        return nil, ErrCommentForbidden
+    }
+    if content == comment.Content {
+        return comment, nil
+    }
+
+    // Edits are checked like new comments, so a live comment cannot be
+    // changed into one moderation would have held
+    comment.Content = content
     comment.UpdatedAt = time.Now()
-    return uc.commentRepo.Update(comment)
+    comment.Moderation = moderate(uc.moderator, content)
+    held := comment.Moderation.Flagged() && comment.CurrentStatus() == domain.CommentStatusApproved
+    if held {
+        comment.Status = domain.CommentStatusPending
+    }
+    if err := uc.commentRepo.Update(comment); err != nil {
+        return nil, err
+    }
+    if held {
+        // Not counted again until approved
+        if err := uc.commentRepo.DecrementCommentCount(comment.BlogID.Hex()); err != nil {
+            return nil, err
+        }
+    }
+    return comment, nil
 }
 
-func (uc *CommentUsecase) DeleteComment(id string) error {
+// DeleteComment deletes a comment. Only its author or a role that may
+// delete any comment may delete it.
+func (uc *CommentUsecase) DeleteComment(id, userID, role string) error {
     comment, err := uc.commentRepo.GetByID(id)
     if err != nil {
+        return ErrCommentNotFound
+    }
+    if comment.Deleted {
+        return ErrCommentDeleted
+    }
+    if !domain.CanActOn(userID, role, comment.UserID, domain.PermCommentsDeleteAny) {
+        return ErrCommentForbidden
+    }
+    // Decrement blog comment count; only approved comments were counted
+    if comment.CurrentStatus() == domain.CommentStatusApproved {
+        if err := uc.commentRepo.DecrementCommentCount(comment.BlogID.Hex()); err != nil {
+            return err
+        }
+    }
+
+    // A comment with replies becomes a tombstone so the replies keep their place
+    replies, err := uc.commentRepo.CountReplies(id)
+    if err != nil {
+        return err
+    }
+    if replies > 0 {
+        comment.Content = ""
+        comment.Username = ""
+        comment.Deleted = true
+        comment.UpdatedAt = time.Now()
+        return uc.commentRepo.Update(comment)
+    }
+
+    if err := uc.commentRepo.Delete(id); err != nil {
+        return err
+    }
+    return uc.pruneTombstone(comment.ParentID)
+}
+
+// pruneTombstone removes a tombstoned parent once its last reply is gone,
+// walking up the thread
+func (uc *CommentUsecase) pruneTombstone(parentID primitive.ObjectID) error {
+    if parentID.IsZero() {
+        return nil
+    }
+    parent, err := uc.commentRepo.GetByID(parentID.Hex())
+    if err != nil || !parent.Deleted {
+        return nil
+    }
+    replies, err := uc.commentRepo.CountReplies(parent.ID.Hex())
+    if err != nil || replies > 0 {
+        return err
+    }
+    if err := uc.commentRepo.Delete(parent.ID.Hex()); err != nil {
         return err
     }
-    // Decrement blog comment count
-    if err := uc.commentRepo.DecrementCommentCount(comment.BlogID.Hex()); err != nil {
+    return uc.pruneTombstone(parent.ParentID)
+}
+
+// Moderation actions
+const (
+    ModerationApprove = "approve"
+    ModerationReject  = "reject"
+)
+
+// ListPendingComments lists the comments waiting for moderation on a blog.
+// Only the blog author or a role that may moderate any comment may moderate.
+func (uc *CommentUsecase) ListPendingComments(blogID, userID, role string, page, limit int) ([]*domain.Comment, error) {
+    if err := uc.canModerate(blogID, userID, role); err != nil {
+        return nil, err
+    }
+    if page < 1 {
+        page = 1
+    }
+    if limit < 1 || limit > 50 {
+        limit = 10
+    }
+    return uc.commentRepo.GetByStatus(blogID, domain.CommentStatusPending, page, limit)
+}
+
+// ModerateComment approves or rejects a single pending comment
+func (uc *CommentUsecase) ModerateComment(blogID, commentID, userID, role, action string) error {
+    if err := uc.canModerate(blogID, userID, role); err != nil {
         return err
     }
-    
-    return uc.commentRepo.Delete(id)
-}
\ No newline at end of file
+    return uc.moderate(blogID, commentID, action)
+}
+
+// ModerateComments applies the action to each comment and returns the
+// outcome per comment ID
+func (uc *CommentUsecase) ModerateComments(blogID string, commentIDs []string, userID, role, action string) (map[string]string, error) {
+    if err := uc.canModerate(blogID, userID, role); err != nil {
+        return nil, err
+    }
+    if action != ModerationApprove && action != ModerationReject {
+        return nil, ErrInvalidAction
+    }
+
+    results := make(map[string]string, len(commentIDs))
+    for _, id := range commentIDs {
+        if err := uc.moderate(blogID, id, action); err != nil {
+            results[id] = err.Error()
+        } else {
+            results[id] = "ok"
+        }
+    }
+    return results, nil
+}
+
+func (uc *CommentUsecase) moderate(blogID, commentID, action string) error {
+    comment, err := uc.commentRepo.GetByID(commentID)
+    if err != nil || comment.BlogID.Hex() != blogID {
+        return ErrCommentNotFound
+    }
+
+    switch action {
+    case ModerationApprove:
+        ok, err := uc.commentRepo.SetStatus(commentID, domain.CommentStatusPending, domain.CommentStatusApproved)
+        if err != nil {
+            return err
+        }
+        if !ok {
+            return ErrNotPending
+        }
+        // The comment is live now, so it counts towards the blog stats
+        return uc.commentRepo.IncrementCommentCount(blogID)
+    case ModerationReject:
+        ok, err := uc.commentRepo.SetStatus(commentID, domain.CommentStatusPending, domain.CommentStatusRejected)
+        if err != nil {
+            return err
+        }
+        if !ok {
+            return ErrNotPending
+        }
+        return nil
+    }
+    return ErrInvalidAction
+}
+
+// canModerate checks that the user is the blog author or has a role that
+// may moderate any comment
+func (uc *CommentUsecase) canModerate(blogID, userID, role string) error {
+    id, err := primitive.ObjectIDFromHex(blogID)
+    if err != nil {
+        return ErrBlogNotFound
+    }
+    blog := uc.blogRepo.ViewBlogByID(id)
+    if blog == nil {
+        return ErrBlogNotFound
+    }
+    if !domain.CanActOn(userID, role, blog.AuthorID, domain.PermCommentsModerateAny) {
+        return ErrBlogForbidden
+    }
+    return nil
+}
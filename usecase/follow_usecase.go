@@ -0,0 +1,245 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrUserNotFound  = errors.New("user not found")
+	ErrFollowSelf    = errors.New("you cannot follow yourself")
+	ErrNotFollowing  = errors.New("you are not following this user")
+	ErrInvalidCursor = errors.New("invalid feed cursor")
+)
+
+type FollowUsecase struct {
+	followRepo domain.FollowRepository
+	userRepo   domain.UserRepository
+	blogRepo   IBlogRepo
+}
+
+func NewFollowUsecase(followRepo domain.FollowRepository, userRepo domain.UserRepository, blogRepo IBlogRepo) *FollowUsecase {
+	return &FollowUsecase{
+		followRepo: followRepo,
+		userRepo:   userRepo,
+		blogRepo:   blogRepo,
+	}
+}
+
+func (uc *FollowUsecase) Follow(ctx context.Context, followerID, followeeID string) error {
+	follower, followee, err := uc.followPair(ctx, followerID, followeeID)
+	if err != nil {
+		return err
+	}
+	_, err = uc.followRepo.Follow(follower, followee)
+	return err
+}
+
+func (uc *FollowUsecase) Unfollow(ctx context.Context, followerID, followeeID string) error {
+	follower, followee, err := uc.followPair(ctx, followerID, followeeID)
+	if err != nil {
+		return err
+	}
+	removed, err := uc.followRepo.Unfollow(follower, followee)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrNotFollowing
+	}
+	return nil
+}
+
+// GetProfile returns a user's public profile with follow counts, and
+// whether the viewer follows them
+func (uc *FollowUsecase) GetProfile(ctx context.Context, userID, viewerID string) (*domain.UserProfile, error) {
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	profile, err := uc.profile(user)
+	if err != nil {
+		return nil, err
+	}
+
+	if viewer, err := primitive.ObjectIDFromHex(viewerID); err == nil && viewer != user.ID {
+		profile.IsFollowing, err = uc.followRepo.IsFollowing(viewer, user.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return profile, nil
+}
+
+func (uc *FollowUsecase) GetFollowers(ctx context.Context, userID string, page, limit int) ([]*domain.UserProfile, int64, error) {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, 0, ErrUserNotFound
+	}
+	page, limit = normalizePage(page, limit)
+	ids, err := uc.followRepo.Followers(id, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := uc.followRepo.CountFollowers(id)
+	if err != nil {
+		return nil, 0, err
+	}
+	profiles, err := uc.profiles(ctx, ids)
+	return profiles, total, err
+}
+
+func (uc *FollowUsecase) GetFollowing(ctx context.Context, userID string, page, limit int) ([]*domain.UserProfile, int64, error) {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, 0, ErrUserNotFound
+	}
+	page, limit = normalizePage(page, limit)
+	ids, err := uc.followRepo.Following(id, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := uc.followRepo.CountFollowing(id)
+	if err != nil {
+		return nil, 0, err
+	}
+	profiles, err := uc.profiles(ctx, ids)
+	return profiles, total, err
+}
+
+// GetFeed returns published blogs from the authors the user follows, most
+// recently published first. The returned cursor fetches the next page and
+// is empty on the last page.
+func (uc *FollowUsecase) GetFeed(userID, cursor string, limit int) ([]*domain.Blog, string, error) {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, "", ErrUserNotFound
+	}
+	if limit < 1 || limit > 50 {
+		limit = 10
+	}
+
+	var after *domain.FeedCursor
+	if cursor != "" {
+		if after, err = decodeFeedCursor(cursor); err != nil {
+			return nil, "", err
+		}
+	}
+
+	authors, err := uc.followRepo.FollowingIDs(id)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(authors) == 0 {
+		return []*domain.Blog{}, "", nil
+	}
+
+	blogs, err := uc.blogRepo.Feed(authors, after, limit)
+	if err != nil {
+		return nil, "", err
+	}
+	withExcerpts(blogs)
+
+	next := ""
+	if len(blogs) == limit {
+		last := blogs[len(blogs)-1]
+		next = encodeFeedCursor(domain.FeedCursor{PublishedAt: last.FeedTime(), ID: last.ID})
+	}
+	return blogs, next, nil
+}
+
+func (uc *FollowUsecase) followPair(ctx context.Context, followerID, followeeID string) (primitive.ObjectID, primitive.ObjectID, error) {
+	follower, err := primitive.ObjectIDFromHex(followerID)
+	if err != nil {
+		return follower, primitive.NilObjectID, ErrUserNotFound
+	}
+	followee, err := uc.userRepo.FindByID(ctx, followeeID)
+	if err != nil {
+		return follower, primitive.NilObjectID, ErrUserNotFound
+	}
+	if follower == followee.ID {
+		return follower, followee.ID, ErrFollowSelf
+	}
+	return follower, followee.ID, nil
+}
+
+func (uc *FollowUsecase) profile(user domain.User) (*domain.UserProfile, error) {
+	followers, err := uc.followRepo.CountFollowers(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	following, err := uc.followRepo.CountFollowing(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.UserProfile{
+		ID:             user.ID,
+		Username:       user.Username,
+		FullName:       user.FullName,
+		Bio:            user.Bio,
+		ProfilePicture: user.ProfilePicture,
+		FollowersCount: followers,
+		FollowingCount: following,
+	}, nil
+}
+
+func (uc *FollowUsecase) profiles(ctx context.Context, ids []primitive.ObjectID) ([]*domain.UserProfile, error) {
+	profiles := make([]*domain.UserProfile, 0, len(ids))
+	for _, id := range ids {
+		user, err := uc.userRepo.FindByID(ctx, id.Hex())
+		if err != nil {
+			// the account was removed since the follow was recorded
+			continue
+		}
+		profile, err := uc.profile(user)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles, nil
+}
+
+func normalizePage(page, limit int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 50 {
+		limit = 10
+	}
+	return page, limit
+}
+
+// Feed cursors are the publish time and ID of the last blog on a page,
+// base64 encoded so clients treat them as opaque
+func encodeFeedCursor(cursor domain.FeedCursor) string {
+	raw := strconv.FormatInt(cursor.PublishedAt.UnixNano(), 10) + ":" + cursor.ID.Hex()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeFeedCursor(cursor string) (*domain.FeedCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	parts := strings.SplitN(string(raw), ":", 2)
+	if len(parts) != 2 {
+		return nil, ErrInvalidCursor
+	}
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	id, err := primitive.ObjectIDFromHex(parts[1])
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &domain.FeedCursor{PublishedAt: time.Unix(0, nanos).UTC(), ID: id}, nil
+}
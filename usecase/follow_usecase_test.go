@@ -0,0 +1,324 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeFollowRepo keeps follows in memory as follower, followee pairs in
+// the order they were made
+type fakeFollowRepo struct {
+	domain.FollowRepository
+	follows [][2]primitive.ObjectID
+}
+
+func (r *fakeFollowRepo) Follow(followerID, followeeID primitive.ObjectID) (bool, error) {
+	if following, _ := r.IsFollowing(followerID, followeeID); following {
+		return false, nil
+	}
+	r.follows = append(r.follows, [2]primitive.ObjectID{followerID, followeeID})
+	return true, nil
+}
+
+func (r *fakeFollowRepo) Unfollow(followerID, followeeID primitive.ObjectID) (bool, error) {
+	for i, follow := range r.follows {
+		if follow == [2]primitive.ObjectID{followerID, followeeID} {
+			r.follows = append(r.follows[:i], r.follows[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeFollowRepo) IsFollowing(followerID, followeeID primitive.ObjectID) (bool, error) {
+	for _, follow := range r.follows {
+		if follow == [2]primitive.ObjectID{followerID, followeeID} {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// matching returns the other side of every follow whose side `from` is id
+func (r *fakeFollowRepo) matching(id primitive.ObjectID, from int) []primitive.ObjectID {
+	ids := []primitive.ObjectID{}
+	for _, follow := range r.follows {
+		if follow[from] == id {
+			ids = append(ids, follow[1-from])
+		}
+	}
+	return ids
+}
+
+func (r *fakeFollowRepo) FollowingIDs(followerID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	return r.matching(followerID, 0), nil
+}
+
+func (r *fakeFollowRepo) Followers(followeeID primitive.ObjectID, page, limit int) ([]primitive.ObjectID, error) {
+	ids := r.matching(followeeID, 1)
+	start := min((page-1)*limit, len(ids))
+	return ids[start:min(start+limit, len(ids))], nil
+}
+
+func (r *fakeFollowRepo) Following(followerID primitive.ObjectID, page, limit int) ([]primitive.ObjectID, error) {
+	ids := r.matching(followerID, 0)
+	start := min((page-1)*limit, len(ids))
+	return ids[start:min(start+limit, len(ids))], nil
+}
+
+func (r *fakeFollowRepo) CountFollowers(userID primitive.ObjectID) (int64, error) {
+	return int64(len(r.matching(userID, 1))), nil
+}
+
+func (r *fakeFollowRepo) CountFollowing(userID primitive.ObjectID) (int64, error) {
+	return int64(len(r.matching(userID, 0))), nil
+}
+
+// Feed orders blogs the way the Mongo query does: by feed time, then ID
+func (r *fakeBlogRepo) Feed(authorIDs []primitive.ObjectID, after *domain.FeedCursor, limit int) ([]*domain.Blog, error) {
+	var blogs []*domain.Blog
+	for _, blog := range r.blogs {
+		at := blog.FeedTime()
+		if after != nil && (at.After(after.PublishedAt) || at.Equal(after.PublishedAt) && blog.ID.Hex() >= after.ID.Hex()) {
+			continue
+		}
+		copied := *blog
+		blogs = append(blogs, &copied)
+	}
+	sort.Slice(blogs, func(i, j int) bool {
+		if !blogs[i].FeedTime().Equal(blogs[j].FeedTime()) {
+			return blogs[i].FeedTime().After(blogs[j].FeedTime())
+		}
+		return blogs[i].ID.Hex() > blogs[j].ID.Hex()
+	})
+	if len(blogs) > limit {
+		blogs = blogs[:limit]
+	}
+	return blogs, nil
+}
+
+func TestFeedCursorEncoding(t *testing.T) {
+	cursor := domain.FeedCursor{
+		PublishedAt: time.Date(2025, 3, 1, 12, 30, 0, 123456789, time.UTC),
+		ID:          primitive.NewObjectID(),
+	}
+	got, err := decodeFeedCursor(encodeFeedCursor(cursor))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.PublishedAt.Equal(cursor.PublishedAt) || got.ID != cursor.ID {
+		t.Errorf("round trip = %+v, want %+v", *got, cursor)
+	}
+
+	for _, invalid := range []string{
+		"not base64!",
+		encodeRaw("no separator"),
+		encodeRaw("soon:" + cursor.ID.Hex()),
+		encodeRaw("1700000000:not-an-id"),
+	} {
+		if _, err := decodeFeedCursor(invalid); err != ErrInvalidCursor {
+			t.Errorf("%q: err = %v, want %v", invalid, err, ErrInvalidCursor)
+		}
+	}
+}
+
+func encodeRaw(raw string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func TestGetFeedPagesByPublishTime(t *testing.T) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(hours int) *time.Time {
+		t := base.Add(time.Duration(hours) * time.Hour)
+		return &t
+	}
+	// Written in one order, published in another; b and c went live together
+	a := &domain.Blog{ID: primitive.NewObjectID(), Title: "a", CreatedAt: *at(1), PublishedAt: at(9)}
+	b := &domain.Blog{ID: primitive.NewObjectID(), Title: "b", CreatedAt: *at(2), PublishedAt: at(5)}
+	c := &domain.Blog{ID: primitive.NewObjectID(), Title: "c", CreatedAt: *at(3), PublishedAt: at(5)}
+	legacy := &domain.Blog{ID: primitive.NewObjectID(), Title: "legacy", CreatedAt: *at(7)}
+	d := &domain.Blog{ID: primitive.NewObjectID(), Title: "d", CreatedAt: *at(8), PublishedAt: at(8)}
+
+	reader := primitive.NewObjectID()
+	follows := &fakeFollowRepo{follows: [][2]primitive.ObjectID{{reader, primitive.NewObjectID()}}}
+	uc := NewFollowUsecase(follows, nil, newFakeBlogRepo(a, b, c, legacy, d))
+
+	want := []string{"a", "d", "legacy"}
+	if b.ID.Hex() > c.ID.Hex() {
+		want = append(want, "b", "c")
+	} else {
+		want = append(want, "c", "b")
+	}
+
+	var got []string
+	cursor := ""
+	for page := 0; page < 5; page++ {
+		blogs, next, err := uc.GetFeed(reader.Hex(), cursor, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, blog := range blogs {
+			got = append(got, blog.Title)
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("feed = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("feed = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestFollowAndUnfollow(t *testing.T) {
+	ctx := context.Background()
+	alice := &domain.User{ID: primitive.NewObjectID(), Username: "alice"}
+	bob := &domain.User{ID: primitive.NewObjectID(), Username: "bob"}
+	follows := &fakeFollowRepo{}
+	uc := NewFollowUsecase(follows, newFakeUserRepo(alice, bob), newFakeBlogRepo())
+
+	steps := []struct {
+		name     string
+		unfollow bool
+		follower string
+		followee string
+		wantErr  error
+	}{
+		{"follow", false, alice.ID.Hex(), bob.ID.Hex(), nil},
+		{"follow again", false, alice.ID.Hex(), bob.ID.Hex(), nil},
+		{"follow back", false, bob.ID.Hex(), alice.ID.Hex(), nil},
+		{"follow self", false, alice.ID.Hex(), alice.ID.Hex(), ErrFollowSelf},
+		{"follow missing user", false, alice.ID.Hex(), primitive.NewObjectID().Hex(), ErrUserNotFound},
+		{"invalid follower", false, "nope", bob.ID.Hex(), ErrUserNotFound},
+		{"unfollow", true, bob.ID.Hex(), alice.ID.Hex(), nil},
+		{"unfollow again", true, bob.ID.Hex(), alice.ID.Hex(), ErrNotFollowing},
+	}
+	for _, step := range steps {
+		run := uc.Follow
+		if step.unfollow {
+			run = uc.Unfollow
+		}
+		if err := run(ctx, step.follower, step.followee); !errors.Is(err, step.wantErr) {
+			t.Errorf("%s: err = %v, want %v", step.name, err, step.wantErr)
+		}
+	}
+	if len(follows.follows) != 1 {
+		t.Errorf("follows = %d, want 1", len(follows.follows))
+	}
+
+	tests := []struct {
+		name          string
+		userID        string
+		viewerID      string
+		wantFollowers int64
+		wantFollowing int64
+		wantFollowed  bool
+	}{
+		{"followed user seen by follower", bob.ID.Hex(), alice.ID.Hex(), 1, 0, true},
+		{"follower seen by followed user", alice.ID.Hex(), bob.ID.Hex(), 0, 1, false},
+		{"own profile", alice.ID.Hex(), alice.ID.Hex(), 0, 1, false},
+		{"anonymous viewer", bob.ID.Hex(), "", 1, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, err := uc.GetProfile(ctx, tt.userID, tt.viewerID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if profile.FollowersCount != tt.wantFollowers || profile.FollowingCount != tt.wantFollowing {
+				t.Errorf("counts = %d followers, %d following; want %d, %d", profile.FollowersCount, profile.FollowingCount, tt.wantFollowers, tt.wantFollowing)
+			}
+			if profile.IsFollowing != tt.wantFollowed {
+				t.Errorf("is following = %v, want %v", profile.IsFollowing, tt.wantFollowed)
+			}
+		})
+	}
+	if _, err := uc.GetProfile(ctx, primitive.NewObjectID().Hex(), ""); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("missing user: err = %v, want %v", err, ErrUserNotFound)
+	}
+}
+
+func TestFollowerLists(t *testing.T) {
+	ctx := context.Background()
+	author := &domain.User{ID: primitive.NewObjectID(), Username: "author"}
+	readers := []*domain.User{
+		{ID: primitive.NewObjectID(), Username: "first"},
+		{ID: primitive.NewObjectID(), Username: "second"},
+		{ID: primitive.NewObjectID(), Username: "third"},
+	}
+	removed := primitive.NewObjectID()
+	follows := &fakeFollowRepo{}
+	for _, reader := range readers {
+		follows.Follow(reader.ID, author.ID)
+	}
+	// An account deleted after following is left out of the list
+	follows.Follow(removed, author.ID)
+	uc := NewFollowUsecase(follows, newFakeUserRepo(append(readers, author)...), newFakeBlogRepo())
+
+	tests := []struct {
+		name      string
+		page      int
+		limit     int
+		wantNames []string
+	}{
+		{"first page", 1, 2, []string{"first", "second"}},
+		{"second page", 2, 2, []string{"third"}},
+		{"bad paging falls back to the defaults", 0, 500, []string{"first", "second", "third"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profiles, total, err := uc.GetFollowers(ctx, author.ID.Hex(), tt.page, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != 4 {
+				t.Errorf("total = %d, want 4", total)
+			}
+			var names []string
+			for _, profile := range profiles {
+				names = append(names, profile.Username)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.wantNames, ",") {
+				t.Errorf("followers = %v, want %v", names, tt.wantNames)
+			}
+		})
+	}
+
+	following, total, err := uc.GetFollowing(ctx, readers[0].ID.Hex(), 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(following) != 1 || following[0].Username != "author" || following[0].FollowersCount != 4 {
+		t.Errorf("following = %+v (total %d), want the author with 4 followers", following, total)
+	}
+}
+
+func TestFeedWithoutFollows(t *testing.T) {
+	blog := &domain.Blog{ID: primitive.NewObjectID(), Status: domain.BlogStatusPublished}
+	uc := NewFollowUsecase(&fakeFollowRepo{}, nil, newFakeBlogRepo(blog))
+
+	blogs, next, err := uc.GetFeed(primitive.NewObjectID().Hex(), "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blogs) != 0 || next != "" {
+		t.Errorf("feed = %d blogs, cursor %q; want an empty last page", len(blogs), next)
+	}
+	if _, _, err := uc.GetFeed(primitive.NewObjectID().Hex(), "not a cursor", 10); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("bad cursor: err = %v, want %v", err, ErrInvalidCursor)
+	}
+}
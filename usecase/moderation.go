@@ -0,0 +1,34 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+)
+
+// moderate checks text with moderator, returning nil when moderation is not
+// configured. Content that cannot be checked is flagged so a person looks
+// at it rather than it going live unchecked.
+func moderate(moderator domain.ContentModerator, text string) *domain.ModerationVerdict {
+	if moderator == nil {
+		return nil
+	}
+	verdict, err := moderator.Moderate(text)
+	if err != nil {
+		fmt.Println("moderation failed:", err)
+		return &domain.ModerationVerdict{
+			Verdict:   domain.ModerationFlag,
+			Reason:    "moderation unavailable: " + err.Error(),
+			Moderator: "none",
+			CheckedAt: time.Now(),
+		}
+	}
+	return verdict
+}
+
+// blogText is the part of a blog that is moderated
+func blogText(blog *domain.Blog) string {
+	return blog.Title + "\n" + strings.Join(blog.Tags, " ") + "\n" + blog.Content
+}
@@ -0,0 +1,286 @@
+package usecase
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// wordModerator flags any text containing its word
+type wordModerator string
+
+func (m wordModerator) Moderate(text string) (*domain.ModerationVerdict, error) {
+	if strings.Contains(text, string(m)) {
+		return &domain.ModerationVerdict{Verdict: domain.ModerationFlag, Moderator: "test"}, nil
+	}
+	return &domain.ModerationVerdict{Verdict: domain.ModerationAllow, Moderator: "test"}, nil
+}
+
+type fakeRevisionRepo struct {
+	domain.BlogRevisionRepository
+	revisions []*domain.BlogRevision
+}
+
+func (r *fakeRevisionRepo) Create(revision *domain.BlogRevision) error {
+	revision.Revision = len(r.revisions) + 1
+	r.revisions = append(r.revisions, revision)
+	return nil
+}
+
+// fakeCommentRepo keeps comments in memory and counts the approved ones
+type fakeCommentRepo struct {
+	domain.CommentRepository
+	comments   map[string]*domain.Comment
+	counted    int
+	failCreate error
+}
+
+func (r *fakeCommentRepo) GetByID(id string) (*domain.Comment, error) {
+	comment, ok := r.comments[id]
+	if !ok {
+		return nil, ErrCommentNotFound
+	}
+	copied := *comment
+	return &copied, nil
+}
+
+func (r *fakeCommentRepo) Update(comment *domain.Comment) error {
+	copied := *comment
+	r.comments[comment.ID.Hex()] = &copied
+	return nil
+}
+
+func (r *fakeCommentRepo) DecrementCommentCount(id string) error {
+	r.counted--
+	return nil
+}
+
+func (r *fakeCommentRepo) IncrementCommentCount(id string) error {
+	r.counted++
+	return nil
+}
+
+// Create fails with failCreate, like a write the database rejects
+func (r *fakeCommentRepo) Create(comment *domain.Comment) error {
+	if r.failCreate != nil {
+		return r.failCreate
+	}
+	comment.ID = primitive.NewObjectID()
+	return r.Update(comment)
+}
+
+func (r *fakeCommentRepo) SetStatus(id string, from, to string) (bool, error) {
+	comment, ok := r.comments[id]
+	if !ok || comment.CurrentStatus() != from {
+		return false, nil
+	}
+	comment.Status = to
+	return true, nil
+}
+
+// GetThread returns the approved comments of a blog, oldest first
+func (r *fakeCommentRepo) GetThread(blogID string, maxDepth int) ([]*domain.Comment, error) {
+	comments := []*domain.Comment{}
+	for _, comment := range r.comments {
+		if comment.BlogID.Hex() == blogID && comment.Depth <= maxDepth && comment.CurrentStatus() == domain.CommentStatusApproved {
+			copied := *comment
+			comments = append(comments, &copied)
+		}
+	}
+	sort.Slice(comments, func(i, j int) bool {
+		return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+	})
+	return comments, nil
+}
+
+func TestUpdateBlogModeratesEdits(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     string
+		content    string
+		wantStatus string
+		wantFlag   bool
+	}{
+		{"clean edit of a published blog", domain.BlogStatusPublished, "still fine", domain.BlogStatusPublished, false},
+		{"flagged edit of a published blog", domain.BlogStatusPublished, "buy spam now", domain.BlogStatusInReview, true},
+		{"flagged edit of a scheduled blog", domain.BlogStatusScheduled, "buy spam now", domain.BlogStatusInReview, true},
+		{"flagged edit of a draft", domain.BlogStatusDraft, "buy spam now", domain.BlogStatusDraft, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			author := primitive.NewObjectID()
+			blog := &domain.Blog{
+				ID:       primitive.NewObjectID(),
+				AuthorID: author,
+				Title:    "Title",
+				Slug:     "title",
+				Content:  "fine",
+				Status:   tt.status,
+			}
+			repo := newFakeBlogRepo(blog)
+			uc := &BlogUseCase{Repo: repo, RevisionRepo: &fakeRevisionRepo{}, Moderator: wordModerator("spam")}
+
+			got, err := uc.EditBlog(blog.ID.Hex(), author.Hex(), domain.RoleAuthor, &domain.Blog{Title: "Title", Content: tt.content})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Status != tt.wantStatus || repo.blogs[blog.ID].Status != tt.wantStatus {
+				t.Errorf("status = %q (stored %q), want %q", got.Status, repo.blogs[blog.ID].Status, tt.wantStatus)
+			}
+			if got := repo.blogs[blog.ID].Moderation.Flagged(); got != tt.wantFlag {
+				t.Errorf("flagged = %v, want %v", got, tt.wantFlag)
+			}
+		})
+	}
+}
+
+func TestUpdateCommentModeratesEdits(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      string
+		content     string
+		wantStatus  string
+		wantCounted int
+	}{
+		{"clean edit of an approved comment", domain.CommentStatusApproved, "still fine", domain.CommentStatusApproved, 1},
+		{"flagged edit of an approved comment", domain.CommentStatusApproved, "buy spam now", domain.CommentStatusPending, 0},
+		{"flagged edit of a legacy comment", "", "buy spam now", domain.CommentStatusPending, 0},
+		{"flagged edit of a pending comment", domain.CommentStatusPending, "buy spam now", domain.CommentStatusPending, 1},
+		{"flagged edit of a rejected comment", domain.CommentStatusRejected, "buy spam now", domain.CommentStatusRejected, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := primitive.NewObjectID()
+			comment := &domain.Comment{
+				ID:      primitive.NewObjectID(),
+				BlogID:  primitive.NewObjectID(),
+				UserID:  user,
+				Content: "fine",
+				Status:  tt.status,
+			}
+			repo := &fakeCommentRepo{comments: map[string]*domain.Comment{comment.ID.Hex(): comment}, counted: 1}
+			uc := NewCommentUsecase(repo, nil, nil, wordModerator("spam"))
+
+			got, err := uc.UpdateComment(comment.ID.Hex(), user.Hex(), tt.content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.CurrentStatus() != tt.wantStatus || repo.comments[comment.ID.Hex()].CurrentStatus() != tt.wantStatus {
+				t.Errorf("status = %q, want %q", got.CurrentStatus(), tt.wantStatus)
+			}
+			if repo.counted != tt.wantCounted {
+				t.Errorf("comment count = %d, want %d", repo.counted, tt.wantCounted)
+			}
+		})
+	}
+}
+
+func TestUpdateCommentOnlyByAuthor(t *testing.T) {
+	comment := &domain.Comment{ID: primitive.NewObjectID(), UserID: primitive.NewObjectID(), Content: "fine"}
+	repo := &fakeCommentRepo{comments: map[string]*domain.Comment{comment.ID.Hex(): comment}}
+	uc := NewCommentUsecase(repo, nil, nil, nil)
+
+	if _, err := uc.UpdateComment(comment.ID.Hex(), primitive.NewObjectID().Hex(), "mine now"); err != ErrCommentForbidden {
+		t.Errorf("err = %v, want %v", err, ErrCommentForbidden)
+	}
+}
+
+func TestCreateCommentCountsOnlyStoredComments(t *testing.T) {
+	blog := &domain.Blog{ID: primitive.NewObjectID(), Status: domain.BlogStatusPublished}
+	user := &domain.User{ID: primitive.NewObjectID(), Username: "ada"}
+	writeFailed := errors.New("write failed")
+	tests := []struct {
+		name        string
+		userID      primitive.ObjectID
+		content     string
+		failCreate  error
+		wantErr     error
+		wantCounted int
+	}{
+		{"approved comment", user.ID, "nice post", nil, nil, 1},
+		{"held comment", user.ID, "buy spam now", nil, nil, 0},
+		{"failed write", user.ID, "nice post", writeFailed, writeFailed, 0},
+		{"unknown user", primitive.NewObjectID(), "nice post", nil, ErrUserNotFound, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeCommentRepo{comments: map[string]*domain.Comment{}, failCreate: tt.failCreate}
+			uc := NewCommentUsecase(repo, newFakeBlogRepo(blog), newFakeUserRepo(user), wordModerator("spam"))
+
+			// A username sent by the client is replaced with the stored one
+			comment := &domain.Comment{BlogID: blog.ID, UserID: tt.userID, Username: "admin", Content: tt.content}
+			err := uc.CreateComment(comment)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if repo.counted != tt.wantCounted {
+				t.Errorf("comment count = %d, want %d", repo.counted, tt.wantCounted)
+			}
+			if err == nil && comment.Username != user.Username {
+				t.Errorf("username = %q, want %q", comment.Username, user.Username)
+			}
+		})
+	}
+}
+
+func TestHeldEditHidesReplies(t *testing.T) {
+	blogID := primitive.NewObjectID()
+	user := primitive.NewObjectID()
+	start := time.Now()
+	comment := func(parent *domain.Comment, minutes int) *domain.Comment {
+		c := &domain.Comment{
+			ID:        primitive.NewObjectID(),
+			BlogID:    blogID,
+			UserID:    user,
+			Content:   "fine",
+			Status:    domain.CommentStatusApproved,
+			CreatedAt: start.Add(time.Duration(minutes) * time.Minute),
+		}
+		if parent != nil {
+			c.ParentID = parent.ID
+			c.Depth = parent.Depth + 1
+		}
+		return c
+	}
+	root := comment(nil, 0)
+	parent := comment(nil, 1)
+	reply := comment(parent, 2)
+	nested := comment(reply, 3)
+	repo := &fakeCommentRepo{comments: map[string]*domain.Comment{}}
+	for _, c := range []*domain.Comment{root, parent, reply, nested} {
+		repo.comments[c.ID.Hex()] = c
+	}
+	uc := NewCommentUsecase(repo, newFakeBlogRepo(&domain.Blog{ID: blogID, AuthorID: user}), nil, wordModerator("spam"))
+
+	thread := func() []primitive.ObjectID {
+		t.Helper()
+		comments, err := uc.GetCommentThread(blogID.Hex(), domain.MaxCommentDepth, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := []primitive.ObjectID{}
+		for _, c := range comments {
+			ids = append(ids, c.ID)
+		}
+		return ids
+	}
+
+	if _, err := uc.UpdateComment(parent.ID.Hex(), user.Hex(), "buy spam now"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := thread(), []primitive.ObjectID{root.ID}; !sameIDs(got, want) {
+		t.Errorf("thread while the edit is held = %v, want %v", got, want)
+	}
+
+	if err := uc.ModerateComment(blogID.Hex(), parent.ID.Hex(), user.Hex(), domain.RoleAuthor, ModerationApprove); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := thread(), []primitive.ObjectID{root.ID, parent.ID, reply.ID, nested.ID}; !sameIDs(got, want) {
+		t.Errorf("thread after approval = %v, want %v", got, want)
+	}
+}
@@ -7,32 +7,32 @@ import (
 
 	"github.com/sol-tad/Blog-post-Api/config"
 	"github.com/sol-tad/Blog-post-Api/domain"
-	"github.com/sol-tad/Blog-post-Api/infrastructure"
 )
 
 type OAuthUsecase struct {
 	userRepo domain.UserRepository
+	twoFactor *TwoFactorUsecase
 }
 
 	
-func NewOAuthUsecase(urepo domain.UserRepository) *OAuthUsecase{
-	return &OAuthUsecase{userRepo: urepo}
+func NewOAuthUsecase(urepo domain.UserRepository, twoFactor *TwoFactorUsecase) *OAuthUsecase{
+	return &OAuthUsecase{userRepo: urepo, twoFactor: twoFactor}
 }
 
 
 //callback function
-func (u *OAuthUsecase) HandleGoogleCallback(code string) (*domain.User, string, string, error){
+func (u *OAuthUsecase) HandleGoogleCallback(code string, device domain.SessionClient) (*domain.User, *domain.LoginResult, error){
 	token,err:=config.GoogleOAuthConfig.Exchange(context.Background(),code)
 
 	if err != nil {
-		return nil, "","", err
+		return nil, nil, err
 	}
 
 	client :=config.GoogleOAuthConfig.Client(context.Background(),token)
 	response,err:=client.Get("https://www.googleapis.com/oauth2/v2/userinfo")
 
 	if err != nil {
-		return nil, "","", err
+		return nil, nil, err
 	}
 	defer response.Body.Close()
 	body,_:=io.ReadAll(response.Body)
@@ -44,7 +44,7 @@ func (u *OAuthUsecase) HandleGoogleCallback(code string) (*domain.User, string,
 		Picture string `json:"picture"`
 	}
 	if err := json.Unmarshal(body, &googleUser); err != nil {
-		return nil, "","", err
+		return nil, nil, err
 	}
 
 	existingUser,_:=u.userRepo.FindByGoogleID(context.Background(),googleUser.ID)
@@ -57,22 +57,24 @@ func (u *OAuthUsecase) HandleGoogleCallback(code string) (*domain.User, string,
 		}
 	}
 
-// Generate tokens
-	accessToken, err := infrastructure.GenerateAccessToken(existingUser.ID.Hex(),existingUser.Role)
-	if err != nil {
-		return nil, "","", err
+	if err := u.userRepo.Save(context.Background(),existingUser); err != nil {
+		return nil, nil, err
 	}
-
-	refreshToken, err := infrastructure.GenerateRefreshToken(existingUser.ID.Hex())
-	if err != nil {
-		return nil, "","", err
+	// A new user only gets an ID once saved
+	if existingUser.ID.IsZero() {
+		saved, err := u.userRepo.FindByGoogleID(context.Background(), googleUser.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		existingUser = saved
 	}
 
-	existingUser.RefreshToken = refreshToken
-	if err := u.userRepo.Save(context.Background(),existingUser); err != nil {
-		return nil, "", "", err
+	// Google only replaces the password; two-factor users still need a code
+	result, err := u.twoFactor.BeginLogin(context.Background(), existingUser, device, domain.AuthMethodGoogle)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return existingUser, accessToken, refreshToken, nil
+	return existingUser, result, nil
 	
 }
\ No newline at end of file
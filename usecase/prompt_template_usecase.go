@@ -0,0 +1,173 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"github.com/sol-tad/Blog-post-Api/infrastructure"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrTemplateNotFound = errors.New("prompt template not found")
+	ErrTemplateExists   = errors.New("prompt template already exists")
+	ErrInvalidTemplate  = errors.New("invalid prompt template")
+	ErrTemplateKind     = errors.New("prompt template cannot be used for this operation")
+)
+
+var templateNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,49}$`)
+
+// samplePromptData is used to check that a template renders before saving it
+var samplePromptData = domain.PromptData{
+	GenerationParams: domain.GenerationParams{
+		Topic:  "topic",
+		Title:  "title",
+		Tags:   []string{"tag"},
+		Tone:   "professional",
+		Length: 800,
+	},
+	Content: "content",
+}
+
+type PromptTemplateUsecase struct {
+	repo domain.PromptTemplateRepository
+}
+
+func NewPromptTemplateUsecase(repo domain.PromptTemplateRepository) *PromptTemplateUsecase {
+	return &PromptTemplateUsecase{
+		repo: repo,
+	}
+}
+
+// ListTemplates returns the latest version of every template
+func (uc *PromptTemplateUsecase) ListTemplates() ([]*domain.PromptTemplate, error) {
+	return uc.repo.ListLatest()
+}
+
+// GetTemplate resolves "name" to the latest version of the template and
+// "name@version" to that version
+func (uc *PromptTemplateUsecase) GetTemplate(ref string) (*domain.PromptTemplate, error) {
+	name, versionStr, pinned := strings.Cut(ref, "@")
+
+	var template *domain.PromptTemplate
+	var err error
+	if pinned {
+		version, convErr := strconv.Atoi(versionStr)
+		if convErr != nil {
+			return nil, ErrTemplateNotFound
+		}
+		template, err = uc.repo.GetVersion(name, version)
+	} else {
+		template, err = uc.repo.Latest(name)
+	}
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrTemplateNotFound
+	}
+	return template, err
+}
+
+func (uc *PromptTemplateUsecase) ListVersions(name string) ([]*domain.PromptTemplate, error) {
+	versions, err := uc.repo.Versions(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, ErrTemplateNotFound
+	}
+	return versions, nil
+}
+
+// CreateTemplate saves the first version of a new template
+func (uc *PromptTemplateUsecase) CreateTemplate(template *domain.PromptTemplate, adminID string) (*domain.PromptTemplate, error) {
+	if err := validateTemplate(template); err != nil {
+		return nil, err
+	}
+	if _, err := uc.repo.Latest(template.Name); err == nil {
+		return nil, ErrTemplateExists
+	} else if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	template.Version = 1
+	return template, uc.save(template, adminID)
+}
+
+// UpdateTemplate saves a new version of the template. An empty body or
+// description keeps the previous one; the kind cannot change.
+func (uc *PromptTemplateUsecase) UpdateTemplate(name string, changes domain.PromptTemplate, adminID string) (*domain.PromptTemplate, error) {
+	latest, err := uc.GetTemplate(name)
+	if err != nil {
+		return nil, err
+	}
+	if changes.Kind != "" && changes.Kind != latest.Kind {
+		return nil, fmt.Errorf("%w: kind cannot be changed", ErrInvalidTemplate)
+	}
+
+	next := &domain.PromptTemplate{
+		Name:        latest.Name,
+		Version:     latest.Version + 1,
+		Kind:        latest.Kind,
+		Description: latest.Description,
+		Body:        latest.Body,
+	}
+	if changes.Description != "" {
+		next.Description = changes.Description
+	}
+	if changes.Body != "" {
+		next.Body = changes.Body
+	}
+	if err := validateTemplate(next); err != nil {
+		return nil, err
+	}
+	return next, uc.save(next, adminID)
+}
+
+// Render resolves the template and fills it with data. The template must
+// be of the given kind.
+func (uc *PromptTemplateUsecase) Render(ref, kind string, data domain.PromptData) (string, *domain.PromptTemplate, error) {
+	template, err := uc.GetTemplate(ref)
+	if err != nil {
+		return "", nil, err
+	}
+	if template.Kind != kind {
+		return "", nil, fmt.Errorf("%w: %s is a %s template", ErrTemplateKind, template.Ref(), template.Kind)
+	}
+	prompt, err := infrastructure.RenderPrompt(template.Body, data)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	return prompt, template, nil
+}
+
+func (uc *PromptTemplateUsecase) save(template *domain.PromptTemplate, adminID string) error {
+	createdBy, err := primitive.ObjectIDFromHex(adminID)
+	if err != nil {
+		return ErrInvalidAIUser
+	}
+	template.ID = primitive.NilObjectID
+	template.CreatedBy = createdBy
+	template.CreatedAt = time.Now()
+	return uc.repo.Create(template)
+}
+
+func validateTemplate(template *domain.PromptTemplate) error {
+	if !templateNamePattern.MatchString(template.Name) {
+		return fmt.Errorf("%w: name must be 1-50 lowercase letters, digits, - or _", ErrInvalidTemplate)
+	}
+	if template.Kind != domain.PromptKindGenerate && template.Kind != domain.PromptKindSummarize {
+		return fmt.Errorf("%w: kind must be %s or %s", ErrInvalidTemplate, domain.PromptKindGenerate, domain.PromptKindSummarize)
+	}
+	if strings.TrimSpace(template.Body) == "" {
+		return fmt.Errorf("%w: body is required", ErrInvalidTemplate)
+	}
+	if _, err := infrastructure.RenderPrompt(template.Body, samplePromptData); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	return nil
+}
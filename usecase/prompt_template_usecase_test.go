@@ -0,0 +1,239 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"github.com/sol-tad/Blog-post-Api/infrastructure"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// fakeTemplateRepo keeps every version of each template, oldest first
+type fakeTemplateRepo struct {
+	domain.PromptTemplateRepository
+	versions map[string][]*domain.PromptTemplate
+}
+
+func newFakeTemplateRepo(templates ...*domain.PromptTemplate) *fakeTemplateRepo {
+	r := &fakeTemplateRepo{versions: map[string][]*domain.PromptTemplate{}}
+	for _, template := range templates {
+		r.Create(template)
+	}
+	return r
+}
+
+func (r *fakeTemplateRepo) Create(template *domain.PromptTemplate) error {
+	template.ID = primitive.NewObjectID()
+	r.versions[template.Name] = append(r.versions[template.Name], template)
+	return nil
+}
+
+func (r *fakeTemplateRepo) Latest(name string) (*domain.PromptTemplate, error) {
+	versions := r.versions[name]
+	if len(versions) == 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+	return versions[len(versions)-1], nil
+}
+
+func (r *fakeTemplateRepo) GetVersion(name string, version int) (*domain.PromptTemplate, error) {
+	for _, template := range r.versions[name] {
+		if template.Version == version {
+			return template, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (r *fakeTemplateRepo) Versions(name string) ([]*domain.PromptTemplate, error) {
+	return r.versions[name], nil
+}
+
+func outlineTemplate() *domain.PromptTemplate {
+	return &domain.PromptTemplate{Name: "outline", Version: 1, Kind: domain.PromptKindGenerate, Body: "Outline {{.Topic}} in a {{.Tone}} tone"}
+}
+
+func TestCreateTemplate(t *testing.T) {
+	admin := primitive.NewObjectID().Hex()
+
+	tests := []struct {
+		name     string
+		template domain.PromptTemplate
+		adminID  string
+		wantErr  error
+	}{
+		{"new template", domain.PromptTemplate{Name: "brief", Kind: domain.PromptKindSummarize, Body: "Sum up {{.Content}}"}, admin, nil},
+		{"name taken", domain.PromptTemplate{Name: "outline", Kind: domain.PromptKindGenerate, Body: "{{.Topic}}"}, admin, ErrTemplateExists},
+		{"bad name", domain.PromptTemplate{Name: "Not Allowed", Kind: domain.PromptKindGenerate, Body: "{{.Topic}}"}, admin, ErrInvalidTemplate},
+		{"unknown kind", domain.PromptTemplate{Name: "brief", Kind: "translate", Body: "{{.Topic}}"}, admin, ErrInvalidTemplate},
+		{"empty body", domain.PromptTemplate{Name: "brief", Kind: domain.PromptKindGenerate, Body: "  "}, admin, ErrInvalidTemplate},
+		{"unknown field", domain.PromptTemplate{Name: "brief", Kind: domain.PromptKindGenerate, Body: "{{.Audience}}"}, admin, ErrInvalidTemplate},
+		{"broken syntax", domain.PromptTemplate{Name: "brief", Kind: domain.PromptKindGenerate, Body: "{{.Topic"}, admin, ErrInvalidTemplate},
+		{"bad admin id", domain.PromptTemplate{Name: "brief", Kind: domain.PromptKindGenerate, Body: "{{.Topic}}"}, "nope", ErrInvalidAIUser},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeTemplateRepo(outlineTemplate())
+			uc := NewPromptTemplateUsecase(repo)
+
+			template := tt.template
+			created, err := uc.CreateTemplate(&template, tt.adminID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if tt.wantErr != ErrTemplateExists && len(repo.versions[tt.template.Name]) != 0 {
+					t.Error("an invalid template was saved")
+				}
+				return
+			}
+			if created.Version != 1 || created.CreatedBy.Hex() != admin || created.CreatedAt.IsZero() {
+				t.Errorf("created %+v, want version 1 by %s", created, admin)
+			}
+			if len(repo.versions[created.Name]) != 1 {
+				t.Errorf("stored %d versions, want 1", len(repo.versions[created.Name]))
+			}
+		})
+	}
+}
+
+func TestUpdateTemplate(t *testing.T) {
+	admin := primitive.NewObjectID().Hex()
+
+	tests := []struct {
+		name        string
+		ref         string
+		changes     domain.PromptTemplate
+		wantErr     error
+		wantBody    string
+		wantVersion int
+	}{
+		{"new body", "outline", domain.PromptTemplate{Body: "List {{.Topic}}"}, nil, "List {{.Topic}}", 2},
+		{"description only keeps the body", "outline", domain.PromptTemplate{Description: "shorter"}, nil, "Outline {{.Topic}} in a {{.Tone}} tone", 2},
+		{"same kind", "outline", domain.PromptTemplate{Kind: domain.PromptKindGenerate, Body: "{{.Title}}"}, nil, "{{.Title}}", 2},
+		{"kind change", "outline", domain.PromptTemplate{Kind: domain.PromptKindSummarize}, ErrInvalidTemplate, "", 1},
+		{"invalid body", "outline", domain.PromptTemplate{Body: "{{.Missing}}"}, ErrInvalidTemplate, "", 1},
+		{"unknown template", "intro", domain.PromptTemplate{Body: "{{.Topic}}"}, ErrTemplateNotFound, "", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeTemplateRepo(outlineTemplate())
+			uc := NewPromptTemplateUsecase(repo)
+
+			updated, err := uc.UpdateTemplate(tt.ref, tt.changes, admin)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got := len(repo.versions["outline"]); got != tt.wantVersion {
+				t.Errorf("stored %d versions, want %d", got, tt.wantVersion)
+			}
+			if err != nil {
+				return
+			}
+			if updated.Version != tt.wantVersion || updated.Body != tt.wantBody || updated.Kind != domain.PromptKindGenerate {
+				t.Errorf("updated %+v, want version %d with body %q", updated, tt.wantVersion, tt.wantBody)
+			}
+			if first, _ := repo.GetVersion("outline", 1); first.Body != outlineTemplate().Body {
+				t.Errorf("version 1 body = %q, want it unchanged", first.Body)
+			}
+		})
+	}
+}
+
+func TestGetTemplate(t *testing.T) {
+	repo := newFakeTemplateRepo(outlineTemplate())
+	repo.Create(&domain.PromptTemplate{Name: "outline", Version: 2, Kind: domain.PromptKindGenerate, Body: "List {{.Topic}}"})
+	uc := NewPromptTemplateUsecase(repo)
+
+	tests := []struct {
+		name        string
+		ref         string
+		wantErr     error
+		wantVersion int
+	}{
+		{"latest", "outline", nil, 2},
+		{"pinned", "outline@1", nil, 1},
+		{"unknown version", "outline@3", ErrTemplateNotFound, 0},
+		{"bad version", "outline@latest", ErrTemplateNotFound, 0},
+		{"unknown name", "intro", ErrTemplateNotFound, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template, err := uc.GetTemplate(tt.ref)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && template.Version != tt.wantVersion {
+				t.Errorf("version = %d, want %d", template.Version, tt.wantVersion)
+			}
+		})
+	}
+
+	versions, err := uc.ListVersions("outline")
+	if err != nil || len(versions) != 2 {
+		t.Errorf("ListVersions() = %d versions, %v; want 2", len(versions), err)
+	}
+	if _, err := uc.ListVersions("intro"); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("ListVersions of an unknown template: err = %v, want %v", err, ErrTemplateNotFound)
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	repo := newFakeTemplateRepo(outlineTemplate(), &domain.PromptTemplate{Name: "brief", Version: 1, Kind: domain.PromptKindSummarize, Body: "Sum up {{upper .Content}}"})
+	uc := NewPromptTemplateUsecase(repo)
+	data := domain.PromptData{GenerationParams: domain.GenerationParams{Topic: "caching", Tone: "casual"}, Content: "hot data"}
+
+	tests := []struct {
+		name       string
+		ref        string
+		kind       string
+		wantErr    error
+		wantPrompt string
+	}{
+		{"generate", "outline", domain.PromptKindGenerate, nil, "Outline caching in a casual tone"},
+		{"summarize", "brief@1", domain.PromptKindSummarize, nil, "Sum up HOT DATA"},
+		{"wrong kind", "brief", domain.PromptKindGenerate, ErrTemplateKind, ""},
+		{"unknown", "intro", domain.PromptKindGenerate, ErrTemplateNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prompt, _, err := uc.Render(tt.ref, tt.kind, data)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if prompt != tt.wantPrompt {
+				t.Errorf("prompt = %q, want %q", prompt, tt.wantPrompt)
+			}
+		})
+	}
+}
+
+func TestGenerateWithTemplate(t *testing.T) {
+	fake, _ := infrastructure.NewFakeAIProvider()
+	uc, usage, _ := newTestAIUseCase(fake, 0)
+	uc.templates = NewPromptTemplateUsecase(newFakeTemplateRepo(outlineTemplate()))
+	userID := primitive.NewObjectID().Hex()
+
+	content, err := uc.GenerateBlog(context.Background(), domain.GenerationParams{Topic: "caching", Template: "outline@1"}, userID, domain.RoleAuthor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(content, "> Outline caching in a professional tone") {
+		t.Errorf("content does not quote the rendered prompt:\n%s", content)
+	}
+	if len(usage.records) != 1 {
+		t.Errorf("made %d AI calls, want 1", len(usage.records))
+	}
+
+	_, err = uc.GenerateBlog(context.Background(), domain.GenerationParams{Topic: "caching", Template: "intro"}, userID, domain.RoleAuthor)
+	if !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("unknown template: err = %v, want %v", err, ErrTemplateNotFound)
+	}
+	if len(usage.records) != 1 {
+		t.Error("an unknown template still called the provider")
+	}
+}
@@ -0,0 +1,113 @@
+package usecase
+
+import (
+	"errors"
+	"time"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"github.com/sol-tad/Blog-post-Api/infrastructure"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// saveRevision snapshots the stored blog before it gets overwritten
+func (b *BlogUseCase) saveRevision(previous *domain.Blog, editorID string) error {
+	editor, _ := primitive.ObjectIDFromHex(editorID)
+	return b.RevisionRepo.Create(&domain.BlogRevision{
+		BlogID:   previous.ID,
+		Title:    previous.Title,
+		Content:  previous.Content,
+		Tags:     previous.Tags,
+		EditedBy: editor,
+		EditedAt: time.Now(),
+	})
+}
+
+// ListRevisions lists the revisions of a blog, newest first
+func (b *BlogUseCase) ListRevisions(blogID, userID, role string, page, limit int) ([]*domain.BlogRevision, error) {
+	id, _, err := b.ownedBlog(blogID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 50 {
+		limit = 10
+	}
+	return b.RevisionRepo.ListByBlog(id, page, limit)
+}
+
+func (b *BlogUseCase) GetRevision(blogID, userID, role string, revision int) (*domain.BlogRevision, error) {
+	id, _, err := b.ownedBlog(blogID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	return b.getRevision(id, revision)
+}
+
+// DiffRevisions compares two revisions line by line. A to of 0 compares
+// against the current version of the blog.
+func (b *BlogUseCase) DiffRevisions(blogID, userID, role string, from, to int) (*domain.RevisionDiff, error) {
+	id, blog, err := b.ownedBlog(blogID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	older, err := b.getRevision(id, from)
+	if err != nil {
+		return nil, err
+	}
+
+	newerTitle, newerContent := blog.Title, blog.Content
+	if to != 0 {
+		newer, err := b.getRevision(id, to)
+		if err != nil {
+			return nil, err
+		}
+		newerTitle, newerContent = newer.Title, newer.Content
+	}
+
+	return &domain.RevisionDiff{
+		From:    from,
+		To:      to,
+		Title:   infrastructure.DiffLines(older.Title, newerTitle),
+		Content: infrastructure.DiffLines(older.Content, newerContent),
+	}, nil
+}
+
+// RestoreRevision brings back the title, content and tags of a revision.
+// The restore is itself an update, so the replaced version gets a revision
+// of its own.
+func (b *BlogUseCase) RestoreRevision(blogID, userID, role string, revision int) (*domain.Blog, error) {
+	id, blog, err := b.ownedBlog(blogID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	rev, err := b.getRevision(id, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	blog.Title = rev.Title
+	blog.Content = rev.Content
+	blog.Tags = rev.Tags
+	if err := b.UpdateBlog(blogID, userID, blog); err != nil {
+		return nil, err
+	}
+	return blog, nil
+}
+
+func (b *BlogUseCase) getRevision(id primitive.ObjectID, revision int) (*domain.BlogRevision, error) {
+	rev, err := b.RevisionRepo.GetByNumber(id, revision)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrRevisionNotFound
+		}
+		return nil, err
+	}
+	return rev, nil
+}
@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidRole   = errors.New("role must be one of reader, author, editor, moderator or admin")
+	ErrOwnRole       = errors.New("you cannot change your own role")
+	ErrRoleUnchanged = errors.New("user already has this role")
+)
+
+const maxRoleChangeReason = 500
+
+// RoleUsecase assigns roles. Every assignment is recorded in the audit
+// trail and revokes the user's access tokens, which carry the old role.
+type RoleUsecase struct {
+	users    domain.UserRepository
+	changes  domain.RoleChangeRepository
+	sessions *SessionUsecase
+}
+
+func NewRoleUsecase(users domain.UserRepository, changes domain.RoleChangeRepository, sessions *SessionUsecase) *RoleUsecase {
+	return &RoleUsecase{
+		users:    users,
+		changes:  changes,
+		sessions: sessions,
+	}
+}
+
+// Roles lists the assignable roles and their permissions
+func (r *RoleUsecase) Roles() []domain.RoleDefinition {
+	return domain.RoleDefinitions()
+}
+
+// AssignRole gives the target user a role on behalf of actorID. Callers
+// check that the actor may manage roles.
+func (r *RoleUsecase) AssignRole(ctx context.Context, actorID, targetID, role, reason string) (*domain.RoleChange, error) {
+	if !domain.IsAssignableRole(role) {
+		return nil, ErrInvalidRole
+	}
+	// Nobody can raise their own privileges, and as admins cannot demote
+	// themselves, the last admin cannot leave the site without one
+	if actorID == targetID {
+		return nil, ErrOwnRole
+	}
+	actor, err := primitive.ObjectIDFromHex(actorID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	target, err := r.users.FindByID(ctx, targetID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if target.Role == role {
+		return nil, ErrRoleUnchanged
+	}
+
+	reason = strings.TrimSpace(reason)
+	if len(reason) > maxRoleChangeReason {
+		reason = reason[:maxRoleChangeReason]
+	}
+	change := &domain.RoleChange{
+		UserID:    target.ID,
+		OldRole:   target.Role,
+		NewRole:   role,
+		ChangedBy: actor,
+		Reason:    reason,
+		CreatedAt: time.Now(),
+	}
+	// Record the change before making it, so no change goes unaudited
+	if err := r.changes.Create(ctx, change); err != nil {
+		return nil, err
+	}
+	if err := r.users.UpdateUserRole(ctx, targetID, role); err != nil {
+		if delErr := r.changes.Delete(ctx, change.ID); delErr != nil {
+			log.Println("removing role change audit entry failed:", delErr)
+		}
+		return nil, err
+	}
+	// The role changed; tokens still carrying the old one must go
+	if err := r.sessions.RevokeAccessTokens(ctx, targetID); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// ListRoleChanges returns the audit trail of one user, or of everyone when
+// userID is empty
+func (r *RoleUsecase) ListRoleChanges(ctx context.Context, userID string, page, limit int) ([]*domain.RoleChange, int64, error) {
+	var id primitive.ObjectID
+	if userID != "" {
+		var err error
+		if id, err = primitive.ObjectIDFromHex(userID); err != nil {
+			return nil, 0, ErrUserNotFound
+		}
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	return r.changes.List(ctx, id, page, limit)
+}
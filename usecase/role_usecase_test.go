@@ -0,0 +1,204 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// fakeUserRepo keeps users in memory. Methods a test does not need are left
+// to the embedded interface and panic when called.
+type fakeUserRepo struct {
+	domain.UserRepository
+	users      map[string]*domain.User
+	updateErr  error
+	roleWrites int
+}
+
+func newFakeUserRepo(users ...*domain.User) *fakeUserRepo {
+	repo := &fakeUserRepo{users: make(map[string]*domain.User)}
+	for _, user := range users {
+		repo.users[user.ID.Hex()] = user
+	}
+	return repo
+}
+
+func (r *fakeUserRepo) FindByID(ctx context.Context, userID string) (domain.User, error) {
+	user, ok := r.users[userID]
+	if !ok {
+		return domain.User{}, mongo.ErrNoDocuments
+	}
+	return *user, nil
+}
+
+func (r *fakeUserRepo) GetByID(userID primitive.ObjectID) *domain.User {
+	user, ok := r.users[userID.Hex()]
+	if !ok {
+		return nil
+	}
+	copied := *user
+	return &copied
+}
+
+func (r *fakeUserRepo) UpdateUserRole(ctx context.Context, userID string, role string) error {
+	if r.updateErr != nil {
+		return r.updateErr
+	}
+	r.roleWrites++
+	r.users[userID].Role = role
+	return nil
+}
+
+type fakeRoleChanges struct {
+	changes   []*domain.RoleChange
+	createErr error
+}
+
+func (r *fakeRoleChanges) Create(ctx context.Context, change *domain.RoleChange) error {
+	if r.createErr != nil {
+		return r.createErr
+	}
+	change.ID = primitive.NewObjectID()
+	r.changes = append(r.changes, change)
+	return nil
+}
+
+func (r *fakeRoleChanges) Delete(ctx context.Context, id primitive.ObjectID) error {
+	for i, change := range r.changes {
+		if change.ID == id {
+			r.changes = append(r.changes[:i], r.changes[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *fakeRoleChanges) List(ctx context.Context, userID primitive.ObjectID, page, limit int) ([]*domain.RoleChange, int64, error) {
+	return r.changes, int64(len(r.changes)), nil
+}
+
+// fakeRevocations records revocations in memory
+type fakeRevocations struct {
+	revoked map[string]time.Time
+}
+
+func newFakeRevocations() *fakeRevocations {
+	return &fakeRevocations{revoked: make(map[string]time.Time)}
+}
+
+func (r *fakeRevocations) Revoke(ctx context.Context, key string, at, expiresAt time.Time) error {
+	r.revoked[key] = at
+	return nil
+}
+
+func (r *fakeRevocations) IsRevoked(ctx context.Context, issuedAt time.Time, keys ...string) (bool, error) {
+	for _, key := range keys {
+		if at, ok := r.revoked[key]; ok && !issuedAt.After(at) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestAssignRole(t *testing.T) {
+	admin := &domain.User{ID: primitive.NewObjectID(), Role: domain.RoleAdmin}
+
+	tests := []struct {
+		name     string
+		actorID  string
+		role     string
+		current  string
+		wantErr  error
+		wantRole string
+	}{
+		{"promote an author to editor", admin.ID.Hex(), domain.RoleEditor, domain.RoleAuthor, nil, domain.RoleEditor},
+		{"move a legacy user to author", admin.ID.Hex(), domain.RoleAuthor, domain.RoleUser, nil, domain.RoleAuthor},
+		{"unknown role", admin.ID.Hex(), "owner", domain.RoleAuthor, ErrInvalidRole, domain.RoleAuthor},
+		{"legacy role cannot be assigned", admin.ID.Hex(), domain.RoleUser, domain.RoleAuthor, ErrInvalidRole, domain.RoleAuthor},
+		{"same role", admin.ID.Hex(), domain.RoleAuthor, domain.RoleAuthor, ErrRoleUnchanged, domain.RoleAuthor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := &domain.User{ID: primitive.NewObjectID(), Role: tt.current}
+			users := newFakeUserRepo(admin, target)
+			changes := &fakeRoleChanges{}
+			revocations := newFakeRevocations()
+			uc := NewRoleUsecase(users, changes, NewSessionUsecase(nil, users, revocations, nil))
+
+			change, err := uc.AssignRole(context.Background(), tt.actorID, target.ID.Hex(), tt.role, "  reason  ")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got := users.users[target.ID.Hex()].Role; got != tt.wantRole {
+				t.Errorf("role = %q, want %q", got, tt.wantRole)
+			}
+			if err != nil {
+				if len(changes.changes) != 0 {
+					t.Errorf("failed change was audited: %+v", changes.changes)
+				}
+				return
+			}
+
+			if change.OldRole != tt.current || change.NewRole != tt.role || change.ChangedBy != admin.ID || change.Reason != "reason" {
+				t.Errorf("unexpected audit entry %+v", change)
+			}
+			if len(changes.changes) != 1 {
+				t.Errorf("got %d audit entries, want 1", len(changes.changes))
+			}
+			if _, ok := revocations.revoked[domain.UserRevocationKey(target.ID.Hex())]; !ok {
+				t.Error("the user's access tokens were not revoked")
+			}
+		})
+	}
+}
+
+func TestAssignRoleRefusesOwnRole(t *testing.T) {
+	admin := &domain.User{ID: primitive.NewObjectID(), Role: domain.RoleAdmin}
+	users := newFakeUserRepo(admin)
+	uc := NewRoleUsecase(users, &fakeRoleChanges{}, NewSessionUsecase(nil, users, newFakeRevocations(), nil))
+
+	_, err := uc.AssignRole(context.Background(), admin.ID.Hex(), admin.ID.Hex(), domain.RoleAuthor, "")
+	if !errors.Is(err, ErrOwnRole) {
+		t.Fatalf("err = %v, want %v", err, ErrOwnRole)
+	}
+	if users.roleWrites != 0 {
+		t.Error("own role was changed")
+	}
+}
+
+func TestAssignRoleAuditsBeforeChanging(t *testing.T) {
+	admin := &domain.User{ID: primitive.NewObjectID(), Role: domain.RoleAdmin}
+	target := &domain.User{ID: primitive.NewObjectID(), Role: domain.RoleAuthor}
+
+	t.Run("audit fails", func(t *testing.T) {
+		users := newFakeUserRepo(admin, target)
+		changes := &fakeRoleChanges{createErr: errors.New("insert failed")}
+		uc := NewRoleUsecase(users, changes, NewSessionUsecase(nil, users, newFakeRevocations(), nil))
+
+		if _, err := uc.AssignRole(context.Background(), admin.ID.Hex(), target.ID.Hex(), domain.RoleEditor, ""); err == nil {
+			t.Fatal("expected an error")
+		}
+		if users.roleWrites != 0 {
+			t.Error("role changed without an audit entry")
+		}
+	})
+
+	t.Run("role update fails", func(t *testing.T) {
+		users := newFakeUserRepo(admin, target)
+		users.updateErr = errors.New("update failed")
+		changes := &fakeRoleChanges{}
+		uc := NewRoleUsecase(users, changes, NewSessionUsecase(nil, users, newFakeRevocations(), nil))
+
+		if _, err := uc.AssignRole(context.Background(), admin.ID.Hex(), target.ID.Hex(), domain.RoleEditor, ""); err == nil {
+			t.Fatal("expected an error")
+		}
+		if len(changes.changes) != 0 {
+			t.Error("audit entry kept for a change that did not happen")
+		}
+	})
+}
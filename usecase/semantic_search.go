@@ -0,0 +1,277 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrSearchUnavailable = errors.New("semantic search is not available: no embedder is configured")
+	ErrEmptyQuery        = errors.New("search query is empty")
+)
+
+const (
+	defaultSearchResults = 10
+	maxSearchResults     = 50
+	// indexTimeout bounds embedding a blog in the background
+	indexTimeout = 30 * time.Second
+	// rankPageSize is how many embeddings ranking loads at a time, and
+	// maxRankedEmbeddings how many one query looks at in total
+	rankPageSize        = 500
+	maxRankedEmbeddings = 20000
+	// candidatesPerResult is how many of the best scores are kept per
+	// wanted result, leaving room for blogs that turn out unpublished
+	candidatesPerResult = 4
+)
+
+// SemanticSearchUsecase ranks blogs by the cosine similarity of their
+// embeddings. Every stored blog is embedded, so scheduled blogs are
+// searchable as soon as they go live; only published blogs are returned.
+type SemanticSearchUsecase struct {
+	embedder   domain.Embedder
+	embeddings domain.BlogEmbeddingRepository
+	blogRepo   IBlogRepo
+}
+
+// NewSemanticSearchUsecase accepts a nil embedder, in which case searches
+// fail with ErrSearchUnavailable and indexing does nothing
+func NewSemanticSearchUsecase(embedder domain.Embedder, embeddings domain.BlogEmbeddingRepository, blogRepo IBlogRepo) *SemanticSearchUsecase {
+	return &SemanticSearchUsecase{
+		embedder:   embedder,
+		embeddings: embeddings,
+		blogRepo:   blogRepo,
+	}
+}
+
+// IndexBlog stores the embedding of the blog unless the stored one is
+// still current
+func (s *SemanticSearchUsecase) IndexBlog(ctx context.Context, blog *domain.Blog) error {
+	if s == nil || s.embedder == nil {
+		return nil
+	}
+	text := embeddingText(blog)
+	hash := domain.HashContent(text)
+	model := s.embedder.EmbeddingModel()
+
+	existing, err := s.embeddings.Get(blog.ID)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+	if existing != nil && existing.Model == model && existing.SourceHash == hash {
+		return nil
+	}
+
+	vector, err := s.embedder.Embed(ctx, text)
+	if err != nil {
+		return err
+	}
+	return s.embeddings.Save(&domain.BlogEmbedding{
+		BlogID:     blog.ID,
+		Model:      model,
+		Vector:     vector,
+		SourceHash: hash,
+		UpdatedAt:  time.Now(),
+	})
+}
+
+// indexInBackground embeds a copy of the blog without holding up the caller
+func (s *SemanticSearchUsecase) indexInBackground(blog *domain.Blog) {
+	if s == nil || s.embedder == nil {
+		return
+	}
+	snapshot := *blog
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), indexTimeout)
+		defer cancel()
+		if err := s.IndexBlog(ctx, &snapshot); err != nil {
+			fmt.Println("indexing blog failed:", err)
+		}
+	}()
+}
+
+// RemoveBlog drops the embedding of a deleted blog
+func (s *SemanticSearchUsecase) RemoveBlog(id primitive.ObjectID) error {
+	if s == nil {
+		return nil
+	}
+	return s.embeddings.Delete(id)
+}
+
+// IndexAll embeds every blog whose embedding is missing or out of date,
+// such as blogs stored before search existed or after the embedder
+// changed. It returns how many blogs it went through.
+func (s *SemanticSearchUsecase) IndexAll(ctx context.Context) (int, error) {
+	if s == nil || s.embedder == nil {
+		return 0, ErrSearchUnavailable
+	}
+	blogs := s.blogRepo.RetriveAll()
+	for i := range blogs {
+		if err := ctx.Err(); err != nil {
+			return i, err
+		}
+		if err := s.IndexBlog(ctx, &blogs[i]); err != nil {
+			fmt.Println("indexing blog failed:", blogs[i].ID.Hex(), err)
+		}
+	}
+	return len(blogs), nil
+}
+
+// Search returns the published blogs closest in meaning to the query
+func (s *SemanticSearchUsecase) Search(ctx context.Context, query string, limit int) ([]*domain.ScoredBlog, error) {
+	if s == nil || s.embedder == nil {
+		return nil, ErrSearchUnavailable
+	}
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, ErrEmptyQuery
+	}
+	vector, err := s.embedder.Embed(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return s.rank(vector, primitive.NilObjectID, limit)
+}
+
+// Related returns the published blogs closest in meaning to the given blog
+func (s *SemanticSearchUsecase) Related(ctx context.Context, blogID, userID, role string, limit int) ([]*domain.ScoredBlog, error) {
+	if s == nil || s.embedder == nil {
+		return nil, ErrSearchUnavailable
+	}
+	id, err := primitive.ObjectIDFromHex(blogID)
+	if err != nil {
+		return nil, ErrBlogNotFound
+	}
+	blog := s.blogRepo.ViewBlogByID(id)
+	if blog == nil || !blog.VisibleTo(userID, role) {
+		return nil, ErrBlogNotFound
+	}
+
+	if err := s.IndexBlog(ctx, blog); err != nil {
+		return nil, err
+	}
+	embedding, err := s.embeddings.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.rank(embedding.Vector, id, limit)
+}
+
+// rank scores the embeddings of the current model against vector and
+// returns the best published blogs, leaving out exclude. Only the best
+// scores are kept while the embeddings are paged through.
+func (s *SemanticSearchUsecase) rank(vector []float32, exclude primitive.ObjectID, limit int) ([]*domain.ScoredBlog, error) {
+	if limit <= 0 {
+		limit = defaultSearchResults
+	}
+	if limit > maxSearchResults {
+		limit = maxSearchResults
+	}
+
+	keep := limit * candidatesPerResult
+	best := make([]scoredID, 0, 2*keep)
+	model := s.embedder.EmbeddingModel()
+	after := primitive.NilObjectID
+	for seen := 0; seen < maxRankedEmbeddings; {
+		page, err := s.embeddings.ListByModel(model, after, min(rankPageSize, maxRankedEmbeddings-seen))
+		if err != nil {
+			return nil, err
+		}
+		for _, embedding := range page {
+			if embedding.BlogID == exclude {
+				continue
+			}
+			if score := cosineSimilarity(vector, embedding.Vector); score > 0 {
+				best = append(best, scoredID{embedding.BlogID, score})
+			}
+		}
+		if len(best) > keep {
+			best = topScores(best, keep)
+		}
+		seen += len(page)
+		if len(page) < rankPageSize {
+			break
+		}
+		after = page[len(page)-1].BlogID
+	}
+	best = topScores(best, keep)
+
+	scores := make(map[primitive.ObjectID]float64, len(best))
+	candidates := make([]primitive.ObjectID, len(best))
+	for i, candidate := range best {
+		scores[candidate.id] = candidate.score
+		candidates[i] = candidate.id
+	}
+
+	// Some candidates may not be published; look further down the ranking
+	// until there are enough results
+	results := []*domain.ScoredBlog{}
+	for start := 0; start < len(candidates) && len(results) < limit; start += limit * 2 {
+		batch := candidates[start:min(start+limit*2, len(candidates))]
+		blogs, err := s.blogRepo.FindPublishedByIDs(batch)
+		if err != nil {
+			return nil, err
+		}
+		byID := make(map[primitive.ObjectID]*domain.Blog, len(blogs))
+		for _, blog := range blogs {
+			byID[blog.ID] = blog
+		}
+		for _, id := range batch {
+			if blog, ok := byID[id]; ok && len(results) < limit {
+				results = append(results, &domain.ScoredBlog{Blog: blog, Score: scores[id]})
+			}
+		}
+	}
+
+	blogs := make([]*domain.Blog, len(results))
+	for i, result := range results {
+		blogs[i] = result.Blog
+	}
+	withExcerpts(blogs)
+	return results, nil
+}
+
+type scoredID struct {
+	id    primitive.ObjectID
+	score float64
+}
+
+// topScores sorts scores best first and keeps the first n
+func topScores(scores []scoredID, n int) []scoredID {
+	sort.Slice(scores, func(i, j int) bool {
+		return scores[i].score > scores[j].score
+	})
+	return scores[:min(n, len(scores))]
+}
+
+// embeddingText is the text a blog is embedded from. The title is repeated
+// to weigh it above the body.
+func embeddingText(blog *domain.Blog) string {
+	return blog.Title + "\n" + blog.Title + "\n" + strings.Join(blog.Tags, " ") + "\n" + blog.Content
+}
+
+// cosineSimilarity returns the cosine of the angle between a and b, or 0
+// if their sizes differ or either is zero
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
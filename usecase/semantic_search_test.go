@@ -0,0 +1,185 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"github.com/sol-tad/Blog-post-Api/infrastructure"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// memoryEmbeddings keeps embeddings in memory and pages them in blog ID
+// order, like the mongo repository
+type memoryEmbeddings struct {
+	embeddings map[primitive.ObjectID]*domain.BlogEmbedding
+	// largestPage is the most embeddings handed out by one ListByModel call
+	largestPage int
+}
+
+func newMemoryEmbeddings() *memoryEmbeddings {
+	return &memoryEmbeddings{embeddings: make(map[primitive.ObjectID]*domain.BlogEmbedding)}
+}
+
+func (m *memoryEmbeddings) Save(embedding *domain.BlogEmbedding) error {
+	m.embeddings[embedding.BlogID] = embedding
+	return nil
+}
+
+func (m *memoryEmbeddings) Get(blogID primitive.ObjectID) (*domain.BlogEmbedding, error) {
+	embedding, ok := m.embeddings[blogID]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	return embedding, nil
+}
+
+func (m *memoryEmbeddings) ListByModel(model string, after primitive.ObjectID, limit int) ([]*domain.BlogEmbedding, error) {
+	page := []*domain.BlogEmbedding{}
+	for _, embedding := range m.embeddings {
+		if embedding.Model == model && embedding.BlogID.Hex() > after.Hex() {
+			page = append(page, embedding)
+		}
+	}
+	sort.Slice(page, func(i, j int) bool {
+		return page[i].BlogID.Hex() < page[j].BlogID.Hex()
+	})
+	page = page[:min(limit, len(page))]
+	m.largestPage = max(m.largestPage, len(page))
+	return page, nil
+}
+
+func (m *memoryEmbeddings) Delete(blogID primitive.ObjectID) error {
+	delete(m.embeddings, blogID)
+	return nil
+}
+
+func (r *fakeBlogRepo) FindPublishedByIDs(ids []primitive.ObjectID) ([]*domain.Blog, error) {
+	blogs := []*domain.Blog{}
+	for _, id := range ids {
+		if blog, ok := r.blogs[id]; ok && blog.Status == domain.BlogStatusPublished {
+			blogs = append(blogs, blog)
+		}
+	}
+	return blogs, nil
+}
+
+// newIndexedSearch indexes every blog with the hash embedder
+func newIndexedSearch(t *testing.T, blogs ...*domain.Blog) (*SemanticSearchUsecase, *memoryEmbeddings) {
+	t.Helper()
+	embeddings := newMemoryEmbeddings()
+	search := NewSemanticSearchUsecase(infrastructure.NewHashEmbedder(0), embeddings, newFakeBlogRepo(blogs...))
+	for _, blog := range blogs {
+		if err := search.IndexBlog(context.Background(), blog); err != nil {
+			t.Fatalf("indexing %q: %v", blog.Title, err)
+		}
+	}
+	return search, embeddings
+}
+
+func publishedBlog(title, content string) *domain.Blog {
+	return &domain.Blog{
+		ID:      primitive.NewObjectID(),
+		Title:   title,
+		Content: content,
+		Status:  domain.BlogStatusPublished,
+	}
+}
+
+func TestSemanticSearchRanking(t *testing.T) {
+	golang := publishedBlog("Goroutines and channels", "Concurrency in golang with goroutines, channels and select.")
+	bread := publishedBlog("Sourdough bread", "Feeding a starter and baking sourdough bread at home.")
+	garden := publishedBlog("Growing tomatoes", "Planting tomatoes in the garden and watering them in summer.")
+	draft := publishedBlog("Channels in golang", "More about golang channels and goroutines.")
+	draft.Status = domain.BlogStatusDraft
+	search, _ := newIndexedSearch(t, golang, bread, garden, draft)
+
+	tests := []struct {
+		name  string
+		query string
+		want  primitive.ObjectID
+	}{
+		{"concurrency", "golang goroutines and channels", golang.ID},
+		{"baking", "how to bake sourdough bread", bread.ID},
+		{"gardening", "watering tomatoes in the garden", garden.ID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := search.Search(context.Background(), tt.query, 3)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			if len(results) == 0 || results[0].Blog.ID != tt.want {
+				t.Fatalf("Search(%q) did not rank the expected blog first: %v", tt.query, results)
+			}
+			for i, result := range results {
+				if result.Blog.ID == draft.ID {
+					t.Error("Search() returned an unpublished blog")
+				}
+				if i > 0 && result.Score > results[i-1].Score {
+					t.Errorf("results are not sorted by score: %v before %v", results[i-1].Score, result.Score)
+				}
+			}
+		})
+	}
+}
+
+func TestSemanticSearchPagesEmbeddings(t *testing.T) {
+	blogs := []*domain.Blog{}
+	for i := 0; i < rankPageSize*2+10; i++ {
+		blogs = append(blogs, publishedBlog(fmt.Sprintf("Filler %d", i), "Lorem ipsum dolor sit amet."))
+	}
+	// The best match sorts last, so it is only found on the final page
+	best := publishedBlog("Sourdough bread", "Feeding a starter and baking sourdough bread at home.")
+	blogs = append(blogs, best)
+	search, embeddings := newIndexedSearch(t, blogs...)
+
+	results, err := search.Search(context.Background(), "baking sourdough bread", 1)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(results) != 1 || results[0].Blog.ID != best.ID {
+		t.Fatalf("Search() = %v, want the blog from the last page", results)
+	}
+	if embeddings.largestPage > rankPageSize {
+		t.Errorf("ranking loaded %d embeddings at once, want at most %d", embeddings.largestPage, rankPageSize)
+	}
+}
+
+func TestSemanticSearchErrors(t *testing.T) {
+	search, _ := newIndexedSearch(t)
+	if _, err := search.Search(context.Background(), "   ", 5); !errors.Is(err, ErrEmptyQuery) {
+		t.Errorf("blank query: error = %v, want %v", err, ErrEmptyQuery)
+	}
+
+	disabled := NewSemanticSearchUsecase(nil, newMemoryEmbeddings(), newFakeBlogRepo())
+	if _, err := disabled.Search(context.Background(), "golang", 5); !errors.Is(err, ErrSearchUnavailable) {
+		t.Errorf("no embedder: error = %v, want %v", err, ErrSearchUnavailable)
+	}
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float32
+		want float64
+	}{
+		{"same direction", []float32{1, 2, 3}, []float32{2, 4, 6}, 1},
+		{"opposite", []float32{1, 0}, []float32{-1, 0}, -1},
+		{"orthogonal", []float32{1, 0}, []float32{0, 1}, 0},
+		{"zero vector", []float32{0, 0}, []float32{1, 1}, 0},
+		{"different lengths", []float32{1, 0}, []float32{1, 0, 0}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cosineSimilarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("cosineSimilarity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
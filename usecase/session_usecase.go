@@ -0,0 +1,266 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"github.com/sol-tad/Blog-post-Api/infrastructure"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrInvalidRefreshToken = infrastructure.ErrInvalidRefreshToken
+	ErrRefreshTokenReused  = errors.New("refresh token was already used; the session has been signed out")
+	ErrSessionNotFound     = errors.New("session not found")
+)
+
+const maxDeviceName = 100
+
+// SessionUsecase issues the tokens of signed in devices. Every refresh
+// rotates the refresh token, and a rotated token presented again revokes
+// its session. Ending a session also revokes the access tokens issued to
+// it, so they stop working before they expire.
+type SessionUsecase struct {
+	sessions    domain.SessionRepository
+	users       domain.UserRepository
+	revocations domain.TokenRevocationRepository
+	policies    domain.TwoFactorPolicyRepository
+}
+
+func NewSessionUsecase(sessions domain.SessionRepository, users domain.UserRepository, revocations domain.TokenRevocationRepository, policies domain.TwoFactorPolicyRepository) *SessionUsecase {
+	return &SessionUsecase{
+		sessions:    sessions,
+		users:       users,
+		revocations: revocations,
+		policies:    policies,
+	}
+}
+
+// StartSession signs the user in on a new device. authMethods lists the
+// factors the user passed, which decide whether a role that requires
+// two-factor authentication is granted.
+func (s *SessionUsecase) StartSession(ctx context.Context, user *domain.User, client domain.SessionClient, authMethods []string) (*domain.LoginResult, error) {
+	role, withheld, err := s.tokenRole(ctx, user.Role, authMethods)
+	if err != nil {
+		return nil, err
+	}
+	sessionID := primitive.NewObjectID()
+	accessToken, err := infrastructure.GenerateAccessToken(user.ID.Hex(), role, sessionID.Hex(), authMethods)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := infrastructure.GenerateRefreshToken(user.ID.Hex(), sessionID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	device := strings.TrimSpace(client.Device)
+	if len(device) > maxDeviceName {
+		device = device[:maxDeviceName]
+	}
+	if device == "" {
+		device = infrastructure.DescribeDevice(client.UserAgent)
+	}
+	now := time.Now()
+	err = s.sessions.Create(ctx, &domain.Session{
+		ID:          sessionID,
+		UserID:      user.ID,
+		Device:      device,
+		UserAgent:   client.UserAgent,
+		IP:          client.IP,
+		TokenHash:   domain.HashContent(refreshToken),
+		AuthMethods: authMethods,
+		CreatedAt:   now,
+		LastUsedAt:  now,
+		ExpiresAt:   now.Add(infrastructure.RefreshTokenTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &domain.LoginResult{
+		AccessToken:            accessToken,
+		RefreshToken:           refreshToken,
+		TwoFactorSetupRequired: withheld,
+	}, nil
+}
+
+// tokenRole returns the role to put in a token. A role the two-factor
+// policy requires a second factor for is withheld from sessions that were
+// not authenticated with one; they get the privileges of a reader.
+func (s *SessionUsecase) tokenRole(ctx context.Context, role string, authMethods []string) (tokenRole string, withheld bool, err error) {
+	if s.policies == nil || domain.HasAuthMethod(authMethods, domain.AuthMethodOTP) {
+		return role, false, nil
+	}
+	policy, err := s.policies.Get(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	if policy.Requires(role) {
+		return domain.RoleReader, true, nil
+	}
+	return role, false, nil
+}
+
+// Refresh exchanges a refresh token for a new access token and a new
+// refresh token. The old refresh token stops working.
+func (s *SessionUsecase) Refresh(ctx context.Context, refreshToken string, client domain.SessionClient) (accessToken string, newRefreshToken string, err error) {
+	userID, sessionID, err := infrastructure.VerifyRefreshToken(refreshToken)
+	if err != nil {
+		return "", "", err
+	}
+	id, err := primitive.ObjectIDFromHex(sessionID)
+	if err != nil {
+		return "", "", ErrInvalidRefreshToken
+	}
+	session, err := s.sessions.Get(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "", "", ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	if session.UserID.Hex() != userID || !session.Active(now) {
+		return "", "", ErrInvalidRefreshToken
+	}
+	oldHash := domain.HashContent(refreshToken)
+	if session.TokenHash != oldHash {
+		return "", "", s.revokeReused(ctx, session)
+	}
+
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return "", "", ErrInvalidRefreshToken
+	}
+	newRefreshToken, err = infrastructure.GenerateRefreshToken(userID, sessionID)
+	if err != nil {
+		return "", "", err
+	}
+	rotated, err := s.sessions.Rotate(ctx, id, oldHash, domain.HashContent(newRefreshToken), client, now, now.Add(infrastructure.RefreshTokenTTL))
+	if err != nil {
+		return "", "", err
+	}
+	if !rotated {
+		// Someone else refreshed with the same token in the meantime
+		return "", "", s.revokeReused(ctx, session)
+	}
+
+	role, _, err := s.tokenRole(ctx, user.Role, session.AuthMethods)
+	if err != nil {
+		return "", "", err
+	}
+	accessToken, err = infrastructure.GenerateAccessToken(userID, role, sessionID, session.AuthMethods)
+	if err != nil {
+		return "", "", err
+	}
+	return accessToken, newRefreshToken, nil
+}
+
+// revokeReused signs out a session whose rotated refresh token came back.
+// Either the client or an attacker holds a stolen token; revoking the whole
+// session locks both out until the owner signs in again.
+func (s *SessionUsecase) revokeReused(ctx context.Context, session *domain.Session) error {
+	log.Println("refresh token reuse detected, revoking session", session.ID.Hex(), "of user", session.UserID.Hex())
+	if err := s.revokeSession(ctx, session.ID, domain.SessionRevokedReuse); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// ListSessions returns the user's active sessions, marking the one the
+// request was made from
+func (s *SessionUsecase) ListSessions(ctx context.Context, userID, currentSessionID string) ([]*domain.Session, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrSessionNotFound
+	}
+	sessions, err := s.sessions.ListActive(ctx, uid, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for _, session := range sessions {
+		session.Current = session.ID.Hex() == currentSessionID
+	}
+	return sessions, nil
+}
+
+// RevokeSession signs one of the user's devices out
+func (s *SessionUsecase) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	return s.revokeOwned(ctx, userID, sessionID, domain.SessionRevokedByUser)
+}
+
+// EndSession signs the user out of the session a request was made from,
+// revoking the access token the request carried as well. Tokens issued
+// before sessions existed carry no session, so those sign the user out
+// everywhere.
+func (s *SessionUsecase) EndSession(ctx context.Context, userID, sessionID, tokenID string, tokenExpiresAt time.Time) error {
+	if tokenID != "" {
+		if err := s.revocations.Revoke(ctx, domain.TokenRevocationKey(tokenID), time.Now(), tokenExpiresAt); err != nil {
+			return err
+		}
+	}
+	if sessionID == "" {
+		return s.RevokeAll(ctx, userID, domain.SessionRevokedLogout)
+	}
+	return s.revokeOwned(ctx, userID, sessionID, domain.SessionRevokedLogout)
+}
+
+// RevokeAll signs the user out of every device
+func (s *SessionUsecase) RevokeAll(ctx context.Context, userID, reason string) error {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return ErrSessionNotFound
+	}
+	if err := s.sessions.RevokeAllForUser(ctx, uid, reason, time.Now()); err != nil {
+		return err
+	}
+	return s.RevokeAccessTokens(ctx, userID)
+}
+
+// RevokeAccessTokens revokes every access token issued to the user so far,
+// leaving their sessions alone. Clients refresh and get a token that
+// reflects the user's current role.
+func (s *SessionUsecase) RevokeAccessTokens(ctx context.Context, userID string) error {
+	now := time.Now()
+	return s.revocations.Revoke(ctx, domain.UserRevocationKey(userID), now, now.Add(infrastructure.AccessTokenTTL))
+}
+
+// RevokeRoleTokens revokes every access token issued with the role, so the
+// next refresh applies a changed two-factor policy
+func (s *SessionUsecase) RevokeRoleTokens(ctx context.Context, role string) error {
+	now := time.Now()
+	return s.revocations.Revoke(ctx, domain.RoleRevocationKey(role), now, now.Add(infrastructure.AccessTokenTTL))
+}
+
+func (s *SessionUsecase) revokeOwned(ctx context.Context, userID, sessionID, reason string) error {
+	id, err := primitive.ObjectIDFromHex(sessionID)
+	if err != nil {
+		return ErrSessionNotFound
+	}
+	session, err := s.sessions.Get(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if session.UserID.Hex() != userID || !session.Active(time.Now()) {
+		return ErrSessionNotFound
+	}
+	return s.revokeSession(ctx, id, reason)
+}
+
+// revokeSession revokes the session and the access tokens issued to it
+func (s *SessionUsecase) revokeSession(ctx context.Context, id primitive.ObjectID, reason string) error {
+	now := time.Now()
+	if err := s.sessions.Revoke(ctx, id, reason, now); err != nil {
+		return err
+	}
+	return s.revocations.Revoke(ctx, domain.SessionRevocationKey(id.Hex()), now, now.Add(infrastructure.AccessTokenTTL))
+}
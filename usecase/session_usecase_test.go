@@ -0,0 +1,196 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"github.com/sol-tad/Blog-post-Api/infrastructure"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// memorySigningKeys keeps signing keys in memory
+type memorySigningKeys struct {
+	domain.SigningKeyRepository
+	mu   sync.Mutex
+	keys []*domain.SigningKey
+}
+
+func (r *memorySigningKeys) CreateBootstrap(ctx context.Context, key *domain.SigningKey) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keys = append(r.keys, key)
+	return true, nil
+}
+
+func (r *memorySigningKeys) List(ctx context.Context, now time.Time) ([]*domain.SigningKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*domain.SigningKey(nil), r.keys...), nil
+}
+
+// setupSigningKeys signs tokens with a fresh key for the duration of the test
+func setupSigningKeys(t *testing.T) {
+	t.Helper()
+	kek := make([]byte, 32)
+	if _, err := rand.Read(kek); err != nil {
+		t.Fatal(err)
+	}
+	keys := infrastructure.NewKeySet(&memorySigningKeys{}, kek)
+	if err := keys.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	previous := infrastructure.SigningKeys
+	infrastructure.SigningKeys = keys
+	t.Cleanup(func() { infrastructure.SigningKeys = previous })
+}
+
+// fakeSessionRepo keeps sessions in memory. Rotate is a compare and set on
+// the token hash, like in Mongo; raceRotate makes the next one lose to a
+// concurrent refresh.
+type fakeSessionRepo struct {
+	domain.SessionRepository
+	sessions   map[primitive.ObjectID]*domain.Session
+	raceRotate bool
+}
+
+func (r *fakeSessionRepo) Create(ctx context.Context, session *domain.Session) error {
+	copied := *session
+	r.sessions[session.ID] = &copied
+	return nil
+}
+
+func (r *fakeSessionRepo) Get(ctx context.Context, id primitive.ObjectID) (*domain.Session, error) {
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	copied := *session
+	return &copied, nil
+}
+
+func (r *fakeSessionRepo) Rotate(ctx context.Context, id primitive.ObjectID, oldHash, newHash string, client domain.SessionClient, usedAt, expiresAt time.Time) (bool, error) {
+	session := r.sessions[id]
+	if r.raceRotate {
+		r.raceRotate = false
+		session.TokenHash = "refreshed concurrently"
+	}
+	if !session.Active(usedAt) || session.TokenHash != oldHash {
+		return false, nil
+	}
+	session.TokenHash = newHash
+	session.LastUsedAt = usedAt
+	session.ExpiresAt = expiresAt
+	return true, nil
+}
+
+func (r *fakeSessionRepo) Revoke(ctx context.Context, id primitive.ObjectID, reason string, at time.Time) error {
+	session := r.sessions[id]
+	session.RevokedAt = &at
+	session.RevokedReason = reason
+	return nil
+}
+
+func TestRefreshDetectsReuse(t *testing.T) {
+	setupSigningKeys(t)
+	user := &domain.User{ID: primitive.NewObjectID(), Role: domain.RoleAuthor}
+
+	tests := []struct {
+		name string
+		// present returns the refresh token to use, given the one from
+		// sign in, after any earlier refreshes
+		present    func(t *testing.T, uc *SessionUsecase, first string) string
+		raceRotate bool
+		wantErr    error
+		wantReason string
+	}{
+		{
+			name:    "latest token",
+			present: func(t *testing.T, uc *SessionUsecase, first string) string { return first },
+		},
+		{
+			name: "rotated token presented again",
+			present: func(t *testing.T, uc *SessionUsecase, first string) string {
+				refresh(t, uc, first)
+				return first
+			},
+			wantErr:    ErrRefreshTokenReused,
+			wantReason: domain.SessionRevokedReuse,
+		},
+		{
+			name:       "token refreshed concurrently",
+			present:    func(t *testing.T, uc *SessionUsecase, first string) string { return first },
+			raceRotate: true,
+			wantErr:    ErrRefreshTokenReused,
+			wantReason: domain.SessionRevokedReuse,
+		},
+		{
+			name:    "not a refresh token",
+			present: func(t *testing.T, uc *SessionUsecase, first string) string { return "garbage" },
+			wantErr: ErrInvalidRefreshToken,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessions := &fakeSessionRepo{sessions: make(map[primitive.ObjectID]*domain.Session)}
+			revocations := newFakeRevocations()
+			uc := NewSessionUsecase(sessions, newFakeUserRepo(user), revocations, nil)
+
+			login, err := uc.StartSession(context.Background(), user, domain.SessionClient{}, []string{domain.AuthMethodPassword})
+			if err != nil {
+				t.Fatal(err)
+			}
+			token := tt.present(t, uc, login.RefreshToken)
+			sessions.raceRotate = tt.raceRotate
+
+			_, _, err = uc.Refresh(context.Background(), token, domain.SessionClient{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+
+			for id, session := range sessions.sessions {
+				if session.RevokedReason != tt.wantReason {
+					t.Errorf("session revoked for %q, want %q", session.RevokedReason, tt.wantReason)
+				}
+				_, revoked := revocations.revoked[domain.SessionRevocationKey(id.Hex())]
+				if revoked != (tt.wantReason != "") {
+					t.Errorf("access tokens of the session revoked = %v", revoked)
+				}
+			}
+		})
+	}
+}
+
+func TestRefreshAfterReuseLocksOutEveryToken(t *testing.T) {
+	setupSigningKeys(t)
+	user := &domain.User{ID: primitive.NewObjectID(), Role: domain.RoleAuthor}
+	sessions := &fakeSessionRepo{sessions: make(map[primitive.ObjectID]*domain.Session)}
+	uc := NewSessionUsecase(sessions, newFakeUserRepo(user), newFakeRevocations(), nil)
+
+	login, err := uc.StartSession(context.Background(), user, domain.SessionClient{}, []string{domain.AuthMethodPassword})
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest := refresh(t, uc, login.RefreshToken)
+	if _, _, err := uc.Refresh(context.Background(), login.RefreshToken, domain.SessionClient{}); err != ErrRefreshTokenReused {
+		t.Fatalf("err = %v, want %v", err, ErrRefreshTokenReused)
+	}
+	// The legitimate client is signed out as well until it signs in again
+	if _, _, err := uc.Refresh(context.Background(), latest, domain.SessionClient{}); err != ErrInvalidRefreshToken {
+		t.Errorf("err = %v, want %v", err, ErrInvalidRefreshToken)
+	}
+}
+
+func refresh(t *testing.T, uc *SessionUsecase, token string) string {
+	t.Helper()
+	_, next, err := uc.Refresh(context.Background(), token, domain.SessionClient{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return next
+}
@@ -0,0 +1,364 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"github.com/sol-tad/Blog-post-Api/infrastructure"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnrolled    = errors.New("two-factor authentication is not set up; start the enrollment first")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrTwoFactorLocked         = errors.New("too many invalid two-factor codes; try again later")
+	ErrInvalidChallengeToken   = infrastructure.ErrInvalidChallengeToken
+	ErrTwoFactorSetupFirst     = errors.New("sign in with two-factor authentication before requiring it for your own role")
+)
+
+const (
+	recoveryCodeCount    = 10
+	maxTwoFactorAttempts = 5
+	twoFactorLockout     = 15 * time.Minute
+	defaultTOTPIssuer    = "Blog API"
+)
+
+// TwoFactorUsecase manages TOTP enrollment and the second step of logins.
+// Each code works once; after maxTwoFactorAttempts wrong codes in a row the
+// account rejects codes for twoFactorLockout, so they cannot be guessed.
+type TwoFactorUsecase struct {
+	twoFactor   domain.TwoFactorRepository
+	policies    domain.TwoFactorPolicyRepository
+	users       domain.UserRepository
+	sessions    *SessionUsecase
+	revocations domain.TokenRevocationRepository
+}
+
+func NewTwoFactorUsecase(twoFactor domain.TwoFactorRepository, policies domain.TwoFactorPolicyRepository, users domain.UserRepository, sessions *SessionUsecase, revocations domain.TokenRevocationRepository) *TwoFactorUsecase {
+	return &TwoFactorUsecase{
+		twoFactor:   twoFactor,
+		policies:    policies,
+		users:       users,
+		sessions:    sessions,
+		revocations: revocations,
+	}
+}
+
+// BeginLogin finishes a login whose first factor, method, was checked, or
+// returns a challenge token if the user has two-factor authentication
+func (t *TwoFactorUsecase) BeginLogin(ctx context.Context, user *domain.User, client domain.SessionClient, method string) (*domain.LoginResult, error) {
+	twoFactor, err := t.twoFactor.Get(ctx, user.ID)
+	if errors.Is(err, mongo.ErrNoDocuments) || (err == nil && !twoFactor.Enabled) {
+		return t.sessions.StartSession(ctx, user, client, []string{method})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	challenge, err := infrastructure.GenerateChallengeToken(user.ID.Hex(), []string{method})
+	if err != nil {
+		return nil, err
+	}
+	return &domain.LoginResult{ChallengeToken: challenge, TwoFactorRequired: true}, nil
+}
+
+// CompleteLogin exchanges a challenge token and a TOTP or recovery code for
+// a session. A challenge token can only be completed once.
+func (t *TwoFactorUsecase) CompleteLogin(ctx context.Context, challengeToken, code string, client domain.SessionClient) (*domain.LoginResult, error) {
+	userID, methods, tokenID, expiresAt, err := infrastructure.VerifyChallengeToken(challengeToken)
+	if err != nil {
+		return nil, err
+	}
+	used, err := t.revocations.IsRevoked(ctx, time.Time{}, domain.TokenRevocationKey(tokenID))
+	if err != nil {
+		return nil, err
+	}
+	if used {
+		return nil, ErrInvalidChallengeToken
+	}
+
+	user, err := t.users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, ErrInvalidChallengeToken
+	}
+	twoFactor, err := t.twoFactor.Get(ctx, user.ID)
+	if errors.Is(err, mongo.ErrNoDocuments) || (err == nil && !twoFactor.Enabled) {
+		return nil, ErrInvalidChallengeToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := t.verifyCode(ctx, twoFactor, code); err != nil {
+		return nil, err
+	}
+
+	if err := t.revocations.Revoke(ctx, domain.TokenRevocationKey(tokenID), time.Now(), expiresAt); err != nil {
+		return nil, err
+	}
+	return t.sessions.StartSession(ctx, &user, client, append(methods, domain.AuthMethodOTP))
+}
+
+// Status returns the user's two-factor setup
+func (t *TwoFactorUsecase) Status(ctx context.Context, userID string) (*domain.TwoFactorStatus, error) {
+	user, err := t.users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := t.policies.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status := &domain.TwoFactorStatus{Required: policy.Requires(user.Role)}
+
+	twoFactor, err := t.twoFactor.Get(ctx, user.ID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return status, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor.Enabled {
+		status.Enabled = true
+		status.EnabledAt = twoFactor.EnabledAt
+		status.RecoveryCodesLeft = len(twoFactor.RecoveryCodes)
+	}
+	return status, nil
+}
+
+// Enroll creates a new TOTP secret for the user to add to an authenticator
+// app. It only takes effect once confirmed with a code; enrolling again
+// before that replaces the secret.
+func (t *TwoFactorUsecase) Enroll(ctx context.Context, userID string) (*domain.TwoFactorEnrollment, error) {
+	user, err := t.users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := infrastructure.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := t.twoFactor.StartEnrollment(ctx, &domain.TwoFactor{
+		UserID:        user.ID,
+		Secret:        secret,
+		CreatedAt:     time.Now(),
+		RecoveryCodes: []string{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !stored {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	issuer := os.Getenv("TOTP_ISSUER")
+	if issuer == "" {
+		issuer = defaultTOTPIssuer
+	}
+	account := user.Email
+	if account == "" {
+		account = user.Username
+	}
+	return &domain.TwoFactorEnrollment{
+		Secret: secret,
+		URI:    infrastructure.TOTPURI(issuer, account, secret),
+	}, nil
+}
+
+// Confirm enables two-factor authentication once the user proves their app
+// generates valid codes. It returns the recovery codes, which are only
+// ever shown this once.
+func (t *TwoFactorUsecase) Confirm(ctx context.Context, userID, code string) ([]string, error) {
+	twoFactor, err := t.get(ctx, userID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor.Enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if err := t.verifyCode(ctx, twoFactor, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	err = t.twoFactor.Enable(ctx, twoFactor.UserID, hashes, time.Now())
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// Confirmed twice at once, or enrolled again in between
+		return nil, ErrTwoFactorNotEnrolled
+	}
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Disable turns two-factor authentication off, after checking a code
+func (t *TwoFactorUsecase) Disable(ctx context.Context, userID, code string) error {
+	twoFactor, err := t.enabled(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := t.verifyCode(ctx, twoFactor, code); err != nil {
+		return err
+	}
+	return t.twoFactor.Delete(ctx, twoFactor.UserID)
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes, after checking
+// a code, and returns the new ones
+func (t *TwoFactorUsecase) RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error) {
+	twoFactor, err := t.enabled(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.verifyCode(ctx, twoFactor, code); err != nil {
+		return nil, err
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := t.twoFactor.ReplaceRecoveryCodes(ctx, twoFactor.UserID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Policy returns the roles that require two-factor authentication
+func (t *TwoFactorUsecase) Policy(ctx context.Context) (*domain.TwoFactorPolicy, error) {
+	return t.policies.Get(ctx)
+}
+
+// SetPolicy changes the roles that require two-factor authentication.
+// Access tokens of newly covered roles are revoked, so sessions without a
+// second factor lose the role at their next refresh. Roles no longer
+// covered are granted again at the next refresh.
+func (t *TwoFactorUsecase) SetPolicy(ctx context.Context, adminID string, authMethods, roles []string) (*domain.TwoFactorPolicy, error) {
+	admin, err := t.users.FindByID(ctx, adminID)
+	if err != nil {
+		return nil, err
+	}
+	required := []string{}
+	seen := map[string]bool{}
+	for _, role := range roles {
+		if !domain.IsAssignableRole(role) {
+			return nil, ErrInvalidRole
+		}
+		if !seen[role] {
+			seen[role] = true
+			required = append(required, role)
+		}
+	}
+	// Requiring it without having it would lock the admin out of the
+	// policy they just set
+	if seen[admin.Role] && !domain.HasAuthMethod(authMethods, domain.AuthMethodOTP) {
+		return nil, ErrTwoFactorSetupFirst
+	}
+
+	previous, err := t.policies.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	policy := &domain.TwoFactorPolicy{
+		RequiredRoles: required,
+		UpdatedAt:     time.Now(),
+		UpdatedBy:     admin.ID,
+	}
+	if err := t.policies.Save(ctx, policy); err != nil {
+		return nil, err
+	}
+	for _, role := range required {
+		if previous.Requires(role) {
+			continue
+		}
+		if err := t.sessions.RevokeRoleTokens(ctx, role); err != nil {
+			return nil, err
+		}
+	}
+	return policy, nil
+}
+
+func (t *TwoFactorUsecase) get(ctx context.Context, userID string) (*domain.TwoFactor, error) {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, mongo.ErrNoDocuments
+	}
+	return t.twoFactor.Get(ctx, id)
+}
+
+func (t *TwoFactorUsecase) enabled(ctx context.Context, userID string) (*domain.TwoFactor, error) {
+	twoFactor, err := t.get(ctx, userID)
+	if errors.Is(err, mongo.ErrNoDocuments) || (err == nil && !twoFactor.Enabled) {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	return twoFactor, err
+}
+
+// verifyCode accepts a TOTP code or, once enabled, an unused recovery code,
+// and counts wrong codes towards a lockout
+func (t *TwoFactorUsecase) verifyCode(ctx context.Context, twoFactor *domain.TwoFactor, code string) error {
+	now := time.Now()
+	if twoFactor.Locked(now) {
+		return ErrTwoFactorLocked
+	}
+	ok, err := t.checkCode(ctx, twoFactor, code, now)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		failures, err := t.twoFactor.RecordFailure(ctx, twoFactor.UserID)
+		if err != nil {
+			return err
+		}
+		if failures >= maxTwoFactorAttempts {
+			if err := t.twoFactor.Lock(ctx, twoFactor.UserID, now.Add(twoFactorLockout)); err != nil {
+				return err
+			}
+			return ErrTwoFactorLocked
+		}
+		return ErrInvalidTwoFactorCode
+	}
+	return t.twoFactor.ResetFailures(ctx, twoFactor.UserID)
+}
+
+func (t *TwoFactorUsecase) checkCode(ctx context.Context, twoFactor *domain.TwoFactor, code string, now time.Time) (bool, error) {
+	if step, ok := infrastructure.ValidateTOTP(twoFactor.Secret, code, now); ok {
+		return t.twoFactor.UseStep(ctx, twoFactor.UserID, step)
+	}
+	if !twoFactor.Enabled || len(infrastructure.NormalizeRecoveryCode(code)) != infrastructure.RecoveryCodeLength {
+		return false, nil
+	}
+	for _, hash := range twoFactor.RecoveryCodes {
+		if infrastructure.CheckRecoveryCode(code, hash) {
+			return t.twoFactor.UseRecoveryCode(ctx, twoFactor.UserID, hash)
+		}
+	}
+	return false, nil
+}
+
+// newRecoveryCodes returns fresh recovery codes and their hashes
+func newRecoveryCodes() (codes, hashes []string, err error) {
+	codes, err = infrastructure.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+	hashes = make([]string, len(codes))
+	for i, code := range codes {
+		if hashes[i], err = infrastructure.HashRecoveryCode(code); err != nil {
+			return nil, nil, err
+		}
+	}
+	return codes, hashes, nil
+}
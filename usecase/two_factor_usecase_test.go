@@ -0,0 +1,109 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sol-tad/Blog-post-Api/domain"
+	"github.com/sol-tad/Blog-post-Api/infrastructure"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeTwoFactorRepo keeps one enrollment in memory with the same compare
+// and set semantics as the Mongo repository
+type fakeTwoFactorRepo struct {
+	domain.TwoFactorRepository
+	twoFactor *domain.TwoFactor
+}
+
+func (r *fakeTwoFactorRepo) UseStep(ctx context.Context, userID primitive.ObjectID, step int64) (bool, error) {
+	if step <= r.twoFactor.LastUsedStep {
+		return false, nil
+	}
+	r.twoFactor.LastUsedStep = step
+	return true, nil
+}
+
+func (r *fakeTwoFactorRepo) UseRecoveryCode(ctx context.Context, userID primitive.ObjectID, hash string) (bool, error) {
+	for i, stored := range r.twoFactor.RecoveryCodes {
+		if stored == hash {
+			r.twoFactor.RecoveryCodes = append(r.twoFactor.RecoveryCodes[:i], r.twoFactor.RecoveryCodes[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeTwoFactorRepo) RecordFailure(ctx context.Context, userID primitive.ObjectID) (int, error) {
+	r.twoFactor.FailedAttempts++
+	return r.twoFactor.FailedAttempts, nil
+}
+
+func (r *fakeTwoFactorRepo) Lock(ctx context.Context, userID primitive.ObjectID, until time.Time) error {
+	r.twoFactor.LockedUntil = &until
+	r.twoFactor.FailedAttempts = 0
+	return nil
+}
+
+func (r *fakeTwoFactorRepo) ResetFailures(ctx context.Context, userID primitive.ObjectID) error {
+	r.twoFactor.FailedAttempts = 0
+	r.twoFactor.LockedUntil = nil
+	return nil
+}
+
+func TestVerifyCode(t *testing.T) {
+	secret, err := infrastructure.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	step := infrastructure.TOTPStep(time.Now())
+	code := func(step int64) string {
+		code, err := infrastructure.TOTPCode(secret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+	recovery, hashes, err := newRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		codes []string
+		// want is the result of the last code
+		want error
+	}{
+		{"current code", []string{code(step)}, nil},
+		{"code replayed", []string{code(step), code(step)}, ErrInvalidTwoFactorCode},
+		{"older code after a newer one", []string{code(step), code(step - 1)}, ErrInvalidTwoFactorCode},
+		{"wrong code", []string{"000000"}, ErrInvalidTwoFactorCode},
+		{"recovery code", []string{recovery[0]}, nil},
+		{"recovery code reused", []string{recovery[0], recovery[0]}, ErrInvalidTwoFactorCode},
+		{"locked after too many wrong codes", []string{"000000", "000000", "000000", "000000", "000000"}, ErrTwoFactorLocked},
+		{"locked even for a right code", []string{"000000", "000000", "000000", "000000", "000000", code(step)}, ErrTwoFactorLocked},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeTwoFactorRepo{twoFactor: &domain.TwoFactor{
+				UserID:        primitive.NewObjectID(),
+				Secret:        secret,
+				Enabled:       true,
+				RecoveryCodes: append([]string(nil), hashes...),
+			}}
+			uc := &TwoFactorUsecase{twoFactor: repo}
+
+			var err error
+			for _, code := range tt.codes {
+				// verifyCode is handed a fresh copy each time, like after Get
+				twoFactor := *repo.twoFactor
+				err = uc.verifyCode(context.Background(), &twoFactor, code)
+			}
+			if err != tt.want {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -6,18 +6,25 @@ import (
 	"fmt"
 	// "log"
 	"math/rand"
+	"time"
 
 	"github.com/sol-tad/Blog-post-Api/domain"
 	"github.com/sol-tad/Blog-post-Api/infrastructure"
 )
 
 type UserUsecase struct {
-	UserRepository domain.UserRepository
+	UserRepository   domain.UserRepository
+	FollowRepository domain.FollowRepository
+	Sessions         *SessionUsecase
+	TwoFactor        *TwoFactorUsecase
 }
 
-func NewUserUsecase(userRepo domain.UserRepository) *UserUsecase{
+func NewUserUsecase(userRepo domain.UserRepository, followRepo domain.FollowRepository, sessions *SessionUsecase, twoFactor *TwoFactorUsecase) *UserUsecase{
 	return &UserUsecase{
-		UserRepository: userRepo,
+		UserRepository:   userRepo,
+		FollowRepository: followRepo,
+		Sessions:         sessions,
+		TwoFactor:        twoFactor,
 	}
 }
 func (uuc *UserUsecase) Register(ctx context.Context, user domain.User) error {
@@ -38,7 +45,7 @@ func (uuc *UserUsecase) Register(ctx context.Context, user domain.User) error {
 	// Generate OTP
 	user.OTPCode = fmt.Sprintf("%06d", rand.Intn(1000000))
 	user.IsVerified = false
-	user.Role = "user"
+	user.Role = domain.DefaultRole
 
 	// Save user
 	_, err := uuc.UserRepository.Register(ctx, user)
@@ -56,66 +63,44 @@ func (uuc *UserUsecase) VerifyOTP(ctx context.Context, email, otp string) error
 
 
 
-func (uuc *UserUsecase) Login(ctx context.Context, username, password string)(accessToken string, refreshToken string, err error) {
+// Login checks the password. Users with two-factor authentication get a
+// challenge token to finish the login with VerifyLogin; everyone else gets
+// their tokens right away.
+func (uuc *UserUsecase) Login(ctx context.Context, username, password string, client domain.SessionClient)(*domain.LoginResult, error) {
 	user, err := uuc.UserRepository.Login(ctx, username)
 	// log.Println("USER&&&&&&&&&&&&&&&",user)
 	if err != nil {
-		return "", "", errors.New("invalid username or password")
+		return nil, errors.New("invalid username or password")
 	}
 
 	//  Check if user is verified
 	if !user.IsVerified {
-		return "", "", errors.New("please verify your email before logging in")
+		return nil, errors.New("please verify your email before logging in")
 	}
 
 	if !infrastructure.CheckPassword(password, user.Password) {
-		return "", "", errors.New("invalid username or password")
+		return nil, errors.New("invalid username or password")
 	}
 
-	accessToken, err = infrastructure.GenerateAccessToken(user.ID.Hex(), user.Role)
-	if err != nil {
-		return "", "", err
-	}
-	refreshToken, err = infrastructure.GenerateRefreshToken(user.ID.Hex())
-	if err != nil {
-		return "", "", err
-	}
-
-	// Store the refresh token
-	err = uuc.UserRepository.SaveRefreshToken(ctx, user.ID.Hex(), refreshToken)
-	if err != nil {
-		return "", "", err
-	}
-
-	return accessToken, refreshToken, nil
+	// Each login is a session of its own, so other devices stay signed in
+	return uuc.TwoFactor.BeginLogin(ctx, &user, client, domain.AuthMethodPassword)
 }
 
+// VerifyLogin is the second login step: it exchanges the challenge token
+// and a TOTP or recovery code for tokens
+func (uuc *UserUsecase) VerifyLogin(ctx context.Context, challengeToken, code string, client domain.SessionClient) (*domain.LoginResult, error) {
+	return uuc.TwoFactor.CompleteLogin(ctx, challengeToken, code, client)
+}
 
-func (uuc *UserUsecase) RefreshToken(ctx context.Context, refreshToken string)(string, error){
-
-
-    userID, err := infrastructure.VerifyRefreshToken(refreshToken)
-    if err != nil {
-        return "", err
-    }
-
-	ok, err := uuc.UserRepository.VerifyRefreshToken(ctx, userID, refreshToken)
-    if err != nil || !ok {
-        return "", errors.New("invalid or expired refresh token")
-    }
-
-	    // Generate new access token
-    user, err := uuc.UserRepository.FindByID(ctx, userID)
-    if err != nil {
-        return "", err
-    }
-
-    return infrastructure.GenerateAccessToken(user.ID.Hex(), user.Role)
 
+// RefreshToken rotates the refresh token and returns it with a new access token
+func (uuc *UserUsecase) RefreshToken(ctx context.Context, refreshToken string, client domain.SessionClient)(string, string, error){
+	return uuc.Sessions.Refresh(ctx, refreshToken, client)
 }
 
-func (uuc *UserUsecase) Logout(ctx context.Context, userID string) error {
-    return uuc.UserRepository.DeleteRefreshToken(ctx, userID)
+// Logout ends the session the request was made from and revokes its access token
+func (uuc *UserUsecase) Logout(ctx context.Context, userID, sessionID, tokenID string, tokenExpiresAt time.Time) error {
+    return uuc.Sessions.EndSession(ctx, userID, sessionID, tokenID, tokenExpiresAt)
 }
 
 
@@ -144,26 +129,38 @@ func (u *UserUsecase) ResetPassword(ctx context.Context, email, otp, newPassword
 		return err
 	}
 
-	return u.UserRepository.UpdatePasswordByEmail(ctx, email, hashedPassword)
-}
-
+	if err := u.UserRepository.UpdatePasswordByEmail(ctx, email, hashedPassword); err != nil {
+		return err
+	}
 
-func (uuc *UserUsecase) PromoteUser(ctx context.Context, adminID string, targetUserID string) error {
-	admin, err := uuc.UserRepository.FindByID(ctx, adminID)
-	if err != nil || admin.Role != "admin" {
-		return errors.New("unauthorized")
+	// Whoever knew the old password may still be signed in somewhere
+	user, err := u.UserRepository.FindByEmail(ctx, email)
+	if err != nil {
+		return err
 	}
-	return uuc.UserRepository.UpdateUserRole(ctx, targetUserID, "admin")
+	return u.Sessions.RevokeAll(ctx, user.ID.Hex(), domain.SessionRevokedPasswordReset)
 }
 
-func (uuc *UserUsecase) DemoteUser(ctx context.Context, adminID string, targetUserID string) error {
-	admin, err := uuc.UserRepository.FindByID(ctx, adminID)
-	if err != nil || admin.Role != "admin" {
-		return errors.New("unauthorized")
+
+func (uuc *UserUsecase) UpdateProfile(ctx context.Context, userID string, updated domain.User) (domain.User, error) {
+	user, err := uuc.UserRepository.UpdateProfile(ctx, userID, updated)
+	if err != nil {
+		return user, err
 	}
-	return uuc.UserRepository.UpdateUserRole(ctx, targetUserID, "user")
+	return user, uuc.fillFollowCounts(&user)
 }
 
-func (uuc *UserUsecase) UpdateProfile(ctx context.Context, userID string, updated domain.User) (domain.User, error) {
-	return uuc.UserRepository.UpdateProfile(ctx, userID, updated)
+// fillFollowCounts sets the computed follower and following counts
+func (uuc *UserUsecase) fillFollowCounts(user *domain.User) error {
+	followers, err := uuc.FollowRepository.CountFollowers(user.ID)
+	if err != nil {
+		return err
+	}
+	following, err := uuc.FollowRepository.CountFollowing(user.ID)
+	if err != nil {
+		return err
+	}
+	user.FollowersCount = followers
+	user.FollowingCount = following
+	return nil
 }